@@ -24,6 +24,10 @@ func Start(ctx context.Context) {
 		panic(err)
 	}
 
+	jobCtx, stopJobs := context.WithCancel(ctx)
+
+	startJobs(jobCtx, ctr)
+
 	r := router.Init(ctr)
 
 	server := server.NewHTTPServer(conf, r)
@@ -36,6 +40,8 @@ func Start(ctx context.Context) {
 
 	<-c
 
+	stopJobs()
+
 	Destruct(ctx, ctr, server)
 
 	os.Exit(0)
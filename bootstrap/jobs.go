@@ -0,0 +1,76 @@
+package bootstrap
+
+import (
+	"context"
+	"log"
+	"no-q-solution/domain/usecases"
+	"no-q-solution/utils/container"
+	"time"
+)
+
+func startJobs(ctx context.Context, ctr container.Containers) {
+
+	noShows := usecases.NewNoShowUsecase(ctr.Repositories.NoShow, ctr.Repositories.Queue, ctr.Repositories.Merchant)
+	merchants := usecases.NewMerchantUsecase(ctr.Repositories.Merchant, ctr.Adapters.Mailer, ctr.Adapters.Suggest, ctr.Adapters.Blobs)
+	analytics := usecases.NewAnalyticsUsecase(ctr.Repositories.Analytics, ctr.Repositories.Queue)
+	purge := usecases.NewPurgeUsecase(ctr.Repositories.Merchant, ctr.Repositories.Queue, ctr.Repositories.Image, ctr.Adapters.Blobs)
+
+	err := merchants.RefreshSuggestions(ctx)
+	if err != nil {
+		log.Printf("suggestion index failed to load: %v", err)
+	}
+
+	go runEvery(ctx, "suggestion index refresh", 10*time.Minute, merchants.RefreshSuggestions)
+
+	go runEvery(ctx, "no-show detection", 5*time.Minute, func(ctx context.Context) error {
+		marked, err := noShows.DetectNoShows(ctx)
+		if marked > 0 {
+			log.Printf("marked %d reservations as no-show", marked)
+		}
+
+		return err
+	})
+
+	go runEvery(ctx, "analytics rollup", time.Hour, func(ctx context.Context) error {
+		_, err := analytics.Rollup(ctx)
+		return err
+	})
+
+	go runEvery(ctx, "deleted data purge", time.Hour, func(ctx context.Context) error {
+		queues, err := purge.PurgeQueues(ctx)
+		if queues > 0 {
+			log.Printf("purged %d deleted queues", queues)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		merchants, err := purge.PurgeMerchants(ctx)
+		if merchants > 0 {
+			log.Printf("purged %d deleted merchants", merchants)
+		}
+
+		return err
+	})
+}
+
+// runEvery calls job on every tick until the context is cancelled. Failures
+// are logged and retried on the next tick.
+func runEvery(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := job(ctx)
+			if err != nil {
+				log.Printf("%s failed: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Command merge_customers folds duplicate user rows created by repeated
+// bookings into a single customer and re-points their reservations.
+//
+// Run it from the repository root so the configuration files are found:
+//
+//	go run ./cmd/merge_customers -dry-run
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"no-q-solution/domain/usecases"
+	"no-q-solution/utils/config"
+	"no-q-solution/utils/container"
+)
+
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	dryRun := flag.Bool("dry-run", false, "report the merges without writing them")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	conf, err := config.Parse()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctr, err := container.Resolve(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer ctr.Adapters.Db.Close()
+
+	queue := usecases.NewQueuetUsecase(ctr.Repositories.Queue, ctr.Repositories.Customer, ctr.Repositories.NoShow, ctr.Adapters.CheckIn, ctr.Adapters.Hub)
+	customer := usecases.NewCustomerUsecase(ctr.Repositories.Customer, ctr.Adapters.SMS, queue)
+
+	merges, err := customer.MergeDuplicates(ctx, *dryRun)
+
+	removed := 0
+
+	for _, merge := range merges {
+		removed += len(merge.DuplicateIDs)
+
+		log.Printf("user %d (%s) <- %v", merge.Keeper.ID, merge.Keeper.Phone, merge.DuplicateIDs)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("%d customers updated, %d duplicate rows merged (dry run: %t)", len(merges), removed, *dryRun)
+}
@@ -0,0 +1,98 @@
+// Command privacy answers data subject requests from the command line. It
+// exports everything held about a phone, email or merchant as JSON, or
+// erases it. Every run is recorded in the privacy audit table.
+//
+// Run it from the repository root so the configuration files are found:
+//
+//	go run ./cmd/privacy -action export -phone 0779497842 -out export.json
+//	go run ./cmd/privacy -action erase -merchant 12
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/usecases"
+	"no-q-solution/utils/config"
+	"no-q-solution/utils/container"
+	"os"
+	"os/user"
+)
+
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	action := flag.String("action", entities.PrivacyExport, "export or erase")
+	phone := flag.String("phone", "", "customer phone number")
+	email := flag.String("email", "", "customer email address")
+	merchantID := flag.Int64("merchant", 0, "merchant id")
+	out := flag.String("out", "", "file to write the export to, defaults to stdout")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	conf, err := config.Parse()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctr, err := container.Resolve(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer ctr.Adapters.Db.Close()
+
+	privacy := usecases.NewPrivacyUsecase(ctr.Repositories.Privacy, ctr.Repositories.Merchant, ctr.Adapters.Blobs)
+
+	subject := entities.PrivacySubject{
+		Phone:      *phone,
+		Email:      *email,
+		MerchantID: *merchantID,
+	}
+
+	requestedBy := "cli"
+	if current, err := user.Current(); err == nil {
+		requestedBy = "cli:" + current.Username
+	}
+
+	switch *action {
+	case entities.PrivacyExport:
+		archive, err := privacy.Export(ctx, subject, requestedBy)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		output := os.Stdout
+
+		if len(*out) != 0 {
+			output, err = os.OpenFile(*out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			defer output.Close()
+		}
+
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+
+		err = encoder.Encode(archive)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+	case entities.PrivacyErase:
+		affected, err := privacy.Erase(ctx, subject, requestedBy)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("erased %d records", affected)
+
+	default:
+		log.Fatalf("unknown action %q", *action)
+	}
+}
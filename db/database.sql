@@ -4,8 +4,13 @@ use db;
 
 CREATE TABLE IF NOT EXISTS category (
     category varchar(120) NOT NULL primary key,
+    parent varchar(120) NULL DEFAULT NULL,
+    icon varchar(255) NOT NULL DEFAULT '',
+    position int unsigned NOT NULL DEFAULT 0,
+    retired_at timestamp NULL DEFAULT NULL,
     created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT category_parent_fk FOREIGN KEY (parent) REFERENCES category (category)
 );
 
 INSERT INTO category (category) VALUES ("Health");
@@ -19,50 +24,117 @@ CREATE TABLE IF NOT EXISTS merchant (
     id int unsigned NOT NULL auto_increment primary key,
     category varchar(120) NOT NULL,
     name varchar(120) NOT NULL,
-    email varchar(120) UNIQUE NOT NULL,
+    email varchar(120) NOT NULL,
     password varchar(1024) NOT NULL,
+    country char(2) NOT NULL DEFAULT 'LK',
     facebook varchar(255) NOT NULL DEFAULT '',
     instagram varchar(255) NOT NULL DEFAULT '',
     website varchar(255) NOT NULL DEFAULT '',
+    rating_avg decimal(3,2) NOT NULL DEFAULT 0,
+    rating_count int unsigned NOT NULL DEFAULT 0,
+    verified_at timestamp NULL DEFAULT NULL,
+    status varchar(10) NOT NULL DEFAULT 'pending',
+    status_reason varchar(255) NOT NULL DEFAULT '',
+    status_changed_at timestamp NULL DEFAULT NULL,
+    deleted_at timestamp NULL DEFAULT NULL,
+    live tinyint GENERATED ALWAYS AS (IF(deleted_at IS NULL, 1, NULL)) STORED,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY merchant_email (email, live),
+    KEY merchant_status (status),
+    KEY merchant_deleted (deleted_at),
+    CONSTRAINT merchant_category_fk FOREIGN KEY (category) REFERENCES category (category)
+);
+
+CREATE TABLE IF NOT EXISTS staff (
+    id int unsigned NOT NULL auto_increment primary key,
+    merchant_id int unsigned NOT NULL,
+    name varchar(120) NOT NULL,
+    email varchar(120) NOT NULL,
+    password varchar(1024) NOT NULL,
+    role varchar(20) NOT NULL,
+    deleted_at timestamp NULL DEFAULT NULL,
+    live tinyint GENERATED ALWAYS AS (IF(deleted_at IS NULL, 1, NULL)) STORED,
     created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    CONSTRAINT merchant_category_fk FOREIGN KEY (category) REFERENCES category (category) ON DELETE CASCADE
+    UNIQUE KEY staff_email (email, live),
+    KEY staff_merchant (merchant_id),
+    CONSTRAINT staff_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
 );
 
 CREATE TABLE IF NOT EXISTS token (
     token_id int unsigned NOT NULL auto_increment primary key,
     merchant_id int unsigned NOT NULL,
+    staff_id int unsigned NULL DEFAULT NULL,
     auth_token varchar(255) NOT NULL,
     created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    CONSTRAINT token_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+    CONSTRAINT token_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE,
+    CONSTRAINT token_staff_fk FOREIGN KEY (staff_id) REFERENCES staff (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS branch (
+    id int unsigned NOT NULL auto_increment primary key,
+    merchant_id int unsigned NOT NULL,
+    name varchar(120) NOT NULL,
+    address varchar(255) NOT NULL DEFAULT '',
+    city varchar(120) NOT NULL DEFAULT '',
+    postal_code varchar(20) NOT NULL DEFAULT '',
+    country char(2) NOT NULL DEFAULT 'LK',
+    latitude decimal(9,6) NULL DEFAULT NULL,
+    longitude decimal(9,6) NULL DEFAULT NULL,
+    phone varchar(16) NOT NULL DEFAULT '',
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    KEY branch_merchant (merchant_id),
+    KEY branch_location (latitude, longitude),
+    CONSTRAINT branch_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS branch_hours (
+    id int unsigned NOT NULL auto_increment primary key,
+    branch_id int unsigned NOT NULL,
+    weekday tinyint unsigned NOT NULL,
+    opens_at time NOT NULL,
+    closes_at time NOT NULL,
+    KEY branch_hours_branch (branch_id, weekday),
+    CONSTRAINT branch_hours_branch_fk FOREIGN KEY (branch_id) REFERENCES branch (id) ON DELETE CASCADE
 );
 
 CREATE TABLE IF NOT EXISTS queue (
     id int unsigned NOT NULL auto_increment primary key,
     merchant_id int unsigned NOT NULL,
+    branch_id int unsigned NOT NULL,
     name varchar(120) NOT NULL,
     intervals int unsigned NOT NULL,
     start_time timestamp NOT NULL,
     end_time timestamp NOT NULL,
     is_available tinyint(1) NOT NULL DEFAULT "0",
     created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE KEY merchant_queue_name (merchant_id, name),
-    CONSTRAINT queue_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+    deleted_at timestamp NULL DEFAULT NULL,
+    live tinyint GENERATED ALWAYS AS (IF(deleted_at IS NULL, 1, NULL)) STORED,
+    UNIQUE KEY merchant_queue_name (merchant_id, name, live),
+    KEY queue_deleted (deleted_at),
+    CONSTRAINT queue_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE,
+    CONSTRAINT queue_branch_fk FOREIGN KEY (branch_id) REFERENCES branch (id) ON DELETE CASCADE
 );
 
 CREATE TABLE IF NOT EXISTS unavailable (
     id int unsigned NOT NULL auto_increment primary key,
     queue_id int unsigned NOT NULL,
     date timestamp NOT NULL,
+    KEY unavailable_queue_date (queue_id, date),
     CONSTRAINT unavailable_queue_fk FOREIGN KEY (queue_id) REFERENCES queue (id) ON DELETE CASCADE
 );
 
 CREATE TABLE IF NOT EXISTS user (
     id int unsigned NOT NULL auto_increment primary key,
     name varchar(120) NOT NULL,
-    phone varchar(10) NOT NULL,
+    phone varchar(16) NOT NULL,
     email varchar(120),
-    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+    erased_at timestamp NULL DEFAULT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY user_phone (phone),
+    KEY user_email (email)
 );
 
 CREATE TABLE IF NOT EXISTS reserved_slots (
@@ -71,6 +143,162 @@ CREATE TABLE IF NOT EXISTS reserved_slots (
     start_time timestamp NOT NULL,
     end_time timestamp NOT NULL,
     reserved_by int unsigned NOT NULL,
+    arrived_at timestamp NULL DEFAULT NULL,
+    called_at timestamp NULL DEFAULT NULL,
+    no_show_at timestamp NULL DEFAULT NULL,
     created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY reserved_slots_queue_start (queue_id, start_time),
     CONSTRAINT slot_user_fk FOREIGN KEY (reserved_by) REFERENCES user (id) ON DELETE CASCADE
-);
\ No newline at end of file
+);
+
+CREATE TABLE IF NOT EXISTS cancelled_slots (
+    token_no int unsigned NOT NULL primary key,
+    queue_id int unsigned NOT NULL,
+    start_time timestamp NOT NULL,
+    reserved_at timestamp NOT NULL,
+    cancelled_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY cancelled_slots_queue_start (queue_id, start_time),
+    CONSTRAINT cancelled_slots_queue_fk FOREIGN KEY (queue_id) REFERENCES queue (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS display_key (
+    id int unsigned NOT NULL auto_increment primary key,
+    merchant_id int unsigned NOT NULL,
+    display_key varchar(64) UNIQUE NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at timestamp NULL DEFAULT NULL,
+    CONSTRAINT display_key_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS customer_otp (
+    id int unsigned NOT NULL auto_increment primary key,
+    phone varchar(16) NOT NULL,
+    code_hash varchar(64) NOT NULL,
+    attempts int unsigned NOT NULL DEFAULT 0,
+    expires_at timestamp NOT NULL,
+    consumed_at timestamp NULL DEFAULT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY customer_otp_phone (phone)
+);
+
+CREATE TABLE IF NOT EXISTS customer_token (
+    token_id int unsigned NOT NULL auto_increment primary key,
+    user_id int unsigned NOT NULL,
+    auth_token varchar(255) NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT customer_token_user_fk FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS privacy_audit (
+    id int unsigned NOT NULL auto_increment primary key,
+    action varchar(20) NOT NULL,
+    subject_phone_hash varchar(64) NOT NULL DEFAULT '',
+    subject_email_hash varchar(64) NOT NULL DEFAULT '',
+    subject_merchant_id int unsigned NOT NULL DEFAULT 0,
+    requested_by varchar(120) NOT NULL,
+    affected int unsigned NOT NULL DEFAULT 0,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS no_show_policy (
+    merchant_id int unsigned NOT NULL primary key,
+    enabled tinyint(1) NOT NULL DEFAULT "0",
+    max_no_shows int unsigned NOT NULL DEFAULT 3,
+    window_days int unsigned NOT NULL DEFAULT 60,
+    block_days int unsigned NOT NULL DEFAULT 30,
+    auto_detect tinyint(1) NOT NULL DEFAULT "0",
+    grace_minutes int unsigned NOT NULL DEFAULT 15,
+    auto_detect_since timestamp NULL DEFAULT NULL,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT no_show_policy_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS customer_restriction (
+    id int unsigned NOT NULL auto_increment primary key,
+    merchant_id int unsigned NOT NULL,
+    phone varchar(16) NOT NULL,
+    mode varchar(10) NOT NULL,
+    until timestamp NULL DEFAULT NULL,
+    reason varchar(255) NOT NULL DEFAULT '',
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY customer_restriction_phone (merchant_id, phone),
+    CONSTRAINT customer_restriction_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS review (
+    id int unsigned NOT NULL auto_increment primary key,
+    token_no int unsigned NOT NULL UNIQUE,
+    merchant_id int unsigned NOT NULL,
+    user_id int unsigned NOT NULL,
+    rating tinyint unsigned NOT NULL,
+    body text NOT NULL,
+    reply text NULL,
+    replied_at timestamp NULL DEFAULT NULL,
+    status varchar(10) NOT NULL DEFAULT 'published',
+    moderation_reason varchar(255) NOT NULL DEFAULT '',
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    KEY review_merchant_status (merchant_id, status),
+    CONSTRAINT review_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE,
+    CONSTRAINT review_user_fk FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS merchant_verification (
+    id int unsigned NOT NULL auto_increment primary key,
+    merchant_id int unsigned NOT NULL,
+    purpose varchar(20) NOT NULL,
+    email varchar(120) NOT NULL,
+    token_hash char(64) NOT NULL UNIQUE,
+    expires_at timestamp NOT NULL,
+    used_at timestamp NULL DEFAULT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY merchant_verification_merchant (merchant_id, purpose),
+    CONSTRAINT merchant_verification_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS merchant_image (
+    id int unsigned NOT NULL auto_increment primary key,
+    merchant_id int unsigned NOT NULL,
+    kind varchar(10) NOT NULL,
+    blob_key varchar(255) NOT NULL,
+    thumbnail_key varchar(255) NOT NULL,
+    width int unsigned NOT NULL,
+    height int unsigned NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY merchant_image_merchant (merchant_id, kind),
+    CONSTRAINT merchant_image_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS queue_daily_stats (
+    queue_id int unsigned NOT NULL,
+    merchant_id int unsigned NOT NULL,
+    day date NOT NULL,
+    offered int unsigned NOT NULL DEFAULT 0,
+    booked int unsigned NOT NULL DEFAULT 0,
+    cancelled int unsigned NOT NULL DEFAULT 0,
+    no_shows int unsigned NOT NULL DEFAULT 0,
+    checked_in int unsigned NOT NULL DEFAULT 0,
+    lead_minutes bigint unsigned NOT NULL DEFAULT 0,
+    PRIMARY KEY (queue_id, day),
+    KEY queue_daily_stats_merchant_day (merchant_id, day),
+    KEY queue_daily_stats_day (day),
+    CONSTRAINT queue_daily_stats_queue_fk FOREIGN KEY (queue_id) REFERENCES queue (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS queue_hourly_stats (
+    queue_id int unsigned NOT NULL,
+    merchant_id int unsigned NOT NULL,
+    day date NOT NULL,
+    hour tinyint unsigned NOT NULL,
+    bookings int unsigned NOT NULL DEFAULT 0,
+    PRIMARY KEY (queue_id, day, hour),
+    KEY queue_hourly_stats_merchant_day (merchant_id, day),
+    KEY queue_hourly_stats_day (day),
+    CONSTRAINT queue_hourly_stats_queue_fk FOREIGN KEY (queue_id) REFERENCES queue (id) ON DELETE CASCADE
+);
+
+-- days whose stats have been rolled up
+CREATE TABLE IF NOT EXISTS analytics_rollup (
+    day date NOT NULL primary key,
+    rolled_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+use db;
+
+ALTER TABLE reserved_slots ADD COLUMN arrived_at timestamp NULL DEFAULT NULL AFTER reserved_by;
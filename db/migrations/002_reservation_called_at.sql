@@ -0,0 +1,3 @@
+use db;
+
+ALTER TABLE reserved_slots ADD COLUMN called_at timestamp NULL DEFAULT NULL AFTER arrived_at;
@@ -0,0 +1,10 @@
+use db;
+
+CREATE TABLE IF NOT EXISTS display_key (
+    id int unsigned NOT NULL auto_increment primary key,
+    merchant_id int unsigned NOT NULL,
+    display_key varchar(64) UNIQUE NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at timestamp NULL DEFAULT NULL,
+    CONSTRAINT display_key_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+);
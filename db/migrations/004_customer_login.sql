@@ -0,0 +1,20 @@
+use db;
+
+CREATE TABLE IF NOT EXISTS customer_otp (
+    id int unsigned NOT NULL auto_increment primary key,
+    phone varchar(10) NOT NULL,
+    code_hash varchar(64) NOT NULL,
+    attempts int unsigned NOT NULL DEFAULT 0,
+    expires_at timestamp NOT NULL,
+    consumed_at timestamp NULL DEFAULT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY customer_otp_phone (phone)
+);
+
+CREATE TABLE IF NOT EXISTS customer_token (
+    token_id int unsigned NOT NULL auto_increment primary key,
+    user_id int unsigned NOT NULL,
+    auth_token varchar(255) NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT customer_token_user_fk FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+use db;
+
+ALTER TABLE user ADD KEY user_phone (phone), ADD KEY user_email (email);
@@ -0,0 +1,14 @@
+use db;
+
+ALTER TABLE user ADD COLUMN erased_at timestamp NULL DEFAULT NULL AFTER email;
+
+CREATE TABLE IF NOT EXISTS privacy_audit (
+    id int unsigned NOT NULL auto_increment primary key,
+    action varchar(20) NOT NULL,
+    subject_phone_hash varchar(64) NOT NULL DEFAULT '',
+    subject_email_hash varchar(64) NOT NULL DEFAULT '',
+    subject_merchant_id int unsigned NOT NULL DEFAULT 0,
+    requested_by varchar(120) NOT NULL,
+    affected int unsigned NOT NULL DEFAULT 0,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,28 @@
+use db;
+
+ALTER TABLE reserved_slots ADD COLUMN no_show_at timestamp NULL DEFAULT NULL AFTER called_at;
+
+CREATE TABLE IF NOT EXISTS no_show_policy (
+    merchant_id int unsigned NOT NULL primary key,
+    enabled tinyint(1) NOT NULL DEFAULT "0",
+    max_no_shows int unsigned NOT NULL DEFAULT 3,
+    window_days int unsigned NOT NULL DEFAULT 60,
+    block_days int unsigned NOT NULL DEFAULT 30,
+    auto_detect tinyint(1) NOT NULL DEFAULT "0",
+    grace_minutes int unsigned NOT NULL DEFAULT 15,
+    auto_detect_since timestamp NULL DEFAULT NULL,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT no_show_policy_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS customer_restriction (
+    id int unsigned NOT NULL auto_increment primary key,
+    merchant_id int unsigned NOT NULL,
+    phone varchar(10) NOT NULL,
+    mode varchar(10) NOT NULL,
+    until timestamp NULL DEFAULT NULL,
+    reason varchar(255) NOT NULL DEFAULT '',
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY customer_restriction_phone (merchant_id, phone),
+    CONSTRAINT customer_restriction_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+);
@@ -0,0 +1,21 @@
+use db;
+
+ALTER TABLE merchant ADD COLUMN rating_avg decimal(3,2) NOT NULL DEFAULT 0 AFTER website, ADD COLUMN rating_count int unsigned NOT NULL DEFAULT 0 AFTER rating_avg;
+
+CREATE TABLE IF NOT EXISTS review (
+    id int unsigned NOT NULL auto_increment primary key,
+    token_no int unsigned NOT NULL UNIQUE,
+    merchant_id int unsigned NOT NULL,
+    user_id int unsigned NOT NULL,
+    rating tinyint unsigned NOT NULL,
+    body text NOT NULL,
+    reply text NULL,
+    replied_at timestamp NULL DEFAULT NULL,
+    status varchar(10) NOT NULL DEFAULT 'published',
+    moderation_reason varchar(255) NOT NULL DEFAULT '',
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    KEY review_merchant_status (merchant_id, status),
+    CONSTRAINT review_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE,
+    CONSTRAINT review_user_fk FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+use db;
+
+ALTER TABLE merchant ADD COLUMN country char(2) NOT NULL DEFAULT 'LK' AFTER password;
+
+ALTER TABLE user MODIFY phone varchar(16) NOT NULL;
+ALTER TABLE customer_otp MODIFY phone varchar(16) NOT NULL;
+ALTER TABLE customer_restriction MODIFY phone varchar(16) NOT NULL;
+
+-- Phones used to be stored as ten digit national numbers. Every merchant
+-- starts out in LK, so those become +94 followed by the number without its
+-- trunk 0. Rows that do not fit that shape are left for manual review.
+UPDATE user SET phone = CONCAT('+94', SUBSTRING(phone, 2)) WHERE phone REGEXP '^0[0-9]{9}$';
+UPDATE customer_otp SET phone = CONCAT('+94', SUBSTRING(phone, 2)) WHERE phone REGEXP '^0[0-9]{9}$';
+UPDATE customer_restriction SET phone = CONCAT('+94', SUBSTRING(phone, 2)) WHERE phone REGEXP '^0[0-9]{9}$';
@@ -0,0 +1,14 @@
+use db;
+
+CREATE TABLE IF NOT EXISTS merchant_verification (
+    id int unsigned NOT NULL auto_increment primary key,
+    merchant_id int unsigned NOT NULL,
+    purpose varchar(20) NOT NULL,
+    email varchar(120) NOT NULL,
+    token_hash char(64) NOT NULL UNIQUE,
+    expires_at timestamp NOT NULL,
+    used_at timestamp NULL DEFAULT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY merchant_verification_merchant (merchant_id, purpose),
+    CONSTRAINT merchant_verification_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+use db;
+
+ALTER TABLE merchant ADD COLUMN verified_at timestamp NULL DEFAULT NULL AFTER rating_count;
+
+-- Merchants registered before verification existed stay listed.
+UPDATE merchant SET verified_at = created_at, updated_at = updated_at WHERE verified_at IS NULL;
@@ -0,0 +1,19 @@
+use db;
+
+CREATE TABLE IF NOT EXISTS staff (
+    id int unsigned NOT NULL auto_increment primary key,
+    merchant_id int unsigned NOT NULL,
+    name varchar(120) NOT NULL,
+    email varchar(120) UNIQUE NOT NULL,
+    password varchar(1024) NOT NULL,
+    role varchar(20) NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    KEY staff_merchant (merchant_id),
+    CONSTRAINT staff_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+);
+
+-- Existing tokens have no staff member and keep resolving to the owner.
+ALTER TABLE token
+    ADD COLUMN staff_id int unsigned NULL DEFAULT NULL AFTER merchant_id,
+    ADD CONSTRAINT token_staff_fk FOREIGN KEY (staff_id) REFERENCES staff (id) ON DELETE CASCADE;
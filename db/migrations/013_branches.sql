@@ -0,0 +1,41 @@
+use db;
+
+CREATE TABLE IF NOT EXISTS branch (
+    id int unsigned NOT NULL auto_increment primary key,
+    merchant_id int unsigned NOT NULL,
+    name varchar(120) NOT NULL,
+    address varchar(255) NOT NULL DEFAULT '',
+    city varchar(120) NOT NULL DEFAULT '',
+    postal_code varchar(20) NOT NULL DEFAULT '',
+    country char(2) NOT NULL DEFAULT 'LK',
+    latitude decimal(9,6) NULL DEFAULT NULL,
+    longitude decimal(9,6) NULL DEFAULT NULL,
+    phone varchar(16) NOT NULL DEFAULT '',
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    KEY branch_merchant (merchant_id),
+    KEY branch_location (latitude, longitude),
+    CONSTRAINT branch_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS branch_hours (
+    id int unsigned NOT NULL auto_increment primary key,
+    branch_id int unsigned NOT NULL,
+    weekday tinyint unsigned NOT NULL,
+    opens_at time NOT NULL,
+    closes_at time NOT NULL,
+    KEY branch_hours_branch (branch_id, weekday),
+    CONSTRAINT branch_hours_branch_fk FOREIGN KEY (branch_id) REFERENCES branch (id) ON DELETE CASCADE
+);
+
+-- Every existing merchant gets a main branch that takes over its queues.
+INSERT INTO branch (merchant_id, name, country)
+    SELECT id, 'Main', country FROM merchant;
+
+ALTER TABLE queue ADD COLUMN branch_id int unsigned NULL DEFAULT NULL AFTER merchant_id;
+
+UPDATE queue q INNER JOIN branch b on b.merchant_id = q.merchant_id SET q.branch_id = b.id;
+
+ALTER TABLE queue
+    MODIFY branch_id int unsigned NOT NULL,
+    ADD CONSTRAINT queue_branch_fk FOREIGN KEY (branch_id) REFERENCES branch (id) ON DELETE CASCADE;
@@ -0,0 +1,7 @@
+use db;
+
+-- Availability filters on merchant listings count reservations and closed
+-- dates per queue and day.
+ALTER TABLE reserved_slots ADD KEY reserved_slots_queue_start (queue_id, start_time);
+
+ALTER TABLE unavailable ADD KEY unavailable_queue_date (queue_id, date);
@@ -0,0 +1,15 @@
+use db;
+
+ALTER TABLE category
+    ADD COLUMN parent varchar(120) NULL DEFAULT NULL AFTER category,
+    ADD COLUMN icon varchar(255) NOT NULL DEFAULT '' AFTER parent,
+    ADD COLUMN position int unsigned NOT NULL DEFAULT 0 AFTER icon,
+    ADD COLUMN retired_at timestamp NULL DEFAULT NULL AFTER position,
+    ADD CONSTRAINT category_parent_fk FOREIGN KEY (parent) REFERENCES category (category);
+
+-- Removing a category must no longer delete its merchants. Categories are
+-- retired instead, and renames move merchants over explicitly.
+ALTER TABLE merchant DROP FOREIGN KEY merchant_category_fk;
+
+ALTER TABLE merchant
+    ADD CONSTRAINT merchant_category_fk FOREIGN KEY (category) REFERENCES category (category);
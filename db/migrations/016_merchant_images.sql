@@ -0,0 +1,14 @@
+use db;
+
+CREATE TABLE IF NOT EXISTS merchant_image (
+    id int unsigned NOT NULL auto_increment primary key,
+    merchant_id int unsigned NOT NULL,
+    kind varchar(10) NOT NULL,
+    blob_key varchar(255) NOT NULL,
+    thumbnail_key varchar(255) NOT NULL,
+    width int unsigned NOT NULL,
+    height int unsigned NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY merchant_image_merchant (merchant_id, kind),
+    CONSTRAINT merchant_image_merchant_fk FOREIGN KEY (merchant_id) REFERENCES merchant (id) ON DELETE CASCADE
+);
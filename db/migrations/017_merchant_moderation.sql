@@ -0,0 +1,10 @@
+use db;
+
+ALTER TABLE merchant
+    ADD COLUMN status varchar(10) NOT NULL DEFAULT 'pending' AFTER verified_at,
+    ADD COLUMN status_reason varchar(255) NOT NULL DEFAULT '' AFTER status,
+    ADD COLUMN status_changed_at timestamp NULL DEFAULT NULL AFTER status_reason,
+    ADD KEY merchant_status (status);
+
+-- Merchants that were already public stay listed.
+UPDATE merchant SET status = 'approved', updated_at = updated_at;
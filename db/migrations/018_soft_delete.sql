@@ -0,0 +1,11 @@
+use db;
+
+-- Deleted merchants and queues are kept until the purge job removes them
+-- after the restore window.
+ALTER TABLE merchant
+    ADD COLUMN deleted_at timestamp NULL DEFAULT NULL AFTER status_changed_at,
+    ADD KEY merchant_deleted (deleted_at);
+
+ALTER TABLE queue
+    ADD COLUMN deleted_at timestamp NULL DEFAULT NULL AFTER created_at,
+    ADD KEY queue_deleted (deleted_at);
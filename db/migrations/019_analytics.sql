@@ -0,0 +1,47 @@
+use db;
+
+-- Cancelled reservations were deleted without a trace until now, so
+-- cancellation rates only cover cancellations from here on.
+CREATE TABLE IF NOT EXISTS cancelled_slots (
+    token_no int unsigned NOT NULL primary key,
+    queue_id int unsigned NOT NULL,
+    start_time timestamp NOT NULL,
+    reserved_at timestamp NOT NULL,
+    cancelled_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY cancelled_slots_queue_start (queue_id, start_time),
+    CONSTRAINT cancelled_slots_queue_fk FOREIGN KEY (queue_id) REFERENCES queue (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS queue_daily_stats (
+    queue_id int unsigned NOT NULL,
+    merchant_id int unsigned NOT NULL,
+    day date NOT NULL,
+    offered int unsigned NOT NULL DEFAULT 0,
+    booked int unsigned NOT NULL DEFAULT 0,
+    cancelled int unsigned NOT NULL DEFAULT 0,
+    no_shows int unsigned NOT NULL DEFAULT 0,
+    checked_in int unsigned NOT NULL DEFAULT 0,
+    lead_minutes bigint unsigned NOT NULL DEFAULT 0,
+    PRIMARY KEY (queue_id, day),
+    KEY queue_daily_stats_merchant_day (merchant_id, day),
+    KEY queue_daily_stats_day (day),
+    CONSTRAINT queue_daily_stats_queue_fk FOREIGN KEY (queue_id) REFERENCES queue (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS queue_hourly_stats (
+    queue_id int unsigned NOT NULL,
+    merchant_id int unsigned NOT NULL,
+    day date NOT NULL,
+    hour tinyint unsigned NOT NULL,
+    bookings int unsigned NOT NULL DEFAULT 0,
+    PRIMARY KEY (queue_id, day, hour),
+    KEY queue_hourly_stats_merchant_day (merchant_id, day),
+    KEY queue_hourly_stats_day (day),
+    CONSTRAINT queue_hourly_stats_queue_fk FOREIGN KEY (queue_id) REFERENCES queue (id) ON DELETE CASCADE
+);
+
+-- days whose stats have been rolled up
+CREATE TABLE IF NOT EXISTS analytics_rollup (
+    day date NOT NULL primary key,
+    rolled_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,25 @@
+use db;
+
+-- Deleted merchants, their staff and deleted queues release their email
+-- and name: live is 1 for rows that are not deleted and NULL otherwise, and
+-- NULLs never collide in a unique key. Restoring a row whose email or name
+-- was taken in the meantime is refused by the application.
+ALTER TABLE merchant
+    ADD COLUMN live tinyint GENERATED ALWAYS AS (IF(deleted_at IS NULL, 1, NULL)) STORED AFTER deleted_at,
+    DROP INDEX email,
+    ADD UNIQUE KEY merchant_email (email, live);
+
+ALTER TABLE staff
+    ADD COLUMN deleted_at timestamp NULL DEFAULT NULL AFTER role,
+    ADD COLUMN live tinyint GENERATED ALWAYS AS (IF(deleted_at IS NULL, 1, NULL)) STORED AFTER deleted_at,
+    DROP INDEX email,
+    ADD UNIQUE KEY staff_email (email, live);
+
+UPDATE staff s INNER JOIN merchant m on m.id = s.merchant_id
+SET s.deleted_at = m.deleted_at, s.updated_at = s.updated_at
+WHERE m.deleted_at IS NOT NULL;
+
+ALTER TABLE queue
+    ADD COLUMN live tinyint GENERATED ALWAYS AS (IF(deleted_at IS NULL, 1, NULL)) STORED AFTER deleted_at,
+    DROP INDEX merchant_queue_name,
+    ADD UNIQUE KEY merchant_queue_name (merchant_id, name, live);
@@ -0,0 +1,74 @@
+package entities
+
+import "time"
+
+// AnalyticsQuery selects the reservations analytics are computed from. From
+// and To are calendar days, both included. A zero MerchantID or QueueID
+// means every merchant or queue.
+type AnalyticsQuery struct {
+	MerchantID int64
+	QueueID    int64
+	From       time.Time
+	To         time.Time
+}
+
+// QueueDay holds the figures of one queue on one day, as stored in the
+// daily rollup. LeadMinutes is the sum over the booked slots. Sums over a
+// date range leave Day zero.
+type QueueDay struct {
+	QueueID     int64
+	MerchantID  int64
+	Day         time.Time
+	Offered     int
+	Booked      int
+	Cancelled   int
+	NoShows     int
+	CheckedIn   int
+	LeadMinutes int64
+}
+
+// QueueHour counts the slots booked on a queue starting in one hour of a
+// day. Sums over a date range keep the weekday and leave Day zero.
+type QueueHour struct {
+	QueueID    int64
+	MerchantID int64
+	Day        time.Time
+	Weekday    time.Weekday
+	Hour       int
+	Bookings   int
+}
+
+type HourCount struct {
+	Hour     int
+	Bookings int
+}
+
+type WeekdayCount struct {
+	Weekday  time.Weekday
+	Bookings int
+}
+
+// Analytics summarises a merchant or a queue over a date range. Rates are
+// fractions between 0 and 1. Queues breaks a merchant summary down per
+// queue and is empty for a single queue.
+type Analytics struct {
+	MerchantID         int64
+	QueueID            int64
+	QueueName          string
+	From               time.Time
+	To                 time.Time
+	Offered            int
+	Booked             int
+	Cancelled          int
+	NoShows            int
+	CheckedIn          int
+	Utilization        float64
+	CancellationRate   float64
+	NoShowRate         float64
+	AverageLeadMinutes float64
+	Hours              []HourCount
+	Weekdays           []WeekdayCount
+	PeakHours          []int
+	BusiestWeekdays    []time.Weekday
+	Queues             []Analytics
+}
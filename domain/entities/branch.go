@@ -0,0 +1,47 @@
+package entities
+
+import "time"
+
+// BranchDefaultName names the branch every merchant is created with.
+const BranchDefaultName = "Main"
+
+// Branch is a location of a merchant. Queues belong to a branch.
+type Branch struct {
+	ID         int64
+	MerchantID int64
+	Name       string
+	Address    string
+	City       string
+	PostalCode string
+	Country    string
+	Latitude   *float64
+	Longitude  *float64
+	Phone      string
+	Hours      []OpeningHours
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// OpeningHours is one opening interval on a weekday, with Sunday as 0. Times
+// are "15:04" in the branch's local time. A day may have several intervals.
+type OpeningHours struct {
+	Weekday int
+	Opens   string
+	Closes  string
+}
+
+// GeoQuery asks for branches within Radius kilometres of a point.
+type GeoQuery struct {
+	Latitude  float64
+	Longitude float64
+	Radius    float64
+	Category  string
+	Limit     int
+}
+
+// NearbyBranch is a geo search result. Distance is in kilometres.
+type NearbyBranch struct {
+	Merchant Merchant
+	Branch   Branch
+	Distance float64
+}
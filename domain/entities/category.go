@@ -2,8 +2,24 @@ package entities
 
 import "time"
 
+// Category groups merchants. Subcategories name their Parent; the hierarchy
+// is two levels deep. Retired categories can no longer be chosen but keep
+// their merchants.
 type Category struct {
 	Name      string
+	Parent    string
+	Icon      string
+	Position  int
+	RetiredAt *time.Time
+	Children  []Category
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+// CategoryUpdate carries a partial category update; nil fields are left
+// unchanged. An empty Parent moves the category to the top level.
+type CategoryUpdate struct {
+	Name   *string
+	Parent *string
+	Icon   *string
+}
@@ -0,0 +1,48 @@
+package entities
+
+import "time"
+
+type OTP struct {
+	ID         int64
+	Phone      string
+	CodeHash   string
+	Attempts   int
+	ExpiresAt  time.Time
+	ConsumedAt *time.Time
+	CreatedAt  time.Time
+}
+
+type CustomerMerge struct {
+	Keeper       User
+	DuplicateIDs []int64
+}
+
+const (
+	BookingBooked    = "booked"
+	BookingCalled    = "called"
+	BookingCheckedIn = "checked_in"
+	BookingMissed    = "missed"
+	BookingNoShow    = "no_show"
+)
+
+type Booking struct {
+	TokenNo      int64
+	QueueID      int64
+	QueueName    string
+	MerchantID   int64
+	MerchantName string
+	StartTime    time.Time
+	EndTime      time.Time
+	Status       string
+	ArrivedAt    *time.Time
+	CalledAt     *time.Time
+	NoShowAt     *time.Time
+	CreatedAt    time.Time
+}
+
+type BookingFilter struct {
+	Upcoming  *bool
+	From      *time.Time
+	To        *time.Time
+	Paginator Paginator
+}
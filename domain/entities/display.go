@@ -0,0 +1,29 @@
+package entities
+
+import "time"
+
+type DisplayKey struct {
+	ID         int64
+	MerchantID int64
+	Key        string
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+}
+
+type DisplayBoard struct {
+	MerchantName string
+	Queues       []DisplayQueue
+	GeneratedAt  time.Time
+}
+
+type DisplayQueue struct {
+	Name         string
+	CurrentToken *ReservedSlots
+	NextTokens   []DisplayToken
+}
+
+type DisplayToken struct {
+	TokenNo       int64
+	StartTime     time.Time
+	EstimatedWait time.Duration
+}
@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+const (
+	ImageLogo    = "logo"
+	ImageGallery = "gallery"
+)
+
+// MerchantImage is an uploaded logo or gallery picture. Key and
+// ThumbnailKey locate the blobs; the URLs are filled in for responses.
+type MerchantImage struct {
+	ID           int64
+	MerchantID   int64
+	Kind         string
+	Key          string
+	ThumbnailKey string
+	URL          string
+	ThumbnailURL string
+	Width        int
+	Height       int
+	CreatedAt    time.Time
+}
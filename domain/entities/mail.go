@@ -0,0 +1,7 @@
+package entities
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
@@ -2,15 +2,85 @@ package entities
 
 import "time"
 
+const (
+	MerchantSortDefault = ""
+	MerchantSortRating  = "rating"
+)
+
+// Moderation statuses of a merchant. Only approved merchants are listed and
+// take reservations.
+const (
+	MerchantPending   = "pending"
+	MerchantApproved  = "approved"
+	MerchantSuspended = "suspended"
+	MerchantRejected  = "rejected"
+)
+
+// RestoreWindow is how long a deleted merchant or queue can be restored
+// before it is purged for good.
+const RestoreWindow = 30 * 24 * time.Hour
+
 type Merchant struct {
-	ID        int64
-	Name      string
-	Category  string
-	Email     string
-	Password  string
-	Facebook  string
-	Instagram string
-	Website   string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID           int64
+	Name         string
+	Category     string
+	Email        string
+	PendingEmail string
+	Password     string
+	Country      string
+	Facebook     string
+	Instagram    string
+	Website      string
+	Rating       float64
+	RatingCount  int
+	VerifiedAt   *time.Time
+	Status       string
+	StatusReason string
+	StatusAt     *time.Time
+	DeletedAt    *time.Time
+	Branches     []Branch
+	Logo         *MerchantImage
+	Gallery      []MerchantImage
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// MerchantFilter narrows merchant listings by queue availability. FreeOn is
+// a calendar day; Now is the moment "open now" and "today" refer to.
+type MerchantFilter struct {
+	OpenNow   bool
+	FreeToday bool
+	FreeOn    *time.Time
+	Now       time.Time
+}
+
+// MerchantUpdate carries a partial profile update; nil fields are left
+// unchanged. CurrentPassword is required to change the email or password.
+type MerchantUpdate struct {
+	Name            *string
+	Category        *string
+	Email           *string
+	Country         *string
+	Facebook        *string
+	Instagram       *string
+	Website         *string
+	Password        *string
+	CurrentPassword string
+}
+
+const (
+	VerificationEmailChange   = "email_change"
+	VerificationPasswordReset = "password_reset"
+	VerificationSignUp        = "sign_up"
+)
+
+type MerchantVerification struct {
+	ID         int64
+	MerchantID int64
+	Purpose    string
+	Email      string
+	TokenHash  string
+	ExpiresAt  time.Time
+	UsedAt     *time.Time
+	CreatedAt  time.Time
 }
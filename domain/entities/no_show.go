@@ -0,0 +1,28 @@
+package entities
+
+import "time"
+
+const (
+	RestrictionBlock = "block"
+	RestrictionAllow = "allow"
+)
+
+type NoShowPolicy struct {
+	MerchantID   int64
+	Enabled      bool
+	MaxNoShows   int
+	WindowDays   int
+	BlockDays    int
+	AutoDetect   bool
+	GraceMinutes int
+}
+
+type CustomerRestriction struct {
+	ID         int64
+	MerchantID int64
+	Phone      string
+	Mode       string
+	Until      *time.Time
+	Reason     string
+	CreatedAt  time.Time
+}
@@ -0,0 +1,44 @@
+package entities
+
+import "time"
+
+const (
+	PrivacyExport = "export"
+	PrivacyErase  = "erase"
+)
+
+type PrivacySubject struct {
+	Phone      string
+	Email      string
+	MerchantID int64
+}
+
+type Session struct {
+	CreatedAt time.Time
+}
+
+type PrivacyArchive struct {
+	Subject          PrivacySubject
+	GeneratedAt      time.Time
+	Customers        []User
+	Bookings         []Booking
+	OTPRequests      []OTP
+	CustomerSessions []Session
+	Restrictions     []CustomerRestriction
+	Reviews          []Review
+	Merchant         *Merchant
+	Queues           []Queue
+	Branches         []Branch
+	Staff            []Staff
+	Images           []MerchantImage
+	DisplayKeys      []DisplayKey
+	MerchantSessions []Session
+}
+
+type PrivacyAudit struct {
+	Action      string
+	Subject     PrivacySubject
+	RequestedBy string
+	Affected    int
+	CreatedAt   time.Time
+}
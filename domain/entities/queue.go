@@ -5,6 +5,7 @@ import "time"
 type Queue struct {
 	ID               int64
 	MerchantID       int64
+	BranchID         int64
 	Name             string
 	Interval         int // minutes
 	StartTime        time.Time
@@ -13,6 +14,7 @@ type Queue struct {
 	UnavailableDates []time.Time
 	ReservedSlots    []ReservedSlots
 	CreatedAt        time.Time
+	DeletedAt        *time.Time
 }
 
 type UnavailableDates struct {
@@ -21,12 +23,22 @@ type UnavailableDates struct {
 }
 
 type ReservedSlots struct {
-	TokenNo    int64
-	QueueID    int64
-	StartTime  time.Time
-	EndTime    time.Time
-	ReservedBy User
-	CreatedAt  time.Time
+	TokenNo     int64
+	QueueID     int64
+	StartTime   time.Time
+	EndTime     time.Time
+	ReservedBy  User
+	CheckInCode string
+	ArrivedAt   *time.Time
+	CalledAt    *time.Time
+	NoShowAt    *time.Time
+	CreatedAt   time.Time
+}
+
+type CheckIn struct {
+	TokenNo   int64
+	QueueID   int64
+	StartTime time.Time
 }
 
 type User struct {
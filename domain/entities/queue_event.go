@@ -0,0 +1,21 @@
+package entities
+
+import "time"
+
+const (
+	EventSlotReserved = "slot_reserved"
+	EventSlotFreed    = "slot_freed"
+	EventDateClosed   = "date_closed"
+	EventTokenCalled  = "token_called"
+)
+
+type QueueEvent struct {
+	Type       string
+	QueueID    int64
+	TokenNo    int64
+	StartTime  time.Time
+	EndTime    time.Time
+	Date       time.Time
+	ReservedBy *User
+	OccurredAt time.Time
+}
@@ -0,0 +1,25 @@
+package entities
+
+import "time"
+
+const (
+	ReviewPublished = "published"
+	ReviewFlagged   = "flagged"
+	ReviewHidden    = "hidden"
+)
+
+type Review struct {
+	ID               int64
+	TokenNo          int64
+	MerchantID       int64
+	UserID           int64
+	Reviewer         string
+	Rating           int
+	Body             string
+	Reply            string
+	RepliedAt        *time.Time
+	Status           string
+	ModerationReason string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
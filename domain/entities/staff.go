@@ -0,0 +1,71 @@
+package entities
+
+import "time"
+
+const (
+	RoleOwner     = "owner"
+	RoleManager   = "manager"
+	RoleFrontDesk = "front_desk"
+)
+
+const (
+	PermissionManageAccount  = "manage_account"
+	PermissionManageStaff    = "manage_staff"
+	PermissionManageBranches = "manage_branches"
+	PermissionManageQueues   = "manage_queues"
+	PermissionManagePolicies = "manage_policies"
+	PermissionManageDisplays = "manage_displays"
+	PermissionManageReviews  = "manage_reviews"
+	PermissionServeCustomers = "serve_customers"
+	PermissionViewMerchant   = "view_merchant"
+	PermissionViewAnalytics  = "view_analytics"
+)
+
+// RolePermissions lists what each role may do. The owner signs in with the
+// merchant's own email and password; everyone else has a staff account.
+var RolePermissions = map[string][]string{
+	RoleOwner: {
+		PermissionManageAccount,
+		PermissionManageStaff,
+		PermissionManageBranches,
+		PermissionManageQueues,
+		PermissionManagePolicies,
+		PermissionManageDisplays,
+		PermissionManageReviews,
+		PermissionServeCustomers,
+		PermissionViewMerchant,
+		PermissionViewAnalytics,
+	},
+	RoleManager: {
+		PermissionManageBranches,
+		PermissionManageQueues,
+		PermissionManagePolicies,
+		PermissionManageDisplays,
+		PermissionManageReviews,
+		PermissionServeCustomers,
+		PermissionViewMerchant,
+		PermissionViewAnalytics,
+	},
+	RoleFrontDesk: {
+		PermissionServeCustomers,
+		PermissionViewMerchant,
+	},
+}
+
+// Staff is the identity behind a merchant token. ID is zero for the owner.
+type Staff struct {
+	ID         int64
+	MerchantID int64
+	Name       string
+	Email      string
+	Password   string
+	Role       string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type StaffUpdate struct {
+	Name     *string
+	Role     *string
+	Password *string
+}
@@ -0,0 +1,15 @@
+package entities
+
+const (
+	SuggestionMerchant = "merchant"
+	SuggestionCategory = "category"
+)
+
+// Suggestion is one autocomplete match. MerchantID is only set for
+// merchant suggestions.
+type Suggestion struct {
+	Type       string
+	Text       string
+	MerchantID int64
+	Category   string
+}
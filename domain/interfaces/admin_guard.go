@@ -0,0 +1,5 @@
+package interfaces
+
+type AdminGuard interface {
+	Validate(token string) error
+}
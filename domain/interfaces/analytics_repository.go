@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+	"no-q-solution/domain/entities"
+	"time"
+)
+
+type AnalyticsRepository interface {
+	Compute(ctx context.Context, query entities.AnalyticsQuery) ([]entities.QueueDay, []entities.QueueHour, error)
+	SumRollups(ctx context.Context, query entities.AnalyticsQuery) ([]entities.QueueDay, []entities.QueueHour, error)
+	GetRolledDays(ctx context.Context, from time.Time, to time.Time) ([]time.Time, error)
+	GetRollupStart(ctx context.Context) (*time.Time, error)
+	Rollup(ctx context.Context, day time.Time) (bool, error)
+}
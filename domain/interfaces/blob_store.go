@@ -0,0 +1,9 @@
+package interfaces
+
+import "context"
+
+type BlobStore interface {
+	Put(ctx context.Context, key string, contentType string, data []byte) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
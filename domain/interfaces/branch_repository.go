@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"no-q-solution/domain/entities"
+)
+
+type BranchRepository interface {
+	GetByMerchant(ctx context.Context, merchantID int64) ([]entities.Branch, error)
+	GetSingle(ctx context.Context, merchantID int64, id int64) (*entities.Branch, error)
+	Create(ctx context.Context, branch entities.Branch) (int64, error)
+	Update(ctx context.Context, branch entities.Branch) (bool, error)
+	Delete(ctx context.Context, merchantID int64, id int64) (bool, error)
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"no-q-solution/domain/entities"
+)
+
+type CategoryRepository interface {
+	GetAll(ctx context.Context) ([]entities.Category, error)
+	GetSingle(ctx context.Context, name string) (*entities.Category, error)
+	Create(ctx context.Context, category entities.Category) (bool, error)
+	Update(ctx context.Context, name string, category entities.Category) (bool, error)
+	Reorder(ctx context.Context, names []string) (bool, error)
+	Retire(ctx context.Context, name string) (bool, error)
+	Restore(ctx context.Context, name string) (bool, error)
+}
@@ -0,0 +1,8 @@
+package interfaces
+
+import "no-q-solution/domain/entities"
+
+type CheckInSigner interface {
+	Sign(checkIn entities.CheckIn) string
+	Verify(code string) (entities.CheckIn, error)
+}
@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+	"no-q-solution/domain/entities"
+	"time"
+)
+
+type CustomerRepository interface {
+	CreateOTP(ctx context.Context, phone string, codeHash string, expiresAt time.Time) (int64, error)
+	GetLatestOTP(ctx context.Context, phone string) (*entities.OTP, error)
+	IncrementOTPAttempts(ctx context.Context, id int64, max int) (bool, error)
+	ConsumeOTP(ctx context.Context, id int64) (bool, error)
+	ResolveUser(ctx context.Context, user entities.User) (entities.User, error)
+	GetUsers(ctx context.Context) ([]entities.User, error)
+	MergeUsers(ctx context.Context, keeper entities.User, duplicateIDs []int64) (bool, error)
+	CreateToken(ctx context.Context, userID int64, token string) (string, error)
+	ValidateToken(ctx context.Context, token string) (entities.User, error)
+	Logout(ctx context.Context, userID int64) (bool, error)
+	GetBookings(ctx context.Context, phone string, filter entities.BookingFilter, now time.Time) ([]entities.Booking, error)
+}
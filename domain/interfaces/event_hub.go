@@ -0,0 +1,12 @@
+package interfaces
+
+import "no-q-solution/domain/entities"
+
+type EventHub interface {
+	Publish(event entities.QueueEvent)
+	Subscribe(queueIDs []int64) (<-chan entities.QueueEvent, func())
+	// SubscribeMerchant follows every queue added to the merchant through
+	// AddQueue, including those added after subscribing.
+	SubscribeMerchant(merchantID int64) (<-chan entities.QueueEvent, func())
+	AddQueue(merchantID int64, queueID int64)
+}
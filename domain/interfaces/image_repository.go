@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+	"no-q-solution/domain/entities"
+)
+
+type ImageRepository interface {
+	GetByMerchant(ctx context.Context, merchantID int64, kind string) ([]entities.MerchantImage, error)
+	Create(ctx context.Context, image entities.MerchantImage) (int64, error)
+	Delete(ctx context.Context, merchantID int64, id int64) (*entities.MerchantImage, error)
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+	"no-q-solution/domain/entities"
+)
+
+type Mailer interface {
+	Send(ctx context.Context, mail entities.Mail) error
+	// URL resolves a path on the public site for links in mails.
+	URL(path string) string
+	// ResetPasswordURL links to the page where a password reset token is
+	// redeemed.
+	ResetPasswordURL(token string) string
+}
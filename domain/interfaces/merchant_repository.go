@@ -3,18 +3,44 @@ package interfaces
 import (
 	"context"
 	"no-q-solution/domain/entities"
+	"time"
 )
 
 type MerchantRepository interface {
-	GetAll(ctx context.Context, paginator entities.Paginator) ([]entities.Merchant, error)
+	GetAll(ctx context.Context, paginator entities.Paginator, sort string, filter entities.MerchantFilter) ([]entities.Merchant, error)
 	GetCategories(ctx context.Context) ([]entities.Category, error)
-	GetByCategory(ctx context.Context, category string) ([]entities.Merchant, error)
+	GetListed(ctx context.Context) ([]entities.Merchant, error)
+	GetByCategory(ctx context.Context, category string, sort string, filter entities.MerchantFilter) ([]entities.Merchant, error)
 	GetSingle(ctx context.Context, id int64) (*entities.Merchant, error)
-	Search(ctx context.Context, input string) ([]entities.Merchant, error)
+	GetCountry(ctx context.Context, id int64) (string, error)
+	Search(ctx context.Context, input string, paginator entities.Paginator) ([]entities.Merchant, error)
+	Nearby(ctx context.Context, geo entities.GeoQuery) ([]entities.NearbyBranch, error)
 	Create(ctx context.Context, merchant entities.Merchant) (int64, error)
-	Login(ctx context.Context, login entities.Login) (*entities.Merchant, error)
+	Update(ctx context.Context, merchant entities.Merchant) (bool, error)
+	GetPassword(ctx context.Context, id int64) (string, error)
+	UpdatePassword(ctx context.Context, id int64, password string, keepToken string) (bool, error)
+	MarkVerified(ctx context.Context, id int64) (bool, error)
+	GetByStatus(ctx context.Context, status string, paginator entities.Paginator) ([]entities.Merchant, error)
+	SetStatus(ctx context.Context, id int64, status string, reason string) (bool, error)
+	RehashPassword(ctx context.Context, id int64, old string, hash string) (bool, error)
+	UpdateEmail(ctx context.Context, id int64, email string) (bool, error)
+	CreateVerification(ctx context.Context, verification entities.MerchantVerification) (int64, error)
+	GetVerification(ctx context.Context, purpose string, tokenHash string) (*entities.MerchantVerification, error)
+	GetLatestVerification(ctx context.Context, merchantID int64, purpose string) (*entities.MerchantVerification, error)
+	RevokeVerifications(ctx context.Context, merchantID int64, purpose string) (bool, error)
+	ConsumeVerification(ctx context.Context, id int64) (bool, error)
+	GetByEmail(ctx context.Context, email string) (*entities.Merchant, error)
 	CreateToken(ctx context.Context, merchantID int64, token string) (string, error)
-	ValidateToken(ctx context.Context, token string) (int64, error)
-	Logout(ctx context.Context, merchantID int64) (bool, error)
+	ValidateToken(ctx context.Context, token string) (entities.Staff, error)
+	CreateDisplayKey(ctx context.Context, merchantID int64, key string) (entities.DisplayKey, error)
+	GetDisplayKeys(ctx context.Context, merchantID int64) ([]entities.DisplayKey, error)
+	RevokeDisplayKey(ctx context.Context, merchantID int64, keyID int64) (bool, error)
+	ValidateDisplayKey(ctx context.Context, key string) (int64, error)
+	Logout(ctx context.Context, staff entities.Staff) (bool, error)
 	Delete(ctx context.Context, id int64) (bool, error)
+	GetDeleted(ctx context.Context, paginator entities.Paginator) ([]entities.Merchant, error)
+	GetDeletedByEmail(ctx context.Context, email string) (*entities.Merchant, error)
+	Restore(ctx context.Context, id int64, since time.Time) (bool, error)
+	GetPurgeable(ctx context.Context, before time.Time) ([]int64, error)
+	Purge(ctx context.Context, id int64) (bool, error)
 }
@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"context"
+	"no-q-solution/domain/entities"
+	"time"
+)
+
+type NoShowRepository interface {
+	GetPolicy(ctx context.Context, merchantID int64) (entities.NoShowPolicy, error)
+	SavePolicy(ctx context.Context, policy entities.NoShowPolicy) (bool, error)
+	GetMerchantIDByQueue(ctx context.Context, queueID int64) (int64, error)
+	MarkNoShow(ctx context.Context, tokenNo int64, at time.Time) (bool, error)
+	MarkMissedSlots(ctx context.Context, now time.Time) (int64, error)
+	GetNoShowTimes(ctx context.Context, merchantID int64, phone string, since time.Time) ([]time.Time, error)
+	GetRestrictions(ctx context.Context, merchantID int64) ([]entities.CustomerRestriction, error)
+	GetActiveRestriction(ctx context.Context, merchantID int64, phone string, now time.Time) (*entities.CustomerRestriction, error)
+	CreateRestriction(ctx context.Context, restriction entities.CustomerRestriction) (int64, error)
+	DeleteRestriction(ctx context.Context, merchantID int64, id int64) (bool, error)
+}
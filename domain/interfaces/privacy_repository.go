@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"context"
+	"no-q-solution/domain/entities"
+)
+
+type PrivacyRepository interface {
+	FindUsers(ctx context.Context, phone string, email string) ([]entities.User, error)
+	GetBookings(ctx context.Context, userIDs []int64) ([]entities.Booking, error)
+	GetOTPRequests(ctx context.Context, phones []string) ([]entities.OTP, error)
+	GetCustomerSessions(ctx context.Context, userIDs []int64) ([]entities.Session, error)
+	GetRestrictions(ctx context.Context, phones []string) ([]entities.CustomerRestriction, error)
+	GetReviews(ctx context.Context, userIDs []int64) ([]entities.Review, error)
+	GetMerchant(ctx context.Context, merchantID int64) (*entities.Merchant, error)
+	GetQueues(ctx context.Context, merchantID int64) ([]entities.Queue, error)
+	GetBranches(ctx context.Context, merchantID int64) ([]entities.Branch, error)
+	GetStaff(ctx context.Context, merchantID int64) ([]entities.Staff, error)
+	GetImages(ctx context.Context, merchantID int64) ([]entities.MerchantImage, error)
+	GetMerchantSessions(ctx context.Context, merchantID int64) ([]entities.Session, error)
+	EraseUsers(ctx context.Context, userIDs []int64, phones []string) (bool, error)
+	EraseMerchant(ctx context.Context, merchantID int64) (bool, error)
+	Audit(ctx context.Context, audit entities.PrivacyAudit) (int64, error)
+}
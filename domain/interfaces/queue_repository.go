@@ -15,7 +15,16 @@ type QueueRepository interface {
 	MakeDatesUnAvailable(ctx context.Context, queueID int64, dates []time.Time) (bool, error)
 	Create(ctx context.Context, queue entities.Queue) (entities.Queue, error)
 	ReserveSlot(ctx context.Context, reserve entities.ReservedSlots) (entities.ReservedSlots, error)
+	GetReservation(ctx context.Context, tokenNo int64) (*entities.ReservedSlots, error)
+	CheckIn(ctx context.Context, tokenNo int64, arrivedAt time.Time) (bool, error)
+	RescheduleSlot(ctx context.Context, tokenNo int64, startTime time.Time, endTime time.Time) (bool, error)
+	CallToken(ctx context.Context, tokenNo int64, calledAt time.Time) (bool, error)
 	UnReserveSlot(ctx context.Context, tokenNo int64) (bool, error)
 	Delete(ctx context.Context, merchantID int64, queueID int64) (bool, error)
+	GetDeleted(ctx context.Context, merchantID int64) ([]entities.Queue, error)
+	Restore(ctx context.Context, merchantID int64, queueID int64, since time.Time) (bool, error)
+	Purge(ctx context.Context, before time.Time) (int64, error)
 	IsQueueBelongsToMerchant(ctx context.Context, merchantID int64, queueID int64) (bool, error)
+	GetMerchantCountry(ctx context.Context, queueID int64) (string, error)
+	GetMerchantStatus(ctx context.Context, queueID int64) (string, error)
 }
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"no-q-solution/domain/entities"
+)
+
+type ReviewRepository interface {
+	Create(ctx context.Context, review entities.Review) (int64, error)
+	GetSingle(ctx context.Context, id int64) (*entities.Review, error)
+	GetByMerchant(ctx context.Context, merchantID int64, paginator entities.Paginator) ([]entities.Review, error)
+	GetByStatus(ctx context.Context, status string, paginator entities.Paginator) ([]entities.Review, error)
+	Reply(ctx context.Context, merchantID int64, id int64, reply string) (bool, error)
+	Flag(ctx context.Context, merchantID int64, id int64, reason string) (bool, error)
+	SetStatus(ctx context.Context, id int64, status string, reason string) (bool, error)
+}
@@ -0,0 +1,7 @@
+package interfaces
+
+import "context"
+
+type SMSSender interface {
+	Send(ctx context.Context, phone string, message string) error
+}
@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+	"no-q-solution/domain/entities"
+)
+
+type StaffRepository interface {
+	GetByMerchant(ctx context.Context, merchantID int64) ([]entities.Staff, error)
+	GetSingle(ctx context.Context, merchantID int64, id int64) (*entities.Staff, error)
+	GetByEmail(ctx context.Context, email string) (*entities.Staff, error)
+	Create(ctx context.Context, staff entities.Staff) (int64, error)
+	Update(ctx context.Context, staff entities.Staff) (bool, error)
+	UpdatePassword(ctx context.Context, id int64, password string) (bool, error)
+	RehashPassword(ctx context.Context, id int64, old string, hash string) (bool, error)
+	Delete(ctx context.Context, merchantID int64, id int64) (bool, error)
+	CreateToken(ctx context.Context, staff entities.Staff, token string) (string, error)
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "no-q-solution/domain/entities"
+
+type SuggestIndex interface {
+	Load(merchants []entities.Merchant, categories []entities.Category)
+	PutMerchant(merchant entities.Merchant)
+	RemoveMerchant(id int64)
+	Suggest(prefix string, limit int) []entities.Suggestion
+}
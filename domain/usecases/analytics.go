@@ -0,0 +1,316 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"sort"
+	"time"
+)
+
+const (
+	analyticsDefaultDays = 30
+	analyticsMaxDays     = 366
+	analyticsTop         = 3
+
+	// rollupRecentDays are rolled up again on every run, so late check-ins,
+	// no-shows and cancellations still reach the rollup.
+	rollupRecentDays = 7
+	rollupBatchDays  = 90
+)
+
+// AnalyticsUsecase reports how merchants and queues are used. Days that
+// have been rolled up are read from the daily rollups, the others are
+// computed from the reservations.
+type AnalyticsUsecase struct {
+	repo   interfaces.AnalyticsRepository
+	queues interfaces.QueueRepository
+}
+
+func NewAnalyticsUsecase(repo interfaces.AnalyticsRepository, queues interfaces.QueueRepository) AnalyticsUsecase {
+	usecase := AnalyticsUsecase{
+		repo:   repo,
+		queues: queues,
+	}
+
+	return usecase
+}
+
+// GetForMerchant summarises all queues of the merchant, with a breakdown per
+// queue.
+func (usecase AnalyticsUsecase) GetForMerchant(ctx context.Context, query entities.AnalyticsQuery) (entities.Analytics, error) {
+
+	query.QueueID = 0
+
+	query, err := checkAnalyticsRange(query)
+	if err != nil {
+		return entities.Analytics{}, err
+	}
+
+	queues, err := usecase.queues.GetByMerchant(ctx, query.MerchantID)
+	if err != nil {
+		return entities.Analytics{}, err
+	}
+
+	days, hours, err := usecase.collect(ctx, query)
+	if err != nil {
+		return entities.Analytics{}, err
+	}
+
+	analytics := summarise(query, days, hours)
+	analytics.Queues = make([]entities.Analytics, 0, len(queues))
+
+	for _, queue := range queues {
+		queueDays := make([]entities.QueueDay, 0)
+		queueHours := make([]entities.QueueHour, 0)
+
+		for _, day := range days {
+			if day.QueueID == queue.ID {
+				queueDays = append(queueDays, day)
+			}
+		}
+
+		for _, hour := range hours {
+			if hour.QueueID == queue.ID {
+				queueHours = append(queueHours, hour)
+			}
+		}
+
+		perQueue := query
+		perQueue.QueueID = queue.ID
+
+		breakdown := summarise(perQueue, queueDays, queueHours)
+		breakdown.QueueName = queue.Name
+
+		analytics.Queues = append(analytics.Queues, breakdown)
+	}
+
+	return analytics, nil
+}
+
+// GetForQueue summarises one of the merchant's queues.
+func (usecase AnalyticsUsecase) GetForQueue(ctx context.Context, query entities.AnalyticsQuery) (entities.Analytics, error) {
+
+	query, err := checkAnalyticsRange(query)
+	if err != nil {
+		return entities.Analytics{}, err
+	}
+
+	_, err = usecase.queues.IsQueueBelongsToMerchant(ctx, query.MerchantID, query.QueueID)
+	if err != nil {
+		return entities.Analytics{}, err
+	}
+
+	days, hours, err := usecase.collect(ctx, query)
+	if err != nil {
+		return entities.Analytics{}, err
+	}
+
+	analytics := summarise(query, days, hours)
+
+	queues, err := usecase.queues.GetByMerchant(ctx, query.MerchantID)
+	if err != nil {
+		return entities.Analytics{}, err
+	}
+
+	for _, queue := range queues {
+		if queue.ID == query.QueueID {
+			analytics.QueueName = queue.Name
+		}
+	}
+
+	return analytics, nil
+}
+
+// Rollup stores the rollups of the last days and of up to rollupBatchDays
+// days that were never rolled up. It returns how many days were rolled up.
+func (usecase AnalyticsUsecase) Rollup(ctx context.Context) (int, error) {
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.AddDate(0, 0, -1)
+	recent := today.AddDate(0, 0, -rollupRecentDays)
+
+	start, err := usecase.repo.GetRollupStart(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if start == nil || start.After(recent) {
+		start = &recent
+	}
+
+	rolled := 0
+
+	for day := *start; !day.After(yesterday) && rolled < rollupBatchDays; day = day.AddDate(0, 0, 1) {
+		_, err = usecase.repo.Rollup(ctx, day)
+		if err != nil {
+			return rolled, err
+		}
+
+		rolled++
+	}
+
+	return rolled, nil
+}
+
+// collect returns the figures of the range: sums of the rolled up days, and
+// computed figures for the days that have not been rolled up yet.
+func (usecase AnalyticsUsecase) collect(ctx context.Context, query entities.AnalyticsQuery) ([]entities.QueueDay, []entities.QueueHour, error) {
+
+	rolledDays, err := usecase.repo.GetRolledDays(ctx, query.From, query.To)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rolled := make(map[string]bool, len(rolledDays))
+
+	for _, day := range rolledDays {
+		rolled[day.Format("2006-01-02")] = true
+	}
+
+	if len(rolled) == 0 {
+		return usecase.repo.Compute(ctx, query)
+	}
+
+	days, hours, err := usecase.repo.SumRollups(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	live := query
+
+	for !live.From.After(live.To) && rolled[live.From.Format("2006-01-02")] {
+		live.From = live.From.AddDate(0, 0, 1)
+	}
+
+	if live.From.After(live.To) {
+		return days, hours, nil
+	}
+
+	liveDays, liveHours, err := usecase.repo.Compute(ctx, live)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, day := range liveDays {
+		if !rolled[day.Day.Format("2006-01-02")] {
+			days = append(days, day)
+		}
+	}
+
+	for _, hour := range liveHours {
+		if !rolled[hour.Day.Format("2006-01-02")] {
+			hours = append(hours, hour)
+		}
+	}
+
+	return days, hours, nil
+}
+
+// checkAnalyticsRange defaults the range to the last analyticsDefaultDays
+// days and limits it to analyticsMaxDays.
+func checkAnalyticsRange(query entities.AnalyticsQuery) (entities.AnalyticsQuery, error) {
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	if query.To.IsZero() {
+		query.To = today
+	}
+
+	if query.From.IsZero() {
+		query.From = query.To.AddDate(0, 0, -(analyticsDefaultDays - 1))
+	}
+
+	if query.To.Before(query.From) {
+		return query, errors.New("from must not be after to")
+	}
+
+	if query.To.Sub(query.From) >= analyticsMaxDays*24*time.Hour {
+		return query, fmt.Errorf("the date range can cover at most %d days", analyticsMaxDays)
+	}
+
+	return query, nil
+}
+
+func summarise(query entities.AnalyticsQuery, days []entities.QueueDay, hours []entities.QueueHour) entities.Analytics {
+
+	analytics := entities.Analytics{
+		MerchantID: query.MerchantID,
+		QueueID:    query.QueueID,
+		From:       query.From,
+		To:         query.To,
+	}
+
+	var lead int64
+
+	for _, day := range days {
+		analytics.Offered += day.Offered
+		analytics.Booked += day.Booked
+		analytics.Cancelled += day.Cancelled
+		analytics.NoShows += day.NoShows
+		analytics.CheckedIn += day.CheckedIn
+		lead += day.LeadMinutes
+	}
+
+	analytics.Utilization = ratio(analytics.Booked, analytics.Offered)
+	analytics.CancellationRate = ratio(analytics.Cancelled, analytics.Booked+analytics.Cancelled)
+	analytics.NoShowRate = ratio(analytics.NoShows, analytics.Booked)
+
+	if analytics.Booked > 0 {
+		analytics.AverageLeadMinutes = float64(lead) / float64(analytics.Booked)
+	}
+
+	analytics.Hours = make([]entities.HourCount, 24)
+	analytics.Weekdays = make([]entities.WeekdayCount, 7)
+
+	for i := range analytics.Hours {
+		analytics.Hours[i].Hour = i
+	}
+
+	for i := range analytics.Weekdays {
+		analytics.Weekdays[i].Weekday = time.Weekday(i)
+	}
+
+	for _, hour := range hours {
+		analytics.Hours[hour.Hour].Bookings += hour.Bookings
+		analytics.Weekdays[hour.Weekday].Bookings += hour.Bookings
+	}
+
+	analytics.PeakHours = make([]int, 0, analyticsTop)
+	analytics.BusiestWeekdays = make([]time.Weekday, 0, analyticsTop)
+
+	peaks := append([]entities.HourCount(nil), analytics.Hours...)
+	sort.SliceStable(peaks, func(i, j int) bool { return peaks[i].Bookings > peaks[j].Bookings })
+
+	for _, peak := range peaks {
+		if peak.Bookings == 0 || len(analytics.PeakHours) == analyticsTop {
+			break
+		}
+
+		analytics.PeakHours = append(analytics.PeakHours, peak.Hour)
+	}
+
+	busiest := append([]entities.WeekdayCount(nil), analytics.Weekdays...)
+	sort.SliceStable(busiest, func(i, j int) bool { return busiest[i].Bookings > busiest[j].Bookings })
+
+	for _, weekday := range busiest {
+		if weekday.Bookings == 0 || len(analytics.BusiestWeekdays) == analyticsTop {
+			break
+		}
+
+		analytics.BusiestWeekdays = append(analytics.BusiestWeekdays, weekday.Weekday)
+	}
+
+	return analytics
+}
+
+func ratio(part int, whole int) float64 {
+
+	if whole == 0 {
+		return 0
+	}
+
+	return float64(part) / float64(whole)
+}
@@ -0,0 +1,149 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/utils/identity"
+	"strings"
+	"time"
+)
+
+type BranchUsecase struct {
+	repo      interfaces.BranchRepository
+	merchants interfaces.MerchantRepository
+}
+
+func NewBranchUsecase(repo interfaces.BranchRepository, merchants interfaces.MerchantRepository) BranchUsecase {
+	usecase := BranchUsecase{
+		repo:      repo,
+		merchants: merchants,
+	}
+
+	return usecase
+}
+
+func (usecase BranchUsecase) GetByMerchant(ctx context.Context, merchantID int64) ([]entities.Branch, error) {
+
+	return usecase.repo.GetByMerchant(ctx, merchantID)
+}
+
+func (usecase BranchUsecase) Create(ctx context.Context, branch entities.Branch) (*entities.Branch, error) {
+
+	branch, err := usecase.validate(ctx, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := usecase.repo.Create(ctx, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	return usecase.repo.GetSingle(ctx, branch.MerchantID, id)
+}
+
+// Update replaces the branch details and opening hours.
+func (usecase BranchUsecase) Update(ctx context.Context, branch entities.Branch) (*entities.Branch, error) {
+
+	current, err := usecase.repo.GetSingle(ctx, branch.MerchantID, branch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if current == nil {
+		return nil, errors.New("there are no such branch")
+	}
+
+	branch, err = usecase.validate(ctx, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = usecase.repo.Update(ctx, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	return usecase.repo.GetSingle(ctx, branch.MerchantID, branch.ID)
+}
+
+func (usecase BranchUsecase) Delete(ctx context.Context, merchantID int64, id int64) (bool, error) {
+
+	return usecase.repo.Delete(ctx, merchantID, id)
+}
+
+func (usecase BranchUsecase) validate(ctx context.Context, branch entities.Branch) (entities.Branch, error) {
+
+	branch.Name = strings.TrimSpace(branch.Name)
+	branch.Address = strings.TrimSpace(branch.Address)
+	branch.City = strings.TrimSpace(branch.City)
+	branch.PostalCode = strings.TrimSpace(branch.PostalCode)
+	branch.Country = strings.ToUpper(strings.TrimSpace(branch.Country))
+
+	if len(branch.Name) == 0 || len(branch.Name) > 120 {
+		return branch, errors.New("name must be inbetween 1 - 120 characters")
+	}
+
+	if len(branch.Address) > 255 || len(branch.City) > 120 || len(branch.PostalCode) > 20 {
+		return branch, errors.New("address is too long")
+	}
+
+	if len(branch.Country) == 0 {
+		country, err := usecase.merchants.GetCountry(ctx, branch.MerchantID)
+		if err != nil {
+			return branch, err
+		}
+
+		branch.Country = country
+	}
+
+	if !identity.IsCountry(branch.Country) {
+		return branch, errors.New("unsupported country")
+	}
+
+	if (branch.Latitude == nil) != (branch.Longitude == nil) {
+		return branch, errors.New("latitude and longitude must be given together")
+	}
+
+	if branch.Latitude != nil && (*branch.Latitude < -90 || *branch.Latitude > 90) {
+		return branch, errors.New("latitude must be inbetween -90 and 90")
+	}
+
+	if branch.Longitude != nil && (*branch.Longitude < -180 || *branch.Longitude > 180) {
+		return branch, errors.New("longitude must be inbetween -180 and 180")
+	}
+
+	if len(strings.TrimSpace(branch.Phone)) > 0 {
+		phone, err := identity.ParsePhone(branch.Phone, branch.Country)
+		if err != nil {
+			return branch, err
+		}
+
+		branch.Phone = phone
+	}
+
+	for _, hours := range branch.Hours {
+		if hours.Weekday < 0 || hours.Weekday > 6 {
+			return branch, errors.New("weekday must be inbetween 0 (sunday) - 6 (saturday)")
+		}
+
+		opens, err := time.Parse("15:04", hours.Opens)
+		if err != nil {
+			return branch, fmt.Errorf("invalid opening time %q, expected HH:MM", hours.Opens)
+		}
+
+		closes, err := time.Parse("15:04", hours.Closes)
+		if err != nil {
+			return branch, fmt.Errorf("invalid closing time %q, expected HH:MM", hours.Closes)
+		}
+
+		if !opens.Before(closes) {
+			return branch, errors.New("opening time must be before closing time")
+		}
+	}
+
+	return branch, nil
+}
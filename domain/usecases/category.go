@@ -0,0 +1,329 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"strings"
+)
+
+type CategoryUsecase struct {
+	repo      interfaces.CategoryRepository
+	merchants interfaces.MerchantRepository
+	suggest   interfaces.SuggestIndex
+}
+
+func NewCategoryUsecase(repo interfaces.CategoryRepository, merchants interfaces.MerchantRepository, suggest interfaces.SuggestIndex) CategoryUsecase {
+	usecase := CategoryUsecase{
+		repo:      repo,
+		merchants: merchants,
+		suggest:   suggest,
+	}
+
+	return usecase
+}
+
+// categoryTree nests subcategories under their parents, keeping the order of
+// the given list. Subcategories whose parent is missing are left out.
+func categoryTree(categories []entities.Category) []entities.Category {
+
+	children := make(map[string][]entities.Category)
+
+	for _, category := range categories {
+		if len(category.Parent) != 0 {
+			children[category.Parent] = append(children[category.Parent], category)
+		}
+	}
+
+	tree := make([]entities.Category, 0)
+
+	for _, category := range categories {
+		if len(category.Parent) != 0 {
+			continue
+		}
+
+		category.Children = children[category.Name]
+		if category.Children == nil {
+			category.Children = make([]entities.Category, 0)
+		}
+
+		tree = append(tree, category)
+	}
+
+	return tree
+}
+
+// GetAll returns every category, retired ones included, as a tree.
+func (usecase CategoryUsecase) GetAll(ctx context.Context) ([]entities.Category, error) {
+
+	categories, err := usecase.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return categoryTree(categories), nil
+}
+
+// Create adds a category after its siblings.
+func (usecase CategoryUsecase) Create(ctx context.Context, category entities.Category) (*entities.Category, error) {
+
+	category.Name = strings.TrimSpace(category.Name)
+	category.Parent = strings.TrimSpace(category.Parent)
+	category.Icon = strings.TrimSpace(category.Icon)
+
+	err := validateCategoryName(category.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(category.Icon) > 255 {
+		return nil, errors.New("icon must be at most 255 characters")
+	}
+
+	categories, err := usecase.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if findCategory(categories, category.Name) != nil {
+		return nil, errors.New("category already exists")
+	}
+
+	err = checkCategoryParent(categories, category.Name, category.Parent)
+	if err != nil {
+		return nil, err
+	}
+
+	category.Position = 0
+
+	for _, sibling := range categories {
+		if sibling.Parent == category.Parent && sibling.Position >= category.Position {
+			category.Position = sibling.Position + 1
+		}
+	}
+
+	_, err = usecase.repo.Create(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+
+	usecase.refresh(ctx)
+
+	return usecase.repo.GetSingle(ctx, category.Name)
+}
+
+// Update renames, moves or changes the icon of a category. Renames carry the
+// category's merchants and subcategories along.
+func (usecase CategoryUsecase) Update(ctx context.Context, name string, update entities.CategoryUpdate) (*entities.Category, error) {
+
+	categories, err := usecase.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current := findCategory(categories, name)
+	if current == nil {
+		return nil, errors.New("there are no such category")
+	}
+
+	category := *current
+
+	if update.Name != nil {
+		category.Name = strings.TrimSpace(*update.Name)
+
+		err = validateCategoryName(category.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if category.Name != name && findCategory(categories, category.Name) != nil {
+			return nil, errors.New("category already exists")
+		}
+	}
+
+	if update.Icon != nil {
+		category.Icon = strings.TrimSpace(*update.Icon)
+
+		if len(category.Icon) > 255 {
+			return nil, errors.New("icon must be at most 255 characters")
+		}
+	}
+
+	if update.Parent != nil && strings.TrimSpace(*update.Parent) != current.Parent {
+		category.Parent = strings.TrimSpace(*update.Parent)
+
+		err = checkCategoryParent(categories, name, category.Parent)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = usecase.repo.Update(ctx, name, category)
+	if err != nil {
+		return nil, err
+	}
+
+	usecase.refresh(ctx)
+
+	return usecase.repo.GetSingle(ctx, category.Name)
+}
+
+// Reorder sets the order of the categories under parent, or of the top
+// level when parent is empty. Every sibling has to be listed exactly once.
+func (usecase CategoryUsecase) Reorder(ctx context.Context, parent string, names []string) ([]entities.Category, error) {
+
+	categories, err := usecase.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	siblings := make(map[string]bool)
+
+	for _, category := range categories {
+		if category.Parent == parent {
+			siblings[category.Name] = true
+		}
+	}
+
+	if len(names) != len(siblings) {
+		return nil, errors.New("order must list every category under the parent exactly once")
+	}
+
+	for _, name := range names {
+		if !siblings[name] {
+			return nil, errors.New("order must list every category under the parent exactly once")
+		}
+
+		delete(siblings, name)
+	}
+
+	_, err = usecase.repo.Reorder(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	return usecase.GetAll(ctx)
+}
+
+// Retire hides a category from listings and sign up. Its merchants keep it
+// until they move. Subcategories have to be retired first.
+func (usecase CategoryUsecase) Retire(ctx context.Context, name string) (bool, error) {
+
+	categories, err := usecase.repo.GetAll(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if findCategory(categories, name) == nil {
+		return false, errors.New("there are no such category")
+	}
+
+	for _, category := range categories {
+		if category.Parent == name && category.RetiredAt == nil {
+			return false, errors.New("retire the subcategories first")
+		}
+	}
+
+	done, err := usecase.repo.Retire(ctx, name)
+	if err != nil {
+		return false, err
+	}
+
+	usecase.refresh(ctx)
+
+	return done, nil
+}
+
+func (usecase CategoryUsecase) Restore(ctx context.Context, name string) (bool, error) {
+
+	categories, err := usecase.repo.GetAll(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	category := findCategory(categories, name)
+	if category == nil {
+		return false, errors.New("there are no such category")
+	}
+
+	if len(category.Parent) != 0 {
+		parent := findCategory(categories, category.Parent)
+
+		if parent != nil && parent.RetiredAt != nil {
+			return false, errors.New("restore the parent category first")
+		}
+	}
+
+	done, err := usecase.repo.Restore(ctx, name)
+	if err != nil {
+		return false, err
+	}
+
+	usecase.refresh(ctx)
+
+	return done, nil
+}
+
+// refresh reloads the suggestion index after a change to category names.
+// A failure only leaves suggestions stale until the next periodic refresh.
+func (usecase CategoryUsecase) refresh(ctx context.Context) {
+
+	err := refreshSuggestions(ctx, usecase.merchants, usecase.suggest)
+	if err != nil {
+		log.Printf("suggestion index refresh failed: %v", err)
+	}
+}
+
+func validateCategoryName(name string) error {
+
+	if len(name) == 0 || len(name) > 120 {
+		return errors.New("name must be inbetween 1 - 120 characters")
+	}
+
+	return nil
+}
+
+// checkCategoryParent keeps the hierarchy two levels deep: a parent must be
+// an active top level category, and a category with subcategories cannot
+// become a subcategory itself.
+func checkCategoryParent(categories []entities.Category, name string, parent string) error {
+
+	if len(parent) == 0 {
+		return nil
+	}
+
+	if parent == name {
+		return errors.New("a category cannot be its own parent")
+	}
+
+	found := findCategory(categories, parent)
+
+	if found == nil || found.RetiredAt != nil {
+		return errors.New("there are no such parent category")
+	}
+
+	if len(found.Parent) != 0 {
+		return errors.New("subcategories cannot have subcategories")
+	}
+
+	for _, category := range categories {
+		if category.Parent == name {
+			return errors.New("a category with subcategories cannot become a subcategory")
+		}
+	}
+
+	return nil
+}
+
+func findCategory(categories []entities.Category, name string) *entities.Category {
+
+	for i := range categories {
+		if categories[i].Name == name {
+			return &categories[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,303 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/utils/identity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	otpLength      = 6
+	otpLifetime    = 5 * time.Minute
+	otpResendAfter = time.Minute
+	otpMaxAttempts = 5
+)
+
+type CustomerUsecase struct {
+	repo  interfaces.CustomerRepository
+	sms   interfaces.SMSSender
+	queue QueuetUsecase
+}
+
+func NewCustomerUsecase(repo interfaces.CustomerRepository, sms interfaces.SMSSender, queue QueuetUsecase) CustomerUsecase {
+	usecase := CustomerUsecase{
+		repo:  repo,
+		sms:   sms,
+		queue: queue,
+	}
+
+	return usecase
+}
+
+func (usecase CustomerUsecase) RequestOTP(ctx context.Context, phone string) (bool, error) {
+
+	latest, err := usecase.repo.GetLatestOTP(ctx, phone)
+	if err != nil {
+		return false, err
+	}
+
+	// expires_at is written by us, so it is a safer clock than created_at.
+	if latest != nil && time.Until(latest.ExpiresAt) > otpLifetime-otpResendAfter {
+		return false, errors.New("please wait before requesting another code")
+	}
+
+	code, err := generateOTP()
+	if err != nil {
+		return false, err
+	}
+
+	_, err = usecase.repo.CreateOTP(ctx, phone, hashOTP(code), time.Now().Add(otpLifetime))
+	if err != nil {
+		return false, err
+	}
+
+	message := fmt.Sprintf("Your No-Q login code is %s. It expires in %d minutes.", code, int(otpLifetime.Minutes()))
+
+	err = usecase.sms.Send(ctx, phone, message)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (usecase CustomerUsecase) VerifyOTP(ctx context.Context, phone string, code string) (string, error) {
+
+	otp, err := usecase.repo.GetLatestOTP(ctx, phone)
+	if err != nil {
+		return "", err
+	}
+
+	if otp == nil || otp.ConsumedAt != nil || time.Now().After(otp.ExpiresAt) {
+		return "", errors.New("code expired, request a new one")
+	}
+
+	// the attempt is counted before the code is compared, so parallel
+	// guesses cannot get past the limit
+	counted, err := usecase.repo.IncrementOTPAttempts(ctx, otp.ID, otpMaxAttempts)
+	if err != nil {
+		return "", err
+	}
+
+	if !counted {
+		return "", errors.New("too many attempts, request a new code")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(otp.CodeHash), []byte(hashOTP(code))) != 1 {
+		return "", errors.New("invalid code")
+	}
+
+	consumed, err := usecase.repo.ConsumeOTP(ctx, otp.ID)
+	if err != nil {
+		return "", err
+	}
+
+	// a parallel request may have used the code in the meantime
+	if !consumed {
+		return "", errors.New("code already used")
+	}
+
+	user, err := usecase.repo.ResolveUser(ctx, entities.User{Phone: phone})
+	if err != nil {
+		return "", err
+	}
+
+	return usecase.repo.CreateToken(ctx, user.ID, uuid.New().String())
+}
+
+func (usecase CustomerUsecase) Logout(ctx context.Context, user entities.User) (bool, error) {
+
+	return usecase.repo.Logout(ctx, user.ID)
+}
+
+func (usecase CustomerUsecase) GetBookings(ctx context.Context, user entities.User, filter entities.BookingFilter) ([]entities.Booking, error) {
+
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return nil, errors.New("given date range is wrong")
+	}
+
+	now := time.Now().UTC()
+
+	bookings, err := usecase.repo.GetBookings(ctx, user.Phone, filter, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range bookings {
+		bookings[i].Status = bookingStatus(bookings[i], now)
+	}
+
+	return bookings, nil
+}
+
+func (usecase CustomerUsecase) CancelReservation(ctx context.Context, user entities.User, tokenNo int64) (bool, error) {
+
+	_, err := usecase.ownReservation(ctx, user, tokenNo)
+	if err != nil {
+		return false, err
+	}
+
+	return usecase.queue.unReserveSlot(ctx, tokenNo)
+}
+
+func (usecase CustomerUsecase) RebookReservation(ctx context.Context, user entities.User, tokenNo int64, startTime time.Time, endTime time.Time) (entities.ReservedSlots, error) {
+
+	reservation, err := usecase.ownReservation(ctx, user, tokenNo)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	return usecase.queue.RescheduleSlot(ctx, reservation, startTime, endTime)
+}
+
+// ownReservation returns the user's reservation if it can still be changed:
+// it has not started yet and the customer neither arrived nor missed it.
+func (usecase CustomerUsecase) ownReservation(ctx context.Context, user entities.User, tokenNo int64) (entities.ReservedSlots, error) {
+
+	reservation, err := usecase.queue.GetReservation(ctx, tokenNo)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	if reservation.ReservedBy.Phone != user.Phone {
+		return entities.ReservedSlots{}, errors.New("there are no such token no")
+	}
+
+	switch {
+	case reservation.NoShowAt != nil:
+		return entities.ReservedSlots{}, errors.New("this reservation was marked as no-show")
+	case reservation.ArrivedAt != nil:
+		return entities.ReservedSlots{}, errors.New("this reservation is already checked in")
+	case !reservation.StartTime.After(time.Now()):
+		return entities.ReservedSlots{}, errors.New("this reservation has already started")
+	}
+
+	return reservation, nil
+}
+
+// MergeDuplicates groups users that share a normalized phone and folds each
+// group into its oldest row. Emails are typed in without verification, so
+// they never link users, and a duplicate's name and email only fill in the
+// keeper's when those are empty. Nothing is written when dryRun is set.
+func (usecase CustomerUsecase) MergeDuplicates(ctx context.Context, dryRun bool) ([]entities.CustomerMerge, error) {
+
+	users, err := usecase.repo.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// users are ordered by id, so the first user of a group is the oldest
+	groups := make([][]entities.User, 0)
+	byPhone := make(map[string]int)
+
+	for _, user := range users {
+		phone := identity.NormalizePhone(user.Phone)
+
+		if i, ok := byPhone[phone]; ok && len(phone) != 0 {
+			groups[i] = append(groups[i], user)
+			continue
+		}
+
+		if len(phone) != 0 {
+			byPhone[phone] = len(groups)
+		}
+
+		groups = append(groups, []entities.User{user})
+	}
+
+	merges := make([]entities.CustomerMerge, 0)
+
+	for _, group := range groups {
+		keeper := group[0]
+		keeper.Email = identity.NormalizeEmail(keeper.Email)
+
+		// numbers that do not parse are kept as they are rather than blanked
+		if phone := identity.NormalizePhone(keeper.Phone); len(phone) != 0 {
+			keeper.Phone = phone
+		}
+
+		duplicateIDs := make([]int64, 0, len(group)-1)
+
+		for _, user := range group[1:] {
+			duplicateIDs = append(duplicateIDs, user.ID)
+
+			if len(keeper.Name) == 0 {
+				keeper.Name = user.Name
+			}
+
+			if len(keeper.Email) == 0 {
+				keeper.Email = identity.NormalizeEmail(user.Email)
+			}
+		}
+
+		// nothing to merge and nothing to normalize
+		if len(duplicateIDs) == 0 && keeper == group[0] {
+			continue
+		}
+
+		merges = append(merges, entities.CustomerMerge{
+			Keeper:       keeper,
+			DuplicateIDs: duplicateIDs,
+		})
+
+		if dryRun {
+			continue
+		}
+
+		_, err = usecase.repo.MergeUsers(ctx, keeper, duplicateIDs)
+		if err != nil {
+			return merges, err
+		}
+	}
+
+	return merges, nil
+}
+
+func bookingStatus(booking entities.Booking, now time.Time) string {
+
+	switch {
+	case booking.NoShowAt != nil:
+		return entities.BookingNoShow
+	case booking.ArrivedAt != nil:
+		return entities.BookingCheckedIn
+	case booking.CalledAt != nil:
+		return entities.BookingCalled
+	case booking.EndTime.Before(now):
+		return entities.BookingMissed
+	}
+
+	return entities.BookingBooked
+}
+
+func generateOTP() (string, error) {
+
+	max := big.NewInt(1)
+	for i := 0; i < otpLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", otpLength, n), nil
+}
+
+func hashOTP(code string) string {
+
+	sum := sha256.Sum256([]byte(code))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,315 @@
+package usecases
+
+import (
+	"context"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCustomerRepository serves users and one-time codes from memory and
+// records merges and sessions.
+type fakeCustomerRepository struct {
+	interfaces.CustomerRepository
+	users  []entities.User
+	merged []entities.CustomerMerge
+
+	mu     sync.Mutex
+	otp    *entities.OTP
+	tokens []string
+	// raceConsume makes ConsumeOTP behave as if a parallel request had
+	// used the code between lookup and consumption.
+	raceConsume bool
+}
+
+func (repo *fakeCustomerRepository) GetLatestOTP(ctx context.Context, phone string) (*entities.OTP, error) {
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	otp := *repo.otp
+
+	return &otp, nil
+}
+
+func (repo *fakeCustomerRepository) IncrementOTPAttempts(ctx context.Context, id int64, max int) (bool, error) {
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.otp.Attempts >= max {
+		return false, nil
+	}
+
+	repo.otp.Attempts++
+
+	return true, nil
+}
+
+func (repo *fakeCustomerRepository) ConsumeOTP(ctx context.Context, id int64) (bool, error) {
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.raceConsume || repo.otp.ConsumedAt != nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	repo.otp.ConsumedAt = &now
+
+	return true, nil
+}
+
+func (repo *fakeCustomerRepository) ResolveUser(ctx context.Context, user entities.User) (entities.User, error) {
+
+	user.ID = 1
+
+	return user, nil
+}
+
+func (repo *fakeCustomerRepository) CreateToken(ctx context.Context, userID int64, token string) (string, error) {
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.tokens = append(repo.tokens, token)
+
+	return token, nil
+}
+
+func (repo *fakeCustomerRepository) GetUsers(ctx context.Context) ([]entities.User, error) {
+	return repo.users, nil
+}
+
+func (repo *fakeCustomerRepository) MergeUsers(ctx context.Context, keeper entities.User, duplicateIDs []int64) (bool, error) {
+
+	repo.merged = append(repo.merged, entities.CustomerMerge{Keeper: keeper, DuplicateIDs: duplicateIDs})
+
+	return true, nil
+}
+
+func TestMergeDuplicates(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		users []entities.User
+		want  []entities.CustomerMerge
+	}{
+		{
+			name:  "normalized single user is left alone",
+			users: []entities.User{{ID: 1, Name: "Ann", Phone: "+94771234567", Email: "ann@example.com"}},
+			want:  []entities.CustomerMerge{},
+		},
+		{
+			name:  "unparseable phone is not blanked",
+			users: []entities.User{{ID: 1, Name: "Ann", Phone: "12ab"}},
+			want:  []entities.CustomerMerge{},
+		},
+		{
+			name:  "local phone is normalized",
+			users: []entities.User{{ID: 1, Name: "Ann", Phone: "0771234567"}},
+			want: []entities.CustomerMerge{
+				{Keeper: entities.User{ID: 1, Name: "Ann", Phone: "+94771234567"}, DuplicateIDs: []int64{}},
+			},
+		},
+		{
+			name: "same phone in different formats",
+			users: []entities.User{
+				{ID: 1, Name: "Ann", Phone: "0771234567"},
+				{ID: 2, Name: "Ann Perera", Phone: "+94 77 123 4567", Email: "Ann@Example.com"},
+			},
+			want: []entities.CustomerMerge{
+				{Keeper: entities.User{ID: 1, Name: "Ann", Phone: "+94771234567", Email: "ann@example.com"}, DuplicateIDs: []int64{2}},
+			},
+		},
+		{
+			name: "keeper's name and email are not overwritten",
+			users: []entities.User{
+				{ID: 1, Name: "Ann", Phone: "+94771234567", Email: "ann@example.com"},
+				{ID: 2, Name: "Mallory", Phone: "0771234567", Email: "mallory@example.com"},
+			},
+			want: []entities.CustomerMerge{
+				{Keeper: entities.User{ID: 1, Name: "Ann", Phone: "+94771234567", Email: "ann@example.com"}, DuplicateIDs: []int64{2}},
+			},
+		},
+		{
+			name: "same email with different phones is not merged",
+			users: []entities.User{
+				{ID: 1, Name: "Ann", Phone: "+94771234567", Email: "ann@example.com"},
+				{ID: 2, Name: "Mallory", Phone: "+94779999999", Email: "ann@example.com"},
+			},
+			want: []entities.CustomerMerge{},
+		},
+		{
+			name: "unparseable phones are not merged",
+			users: []entities.User{
+				{ID: 1, Name: "Ann", Phone: "12ab", Email: "ann@example.com"},
+				{ID: 2, Name: "Bob", Phone: "12ab"},
+			},
+			want: []entities.CustomerMerge{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &fakeCustomerRepository{users: test.users}
+
+			usecase := NewCustomerUsecase(repo, nil, QueuetUsecase{})
+
+			merges, err := usecase.MergeDuplicates(context.Background(), false)
+			if err != nil {
+				t.Fatalf("MergeDuplicates() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(merges, test.want) {
+				t.Errorf("MergeDuplicates() = %+v, want %+v", merges, test.want)
+			}
+
+			if len(repo.merged) != len(test.want) {
+				t.Errorf("MergeUsers called %d times, want %d", len(repo.merged), len(test.want))
+			}
+		})
+	}
+}
+
+func TestChangeReservation(t *testing.T) {
+
+	now := time.Now()
+	upcoming := now.Add(2 * time.Hour)
+	past := now.Add(-2 * time.Hour)
+	ann := entities.User{ID: 1, Phone: "+94771234567"}
+
+	tests := []struct {
+		name        string
+		reservation entities.ReservedSlots
+		user        entities.User
+		wantErr     bool
+	}{
+		{name: "upcoming", reservation: entities.ReservedSlots{StartTime: upcoming}, user: ann},
+		{name: "someone else's", reservation: entities.ReservedSlots{StartTime: upcoming}, user: entities.User{ID: 2, Phone: "+94779999999"}, wantErr: true},
+		{name: "started", reservation: entities.ReservedSlots{StartTime: past}, user: ann, wantErr: true},
+		{name: "arrived", reservation: entities.ReservedSlots{StartTime: upcoming, ArrivedAt: &now}, user: ann, wantErr: true},
+		{name: "no-show", reservation: entities.ReservedSlots{StartTime: upcoming, NoShowAt: &now}, user: ann, wantErr: true},
+	}
+
+	actions := map[string]func(usecase CustomerUsecase, user entities.User) error{
+		"cancel": func(usecase CustomerUsecase, user entities.User) error {
+			_, err := usecase.CancelReservation(context.Background(), user, 10)
+			return err
+		},
+		"rebook": func(usecase CustomerUsecase, user entities.User) error {
+			_, err := usecase.RebookReservation(context.Background(), user, 10, upcoming.Add(time.Hour), upcoming.Add(2*time.Hour))
+			return err
+		},
+	}
+
+	for _, test := range tests {
+		for action, change := range actions {
+			t.Run(test.name+" "+action, func(t *testing.T) {
+				reservation := test.reservation
+				reservation.TokenNo = 10
+				reservation.QueueID = 5
+				reservation.EndTime = reservation.StartTime.Add(time.Hour)
+				reservation.ReservedBy = ann
+
+				repo := &fakeQueueRepository{
+					queues:       map[int64]int64{5: 1},
+					reservations: map[int64]entities.ReservedSlots{10: reservation},
+				}
+
+				queue := NewQueuetUsecase(repo, nil, fakeNoShowRepository{}, fakeCheckInSigner{}, &fakeEventHub{})
+				usecase := NewCustomerUsecase(&fakeCustomerRepository{}, nil, queue)
+
+				err := change(usecase, test.user)
+				if (err != nil) != test.wantErr {
+					t.Fatalf("got error %v, want error %v", err, test.wantErr)
+				}
+
+				stored, kept := repo.reservations[10]
+				unchanged := kept && stored.StartTime.Equal(reservation.StartTime)
+
+				if unchanged != test.wantErr {
+					t.Errorf("reservation unchanged = %v, want %v", unchanged, test.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestVerifyOTP(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		wrong       int
+		parallel    bool
+		raceConsume bool
+		wantErr     bool
+	}{
+		{name: "right code"},
+		{name: "right code after wrong guesses", wrong: otpMaxAttempts - 1},
+		{name: "right code after too many guesses", wrong: otpMaxAttempts, wantErr: true},
+		{name: "parallel guesses stay limited", wrong: 50, parallel: true, wantErr: true},
+		{name: "code consumed by a parallel request", raceConsume: true, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &fakeCustomerRepository{
+				otp:         &entities.OTP{ID: 1, CodeHash: hashOTP("123456"), ExpiresAt: time.Now().Add(otpLifetime)},
+				raceConsume: test.raceConsume,
+			}
+
+			usecase := NewCustomerUsecase(repo, nil, QueuetUsecase{})
+
+			var wg sync.WaitGroup
+
+			for i := 0; i < test.wrong; i++ {
+				guess := func() {
+					_, err := usecase.VerifyOTP(context.Background(), "+94771234567", "000000")
+					if err == nil {
+						t.Error("a wrong code was accepted")
+					}
+				}
+
+				if !test.parallel {
+					guess()
+					continue
+				}
+
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					guess()
+				}()
+			}
+
+			wg.Wait()
+
+			if repo.otp.Attempts > otpMaxAttempts {
+				t.Errorf("%d attempts counted, limit is %d", repo.otp.Attempts, otpMaxAttempts)
+			}
+
+			_, err := usecase.VerifyOTP(context.Background(), "+94771234567", "123456")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+
+			if issued := len(repo.tokens) != 0; issued == test.wantErr {
+				t.Errorf("session issued = %v, want %v", issued, !test.wantErr)
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			_, err = usecase.VerifyOTP(context.Background(), "+94771234567", "123456")
+			if err == nil {
+				t.Error("a code was accepted twice")
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"sort"
+	"time"
+)
+
+// upcomingTokens is the number of waiting tokens listed under each queue.
+const upcomingTokens = 5
+
+type DisplayUsecase struct {
+	merchantRepo interfaces.MerchantRepository
+	queueRepo    interfaces.QueueRepository
+}
+
+func NewDisplayUsecase(merchantRepo interfaces.MerchantRepository, queueRepo interfaces.QueueRepository) DisplayUsecase {
+	usecase := DisplayUsecase{
+		merchantRepo: merchantRepo,
+		queueRepo:    queueRepo,
+	}
+
+	return usecase
+}
+
+func (usecase DisplayUsecase) GetBoard(ctx context.Context, key string, queueID int64) (entities.DisplayBoard, error) {
+
+	merchantID, err := usecase.merchantRepo.ValidateDisplayKey(ctx, key)
+	if err != nil {
+		return entities.DisplayBoard{}, err
+	}
+
+	merchant, err := usecase.merchantRepo.GetSingle(ctx, merchantID)
+	if err != nil {
+		return entities.DisplayBoard{}, err
+	}
+
+	if merchant == nil {
+		return entities.DisplayBoard{}, errors.New("not found")
+	}
+
+	queues, err := usecase.queueRepo.GetByMerchant(ctx, merchantID)
+	if err != nil {
+		return entities.DisplayBoard{}, err
+	}
+
+	generatedAt := time.Now()
+	now := wallClock(generatedAt)
+
+	board := entities.DisplayBoard{
+		MerchantName: merchant.Name,
+		Queues:       make([]entities.DisplayQueue, 0, len(queues)),
+		GeneratedAt:  generatedAt.UTC(),
+	}
+
+	for _, queue := range queues {
+		if queueID != 0 && queue.ID != queueID {
+			continue
+		}
+
+		slots, err := usecase.queueRepo.GetSlotsByDate(ctx, queue.ID, now)
+		if err != nil {
+			return entities.DisplayBoard{}, err
+		}
+
+		board.Queues = append(board.Queues, buildDisplayQueue(queue, slots.ReservedSlots, now))
+	}
+
+	if queueID != 0 && len(board.Queues) == 0 {
+		return entities.DisplayBoard{}, errors.New("queue is not blongs to merchant")
+	}
+
+	return board, nil
+}
+
+// wallClock returns the local date and time of t labelled as UTC. Slot times
+// are stored as the merchant's wall clock and read back as UTC, so "today"
+// and waits have to be computed on the same clock, not on UTC itself.
+func wallClock(t time.Time) time.Time {
+
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// buildDisplayQueue picks the most recently called token as the one being
+// served and lists the waiting tokens after it in slot order. Tokens that
+// arrived or were marked as no-show are no longer waiting. The wait is
+// the later of the slot start and the backlog ahead of it.
+func buildDisplayQueue(queue entities.Queue, slots []entities.ReservedSlots, now time.Time) entities.DisplayQueue {
+
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].StartTime.Before(slots[j].StartTime)
+	})
+
+	displayQueue := entities.DisplayQueue{
+		Name:       queue.Name,
+		NextTokens: make([]entities.DisplayToken, 0, upcomingTokens),
+	}
+
+	for i := range slots {
+		if slots[i].CalledAt == nil {
+			continue
+		}
+
+		if displayQueue.CurrentToken == nil || slots[i].CalledAt.After(*displayQueue.CurrentToken.CalledAt) {
+			displayQueue.CurrentToken = &slots[i]
+		}
+	}
+
+	interval := time.Duration(queue.Interval) * time.Minute
+
+	for _, slot := range slots {
+		if len(displayQueue.NextTokens) == upcomingTokens {
+			break
+		}
+
+		if slot.CalledAt != nil || slot.ArrivedAt != nil || slot.NoShowAt != nil || slot.EndTime.Before(now) {
+			continue
+		}
+
+		wait := interval * time.Duration(len(displayQueue.NextTokens)+1)
+
+		if untilStart := slot.StartTime.Sub(now); untilStart > wait {
+			wait = untilStart
+		}
+
+		displayQueue.NextTokens = append(displayQueue.NextTokens, entities.DisplayToken{
+			TokenNo:       slot.TokenNo,
+			StartTime:     slot.StartTime,
+			EstimatedWait: wait.Round(time.Minute),
+		})
+	}
+
+	return displayQueue
+}
@@ -0,0 +1,94 @@
+package usecases
+
+import (
+	"no-q-solution/domain/entities"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildDisplayQueue(t *testing.T) {
+
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	earlier := now.Add(-5 * time.Minute)
+	queue := entities.Queue{Name: "Counter", Interval: 10}
+
+	slot := func(tokenNo int64, start time.Time) entities.ReservedSlots {
+		return entities.ReservedSlots{TokenNo: tokenNo, StartTime: start, EndTime: start.Add(10 * time.Minute)}
+	}
+
+	called := slot(1, now.Add(-10*time.Minute))
+	called.CalledAt = &earlier
+
+	arrived := slot(2, now)
+	arrived.ArrivedAt = &earlier
+
+	noShow := slot(3, now)
+	noShow.NoShowAt = &earlier
+
+	tests := []struct {
+		name    string
+		slots   []entities.ReservedSlots
+		current int64
+		next    []int64
+	}{
+		{
+			name:  "waiting tokens are listed in slot order",
+			slots: []entities.ReservedSlots{slot(5, now.Add(20*time.Minute)), slot(4, now)},
+			next:  []int64{4, 5},
+		},
+		{
+			name:    "called token is served, not waiting",
+			slots:   []entities.ReservedSlots{called, slot(4, now)},
+			current: 1,
+			next:    []int64{4},
+		},
+		{
+			name:  "arrived and no-show tokens are not waiting",
+			slots: []entities.ReservedSlots{arrived, noShow, slot(4, now.Add(10*time.Minute))},
+			next:  []int64{4},
+		},
+		{
+			name:  "ended slots are not waiting",
+			slots: []entities.ReservedSlots{slot(4, now.Add(-30*time.Minute))},
+			next:  []int64{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			display := buildDisplayQueue(queue, test.slots, now)
+
+			var current int64
+			if display.CurrentToken != nil {
+				current = display.CurrentToken.TokenNo
+			}
+
+			if current != test.current {
+				t.Errorf("current token = %d, want %d", current, test.current)
+			}
+
+			next := make([]int64, 0, len(display.NextTokens))
+			for _, token := range display.NextTokens {
+				next = append(next, token.TokenNo)
+			}
+
+			if !reflect.DeepEqual(next, test.next) {
+				t.Errorf("next tokens = %v, want %v", next, test.next)
+			}
+		})
+	}
+}
+
+func TestWallClock(t *testing.T) {
+
+	// 02:00 on 1 March in UTC+5:30 is still 29 February in UTC.
+	local := time.Date(2024, 3, 1, 2, 0, 0, 0, time.FixedZone("IST", 5*60*60+30*60))
+
+	got := wallClock(local)
+	want := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+
+	if !got.Equal(want) {
+		t.Errorf("wallClock() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,233 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/utils/images"
+)
+
+const (
+	LogoMaxBytes    = 2 << 20
+	GalleryMaxBytes = 5 << 20
+	galleryLimit    = 8
+)
+
+// imageSpec describes how an upload of a kind is stored. Logos stay PNG to
+// keep transparency, gallery photos become JPEG.
+type imageSpec struct {
+	size      int
+	thumbnail int
+	png       bool
+}
+
+var imageSpecs = map[string]imageSpec{
+	entities.ImageLogo:    {size: 512, thumbnail: 128, png: true},
+	entities.ImageGallery: {size: 1600, thumbnail: 320, png: false},
+}
+
+type ImageUsecase struct {
+	repo  interfaces.ImageRepository
+	blobs interfaces.BlobStore
+}
+
+func NewImageUsecase(repo interfaces.ImageRepository, blobs interfaces.BlobStore) ImageUsecase {
+	usecase := ImageUsecase{
+		repo:  repo,
+		blobs: blobs,
+	}
+
+	return usecase
+}
+
+// UploadLogo stores a new logo and removes the previous one.
+func (usecase ImageUsecase) UploadLogo(ctx context.Context, merchantID int64, data []byte) (entities.MerchantImage, error) {
+
+	previous, err := usecase.repo.GetByMerchant(ctx, merchantID, entities.ImageLogo)
+	if err != nil {
+		return entities.MerchantImage{}, err
+	}
+
+	logo, err := usecase.upload(ctx, merchantID, entities.ImageLogo, data)
+	if err != nil {
+		return entities.MerchantImage{}, err
+	}
+
+	for _, old := range previous {
+		usecase.remove(ctx, merchantID, old.ID)
+	}
+
+	return logo, nil
+}
+
+func (usecase ImageUsecase) DeleteLogo(ctx context.Context, merchantID int64) (bool, error) {
+
+	logos, err := usecase.repo.GetByMerchant(ctx, merchantID, entities.ImageLogo)
+	if err != nil {
+		return false, err
+	}
+
+	if len(logos) == 0 {
+		return false, errors.New("there are no logo")
+	}
+
+	for _, logo := range logos {
+		err = usecase.remove(ctx, merchantID, logo.ID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// UploadGalleryImage adds a picture to the gallery, which holds at most
+// eight.
+func (usecase ImageUsecase) UploadGalleryImage(ctx context.Context, merchantID int64, data []byte) (entities.MerchantImage, error) {
+
+	gallery, err := usecase.repo.GetByMerchant(ctx, merchantID, entities.ImageGallery)
+	if err != nil {
+		return entities.MerchantImage{}, err
+	}
+
+	if len(gallery) >= galleryLimit {
+		return entities.MerchantImage{}, fmt.Errorf("the gallery holds at most %d images", galleryLimit)
+	}
+
+	return usecase.upload(ctx, merchantID, entities.ImageGallery, data)
+}
+
+func (usecase ImageUsecase) DeleteGalleryImage(ctx context.Context, merchantID int64, id int64) (bool, error) {
+
+	err := usecase.remove(ctx, merchantID, id)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// upload decodes and re-encodes the image, so only clean pixels are stored,
+// and saves it with a thumbnail.
+func (usecase ImageUsecase) upload(ctx context.Context, merchantID int64, kind string, data []byte) (entities.MerchantImage, error) {
+
+	spec := imageSpecs[kind]
+
+	img, err := images.Decode(data)
+	if err != nil {
+		return entities.MerchantImage{}, err
+	}
+
+	full := images.Fit(img, spec.size, spec.size)
+	thumbnail := images.Fit(full, spec.thumbnail, spec.thumbnail)
+
+	name, err := randomName()
+	if err != nil {
+		return entities.MerchantImage{}, err
+	}
+
+	saved := entities.MerchantImage{
+		MerchantID: merchantID,
+		Kind:       kind,
+		Width:      full.Bounds().Dx(),
+		Height:     full.Bounds().Dy(),
+	}
+
+	blobs := []struct {
+		key *string
+		img image.Image
+		tag string
+	}{
+		{&saved.Key, full, ""},
+		{&saved.ThumbnailKey, thumbnail, "_thumb"},
+	}
+
+	for _, blob := range blobs {
+		encoded, contentType, ext, err := encodeImage(blob.img, spec.png)
+		if err != nil {
+			return entities.MerchantImage{}, err
+		}
+
+		*blob.key = fmt.Sprintf("merchants/%d/%s%s.%s", merchantID, name, blob.tag, ext)
+
+		err = usecase.blobs.Put(ctx, *blob.key, contentType, encoded)
+		if err != nil {
+			return entities.MerchantImage{}, err
+		}
+	}
+
+	saved.ID, err = usecase.repo.Create(ctx, saved)
+	if err != nil {
+		return entities.MerchantImage{}, err
+	}
+
+	fillImageURLs(usecase.blobs, &saved)
+
+	return saved, nil
+}
+
+// remove deletes the image row, then its blobs. A blob that cannot be
+// deleted is only logged, as nothing references it any more.
+func (usecase ImageUsecase) remove(ctx context.Context, merchantID int64, id int64) error {
+
+	removed, err := usecase.repo.Delete(ctx, merchantID, id)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range []string{removed.Key, removed.ThumbnailKey} {
+		err = usecase.blobs.Delete(ctx, key)
+		if err != nil {
+			log.Printf("could not delete blob %s: %v", key, err)
+		}
+	}
+
+	return nil
+}
+
+func encodeImage(img image.Image, png bool) ([]byte, string, string, error) {
+
+	if png {
+		data, err := images.EncodePNG(img)
+		return data, "image/png", "png", err
+	}
+
+	data, err := images.EncodeJPEG(img)
+	return data, "image/jpeg", "jpg", err
+}
+
+func randomName() (string, error) {
+
+	buf := make([]byte, 16)
+
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+func fillImageURLs(blobs interfaces.BlobStore, image *entities.MerchantImage) {
+
+	image.URL = blobs.URL(image.Key)
+	image.ThumbnailURL = blobs.URL(image.ThumbnailKey)
+}
+
+// fillMerchantImageURLs resolves the blob keys of a merchant's images.
+func fillMerchantImageURLs(blobs interfaces.BlobStore, merchant *entities.Merchant) {
+
+	if merchant.Logo != nil {
+		fillImageURLs(blobs, merchant.Logo)
+	}
+
+	for i := range merchant.Gallery {
+		fillImageURLs(blobs, &merchant.Gallery[i])
+	}
+}
@@ -2,38 +2,121 @@ package usecases
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
 	"no-q-solution/domain/entities"
 	"no-q-solution/domain/interfaces"
+	"no-q-solution/utils/identity"
+	"no-q-solution/utils/password"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+var merchantEmail = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+const (
+	emailChangeTTL     = 24 * time.Hour
+	passwordResetTTL   = time.Hour
+	passwordResetLimit = time.Minute
+	signUpTTL          = 72 * time.Hour
+	signUpResendLimit  = time.Minute
+)
+
 type MerchantUsecase struct {
-	repo interfaces.MerchantRepository
+	repo    interfaces.MerchantRepository
+	mailer  interfaces.Mailer
+	suggest interfaces.SuggestIndex
+	blobs   interfaces.BlobStore
 }
 
-func NewMerchantUsecase(repo interfaces.MerchantRepository) MerchantUsecase {
+func NewMerchantUsecase(repo interfaces.MerchantRepository, mailer interfaces.Mailer, suggest interfaces.SuggestIndex, blobs interfaces.BlobStore) MerchantUsecase {
 	usecase := MerchantUsecase{
-		repo: repo,
+		repo:    repo,
+		mailer:  mailer,
+		suggest: suggest,
+		blobs:   blobs,
 	}
 
 	return usecase
 }
 
-func (usecase MerchantUsecase) GetAll(ctx context.Context, paginator entities.Paginator) ([]entities.Merchant, error) {
+func (usecase MerchantUsecase) GetAll(ctx context.Context, paginator entities.Paginator, sort string, filter entities.MerchantFilter) ([]entities.Merchant, error) {
+
+	err := validateMerchantSort(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	merchants, err := usecase.repo.GetAll(ctx, paginator, sort, resolveMerchantFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	return usecase.withImageURLs(merchants), nil
+}
+
+// withImageURLs resolves the blob keys of the merchants' images to URLs.
+func (usecase MerchantUsecase) withImageURLs(merchants []entities.Merchant) []entities.Merchant {
+
+	for i := range merchants {
+		fillMerchantImageURLs(usecase.blobs, &merchants[i])
+	}
 
-	return usecase.repo.GetAll(ctx, paginator)
+	return merchants
 }
 
+// GetCategories returns the active categories as a tree.
 func (usecase MerchantUsecase) GetCategories(ctx context.Context) ([]entities.Category, error) {
-	return usecase.repo.GetCategories(ctx)
+
+	categories, err := usecase.repo.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return categoryTree(categories), nil
 }
 
-func (usecase MerchantUsecase) GetByCategory(ctx context.Context, category string) ([]entities.Merchant, error) {
+func (usecase MerchantUsecase) GetByCategory(ctx context.Context, category string, sort string, filter entities.MerchantFilter) ([]entities.Merchant, error) {
+
+	err := validateMerchantSort(sort)
+	if err != nil {
+		return nil, err
+	}
 
-	return usecase.repo.GetByCategory(ctx, category)
+	merchants, err := usecase.repo.GetByCategory(ctx, category, sort, resolveMerchantFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	return usecase.withImageURLs(merchants), nil
+}
+
+// resolveMerchantFilter pins the filter to the current time.
+func resolveMerchantFilter(filter entities.MerchantFilter) entities.MerchantFilter {
+
+	filter.Now = time.Now()
+
+	if filter.FreeToday {
+		today := time.Date(filter.Now.Year(), filter.Now.Month(), filter.Now.Day(), 0, 0, 0, 0, filter.Now.Location())
+		filter.FreeOn = &today
+	}
+
+	return filter
+}
+
+func validateMerchantSort(sort string) error {
+
+	if sort != entities.MerchantSortDefault && sort != entities.MerchantSortRating {
+		return errors.New("sort must be empty or rating")
+	}
+
+	return nil
 }
 
 func (usecase MerchantUsecase) GetSingle(ctx context.Context, id int64) (entities.Merchant, error) {
@@ -47,12 +130,106 @@ func (usecase MerchantUsecase) GetSingle(ctx context.Context, id int64) (entitie
 		return entities.Merchant{}, errors.New("not found")
 	}
 
+	fillMerchantImageURLs(usecase.blobs, merchant)
+
 	return *merchant, nil
 }
 
-func (usecase MerchantUsecase) Search(ctx context.Context, input string) ([]entities.Merchant, error) {
+func (usecase MerchantUsecase) Search(ctx context.Context, input string, paginator entities.Paginator) ([]entities.Merchant, error) {
+
+	if len(strings.TrimSpace(input)) == 0 || len(input) > 120 {
+		return nil, errors.New("search input must be inbetween 1 - 120 characters")
+	}
+
+	merchants, err := usecase.repo.Search(ctx, input, paginator)
+	if err != nil {
+		return nil, err
+	}
+
+	return usecase.withImageURLs(merchants), nil
+}
+
+// Suggest answers the search box from the in-memory index.
+func (usecase MerchantUsecase) Suggest(ctx context.Context, prefix string, limit int) ([]entities.Suggestion, error) {
+
+	if len(strings.TrimSpace(prefix)) == 0 || len(prefix) > 120 {
+		return nil, errors.New("q must be inbetween 1 - 120 characters")
+	}
+
+	if limit == 0 {
+		limit = 8
+	}
+
+	if limit < 0 || limit > 20 {
+		return nil, errors.New("limit must be inbetween 1 - 20")
+	}
+
+	return usecase.suggest.Suggest(prefix, limit), nil
+}
+
+// RefreshSuggestions rebuilds the suggestion index from the database. It
+// runs at startup and periodically to pick up changes made by other
+// processes, such as the privacy command.
+func (usecase MerchantUsecase) RefreshSuggestions(ctx context.Context) error {
 
-	return usecase.repo.Search(ctx, input)
+	return refreshSuggestions(ctx, usecase.repo, usecase.suggest)
+}
+
+func refreshSuggestions(ctx context.Context, repo interfaces.MerchantRepository, suggest interfaces.SuggestIndex) error {
+
+	merchants, err := repo.GetListed(ctx)
+	if err != nil {
+		return err
+	}
+
+	categories, err := repo.GetCategories(ctx)
+	if err != nil {
+		return err
+	}
+
+	suggest.Load(merchants, categories)
+
+	return nil
+}
+
+// Nearby finds the closest branches, defaulting to a 5 km radius and 20
+// results.
+func (usecase MerchantUsecase) Nearby(ctx context.Context, geo entities.GeoQuery) ([]entities.NearbyBranch, error) {
+
+	if geo.Latitude < -90 || geo.Latitude > 90 {
+		return nil, errors.New("lat must be inbetween -90 and 90")
+	}
+
+	if geo.Longitude < -180 || geo.Longitude > 180 {
+		return nil, errors.New("lng must be inbetween -180 and 180")
+	}
+
+	if geo.Radius == 0 {
+		geo.Radius = 5
+	}
+
+	if geo.Radius < 0 || geo.Radius > 50 {
+		return nil, errors.New("radius must be inbetween 0 - 50 km")
+	}
+
+	if geo.Limit == 0 {
+		geo.Limit = 20
+	}
+
+	if geo.Limit < 0 || geo.Limit > 100 {
+		return nil, errors.New("limit must be inbetween 1 - 100")
+	}
+
+	branches, err := usecase.repo.Nearby(ctx, geo)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range branches {
+		fillMerchantImageURLs(usecase.blobs, &branches[i].Merchant)
+	}
+
+	return branches, nil
 }
 
 func (usecase MerchantUsecase) Create(ctx context.Context, merchant entities.Merchant) (int64, error) {
@@ -61,9 +238,7 @@ func (usecase MerchantUsecase) Create(ctx context.Context, merchant entities.Mer
 		return 0, errors.New("name not found")
 	}
 
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-
-	if !emailRegex.MatchString(merchant.Email) {
+	if !merchantEmail.MatchString(merchant.Email) {
 		return 0, errors.New("invalid email")
 	}
 
@@ -71,14 +246,591 @@ func (usecase MerchantUsecase) Create(ctx context.Context, merchant entities.Mer
 		return 0, errors.New("password not found")
 	}
 
-	return usecase.repo.Create(ctx, merchant)
+	if len(merchant.Password) < 8 {
+		return 0, errors.New("password must be at least 8 characters")
+	}
+
+	err := usecase.checkCategory(ctx, merchant.Category)
+	if err != nil {
+		return 0, err
+	}
+
+	merchant.Country = strings.ToUpper(strings.TrimSpace(merchant.Country))
+
+	if len(merchant.Country) == 0 {
+		merchant.Country = identity.DefaultCountry()
+	}
+
+	if !identity.IsCountry(merchant.Country) {
+		return 0, errors.New("unsupported country")
+	}
+
+	hash, err := password.Hash(merchant.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	merchant.Password = hash
+
+	id, err := usecase.repo.Create(ctx, merchant)
+	if err != nil {
+		return 0, err
+	}
+
+	merchant.ID = id
+
+	// The account exists either way; a failed mail can be resent.
+	err = usecase.sendSignUpVerification(ctx, merchant)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return id, nil
 }
 
-func (usecase MerchantUsecase) Login(ctx context.Context, login entities.Login) (string, error) {
+func (usecase MerchantUsecase) sendSignUpVerification(ctx context.Context, merchant entities.Merchant) error {
+
+	token, err := usecase.createVerification(ctx, merchant.ID, entities.VerificationSignUp, merchant.Email, signUpTTL)
+	if err != nil {
+		return err
+	}
+
+	return usecase.mailer.Send(ctx, entities.Mail{
+		To:      merchant.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nWelcome to No-Q. Open the link below within 3 days to confirm your email address and list %s publicly:\n\n%s\n",
+			merchant.Name,
+			merchant.Name,
+			usecase.mailer.URL("/merchant/verify_email/"+token),
+		),
+	})
+}
+
+// VerifyEmail confirms a sign-up from the mailed link. Merchants only show up
+// in listings and search once verified and approved.
+func (usecase MerchantUsecase) VerifyEmail(ctx context.Context, token string) (bool, error) {
+
+	verification, err := usecase.redeemVerification(ctx, entities.VerificationSignUp, token)
+	if err != nil {
+		return false, err
+	}
+
+	done, err := usecase.repo.MarkVerified(ctx, verification.MerchantID)
+	if err != nil {
+		return false, err
+	}
+
+	merchant, err := usecase.repo.GetSingle(ctx, verification.MerchantID)
+	if err == nil && merchant != nil {
+		usecase.syncSuggestion(*merchant)
+	}
+
+	return done, nil
+}
+
+// syncSuggestion adds a merchant to the suggestion index while it is listed,
+// that is verified and approved, and removes it otherwise.
+func (usecase MerchantUsecase) syncSuggestion(merchant entities.Merchant) {
+
+	if merchant.VerifiedAt != nil && merchant.Status == entities.MerchantApproved {
+		usecase.suggest.PutMerchant(merchant)
+		return
+	}
+
+	usecase.suggest.RemoveMerchant(merchant.ID)
+}
+
+// GetForModeration lists merchants in a moderation status for admins.
+func (usecase MerchantUsecase) GetForModeration(ctx context.Context, status string, paginator entities.Paginator) ([]entities.Merchant, error) {
+
+	if len(status) == 0 {
+		status = entities.MerchantPending
+	}
+
+	err := validateMerchantStatus(status)
+	if err != nil {
+		return nil, err
+	}
+
+	return usecase.repo.GetByStatus(ctx, status, paginator)
+}
+
+// Moderate changes a merchant's moderation status and tells the merchant by
+// mail. Suspending or rejecting needs a reason, which is shared with them.
+func (usecase MerchantUsecase) Moderate(ctx context.Context, id int64, status string, reason string) (entities.Merchant, error) {
+
+	reason = strings.TrimSpace(reason)
+
+	err := validateMerchantStatus(status)
+	if err != nil {
+		return entities.Merchant{}, err
+	}
+
+	if status == entities.MerchantPending {
+		return entities.Merchant{}, errors.New("merchants cannot be moved back to pending")
+	}
+
+	if (status == entities.MerchantSuspended || status == entities.MerchantRejected) && len(reason) == 0 {
+		return entities.Merchant{}, errors.New("a reason is required")
+	}
+
+	if len(reason) > 255 {
+		return entities.Merchant{}, errors.New("reason must be at most 255 characters")
+	}
+
+	merchant, err := usecase.repo.GetSingle(ctx, id)
+	if err != nil {
+		return entities.Merchant{}, err
+	}
+
+	if merchant == nil {
+		return entities.Merchant{}, errors.New("not found")
+	}
+
+	if merchant.Status == status {
+		return entities.Merchant{}, fmt.Errorf("merchant is already %s", status)
+	}
+
+	_, err = usecase.repo.SetStatus(ctx, id, status, reason)
+	if err != nil {
+		return entities.Merchant{}, err
+	}
+
+	now := time.Now()
+
+	merchant.Status = status
+	merchant.StatusReason = reason
+	merchant.StatusAt = &now
+
+	usecase.syncSuggestion(*merchant)
+
+	err = usecase.mailer.Send(ctx, moderationMail(*merchant))
+	if err != nil {
+		log.Printf("could not notify merchant %d of status %s: %v", id, status, err)
+	}
+
+	fillMerchantImageURLs(usecase.blobs, merchant)
+
+	return *merchant, nil
+}
+
+func validateMerchantStatus(status string) error {
+
+	switch status {
+	case entities.MerchantPending, entities.MerchantApproved, entities.MerchantSuspended, entities.MerchantRejected:
+		return nil
+	}
+
+	return errors.New("status must be pending, approved, suspended or rejected")
+}
+
+func moderationMail(merchant entities.Merchant) entities.Mail {
+
+	mail := entities.Mail{To: merchant.Email}
+
+	switch merchant.Status {
+	case entities.MerchantApproved:
+		mail.Subject = "Your merchant account is approved"
+		mail.Body = fmt.Sprintf("Hi %s,\n\n%s is approved and customers can now find and book you", merchant.Name, merchant.Name)
+
+		if merchant.VerifiedAt == nil {
+			mail.Body += " once you confirm your email address"
+		}
+
+		mail.Body += ".\n"
+	case entities.MerchantSuspended:
+		mail.Subject = "Your merchant account is suspended"
+		mail.Body = fmt.Sprintf("Hi %s,\n\n%s is suspended and hidden from customers until further notice.\n\nReason: %s\n", merchant.Name, merchant.Name, merchant.StatusReason)
+	case entities.MerchantRejected:
+		mail.Subject = "Your merchant account was not approved"
+		mail.Body = fmt.Sprintf("Hi %s,\n\nWe could not approve %s.\n\nReason: %s\n", merchant.Name, merchant.Name, merchant.StatusReason)
+	}
+
+	return mail
+}
+
+// ResendVerification mails a fresh sign-up link, at most once a minute. Like
+// ForgotPassword it does not reveal whether the email is registered.
+func (usecase MerchantUsecase) ResendVerification(ctx context.Context, email string) (bool, error) {
+
+	email = strings.TrimSpace(email)
+
+	if !merchantEmail.MatchString(email) {
+		return false, errors.New("invalid email")
+	}
+
+	merchant, err := usecase.repo.GetByEmail(ctx, email)
+	if err != nil {
+		return false, err
+	}
+
+	if merchant == nil || merchant.VerifiedAt != nil {
+		return true, nil
+	}
+
+	latest, err := usecase.repo.GetLatestVerification(ctx, merchant.ID, entities.VerificationSignUp)
+	if err != nil {
+		return false, err
+	}
+
+	if latest != nil && time.Since(latest.CreatedAt) < signUpResendLimit {
+		return false, errors.New("please wait a minute before requesting another mail")
+	}
+
+	_, err = usecase.repo.RevokeVerifications(ctx, merchant.ID, entities.VerificationSignUp)
+	if err != nil {
+		return false, err
+	}
+
+	err = usecase.sendSignUpVerification(ctx, *merchant)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (usecase MerchantUsecase) createVerification(ctx context.Context, merchantID int64, purpose string, email string, ttl time.Duration) (string, error) {
+
+	token, hash, err := newVerificationToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = usecase.repo.CreateVerification(ctx, entities.MerchantVerification{
+		MerchantID: merchantID,
+		Purpose:    purpose,
+		Email:      email,
+		TokenHash:  hash,
+		ExpiresAt:  time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// redeemVerification looks up a mailed token and marks it used. Unknown,
+// used and expired tokens are reported alike.
+func (usecase MerchantUsecase) redeemVerification(ctx context.Context, purpose string, token string) (entities.MerchantVerification, error) {
+
+	verification, err := usecase.repo.GetVerification(ctx, purpose, hashVerificationToken(token))
+	if err != nil {
+		return entities.MerchantVerification{}, err
+	}
+
+	if verification == nil || verification.UsedAt != nil || verification.ExpiresAt.Before(time.Now()) {
+		return entities.MerchantVerification{}, errors.New("link is invalid or has expired")
+	}
+
+	consumed, err := usecase.repo.ConsumeVerification(ctx, verification.ID)
+	if err != nil {
+		return entities.MerchantVerification{}, err
+	}
+
+	if !consumed {
+		return entities.MerchantVerification{}, errors.New("link is invalid or has expired")
+	}
+
+	return *verification, nil
+}
+
+// Update applies a partial profile update. A new email only takes effect
+// once confirmed from a link sent to it, and a new password signs out every
+// other session of the merchant.
+func (usecase MerchantUsecase) Update(ctx context.Context, id int64, token string, update entities.MerchantUpdate) (entities.Merchant, error) {
+
+	merchant, err := usecase.repo.GetSingle(ctx, id)
+	if err != nil {
+		return entities.Merchant{}, err
+	}
+
+	if merchant == nil {
+		return entities.Merchant{}, errors.New("not found")
+	}
+
+	err = usecase.applyProfile(ctx, merchant, update)
+	if err != nil {
+		return entities.Merchant{}, err
+	}
+
+	var email string
+
+	if update.Email != nil {
+		email = strings.TrimSpace(*update.Email)
+
+		if !merchantEmail.MatchString(email) {
+			return entities.Merchant{}, errors.New("invalid email")
+		}
+
+		if strings.EqualFold(email, merchant.Email) {
+			email = ""
+		}
+	}
+
+	if update.Password != nil && len(*update.Password) < 8 {
+		return entities.Merchant{}, errors.New("password must be at least 8 characters")
+	}
+
+	if len(email) != 0 || update.Password != nil {
+		err = usecase.checkPassword(ctx, id, update.CurrentPassword)
+		if err != nil {
+			return entities.Merchant{}, err
+		}
+	}
+
+	_, err = usecase.repo.Update(ctx, *merchant)
+	if err != nil {
+		return entities.Merchant{}, err
+	}
+
+	usecase.syncSuggestion(*merchant)
+
+	if update.Password != nil {
+		hash, err := password.Hash(*update.Password)
+		if err != nil {
+			return entities.Merchant{}, err
+		}
+
+		_, err = usecase.repo.UpdatePassword(ctx, id, hash, token)
+		if err != nil {
+			return entities.Merchant{}, err
+		}
+	}
+
+	if len(email) != 0 {
+		err = usecase.requestEmailChange(ctx, *merchant, email)
+		if err != nil {
+			return entities.Merchant{}, err
+		}
+
+		merchant.PendingEmail = email
+	}
+
+	fillMerchantImageURLs(usecase.blobs, merchant)
+
+	return *merchant, nil
+}
+
+func (usecase MerchantUsecase) applyProfile(ctx context.Context, merchant *entities.Merchant, update entities.MerchantUpdate) error {
+
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+
+		if len(name) == 0 || len(name) > 120 {
+			return errors.New("name must be inbetween 1 - 120 characters")
+		}
+
+		merchant.Name = name
+	}
+
+	if update.Category != nil {
+		err := usecase.checkCategory(ctx, *update.Category)
+		if err != nil {
+			return err
+		}
+
+		merchant.Category = *update.Category
+	}
+
+	if update.Country != nil {
+		country := strings.ToUpper(strings.TrimSpace(*update.Country))
+
+		if !identity.IsCountry(country) {
+			return errors.New("unsupported country")
+		}
+
+		merchant.Country = country
+	}
+
+	links := []struct {
+		value  *string
+		target *string
+	}{
+		{update.Facebook, &merchant.Facebook},
+		{update.Instagram, &merchant.Instagram},
+		{update.Website, &merchant.Website},
+	}
+
+	for _, link := range links {
+		if link.value == nil {
+			continue
+		}
+
+		value := strings.TrimSpace(*link.value)
+
+		if len(value) > 255 {
+			return errors.New("links must be at most 255 characters")
+		}
+
+		*link.target = value
+	}
+
+	return nil
+}
+
+// checkCategory makes sure merchants only pick active categories.
+func (usecase MerchantUsecase) checkCategory(ctx context.Context, name string) error {
+
+	categories, err := usecase.repo.GetCategories(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, category := range categories {
+		if category.Name == name {
+			return nil
+		}
+	}
+
+	return errors.New("there are no such category")
+}
+
+func (usecase MerchantUsecase) checkPassword(ctx context.Context, id int64, given string) error {
+
+	if len(given) == 0 {
+		return errors.New("current password is required")
+	}
+
+	stored, err := usecase.repo.GetPassword(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	ok, _, err := password.Verify(given, stored)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return errors.New("current password is wrong")
+	}
+
+	return nil
+}
+
+func (usecase MerchantUsecase) requestEmailChange(ctx context.Context, merchant entities.Merchant, email string) error {
+
+	token, err := usecase.createVerification(ctx, merchant.ID, entities.VerificationEmailChange, email, emailChangeTTL)
+	if err != nil {
+		return err
+	}
+
+	err = usecase.mailer.Send(ctx, entities.Mail{
+		To:      email,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nOpen the link below within 24 hours to use this address for your No-Q account:\n\n%s\n",
+			merchant.Name,
+			usecase.mailer.URL("/merchant/confirm_email/"+token),
+		),
+	})
+	if err != nil {
+		return err
+	}
+
+	return usecase.mailer.Send(ctx, entities.Mail{
+		To:      merchant.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nA change of your No-Q account email to %s was requested. It takes effect once confirmed from the new address.\n",
+			merchant.Name,
+			email,
+		),
+	})
+}
+
+func (usecase MerchantUsecase) ConfirmEmail(ctx context.Context, token string) (bool, error) {
+
+	verification, err := usecase.redeemVerification(ctx, entities.VerificationEmailChange, token)
+	if err != nil {
+		return false, err
+	}
+
+	return usecase.repo.UpdateEmail(ctx, verification.MerchantID, verification.Email)
+}
+
+// ForgotPassword mails a single use reset link when the email belongs to a
+// merchant. It succeeds either way so it cannot be used to probe accounts.
+func (usecase MerchantUsecase) ForgotPassword(ctx context.Context, email string) (bool, error) {
+
+	email = strings.TrimSpace(email)
+
+	if !merchantEmail.MatchString(email) {
+		return false, errors.New("invalid email")
+	}
 
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	merchant, err := usecase.repo.GetByEmail(ctx, email)
+	if err != nil {
+		return false, err
+	}
 
-	if !emailRegex.MatchString(login.Email) {
+	if merchant == nil {
+		return true, nil
+	}
+
+	latest, err := usecase.repo.GetLatestVerification(ctx, merchant.ID, entities.VerificationPasswordReset)
+	if err != nil {
+		return false, err
+	}
+
+	if latest != nil && time.Since(latest.CreatedAt) < passwordResetLimit {
+		return true, nil
+	}
+
+	_, err = usecase.repo.RevokeVerifications(ctx, merchant.ID, entities.VerificationPasswordReset)
+	if err != nil {
+		return false, err
+	}
+
+	token, err := usecase.createVerification(ctx, merchant.ID, entities.VerificationPasswordReset, merchant.Email, passwordResetTTL)
+	if err != nil {
+		return false, err
+	}
+
+	err = usecase.mailer.Send(ctx, entities.Mail{
+		To:      merchant.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nOpen the link below within an hour to choose a new password for your No-Q account:\n\n%s\n\nIf you did not ask for this, you can ignore this mail.\n",
+			merchant.Name,
+			usecase.mailer.ResetPasswordURL(token),
+		),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ResetPassword sets a new password from a reset link and signs the
+// merchant out everywhere.
+func (usecase MerchantUsecase) ResetPassword(ctx context.Context, token string, newPassword string) (bool, error) {
+
+	if len(newPassword) < 8 {
+		return false, errors.New("password must be at least 8 characters")
+	}
+
+	verification, err := usecase.redeemVerification(ctx, entities.VerificationPasswordReset, token)
+	if err != nil {
+		return false, err
+	}
+
+	hash, err := password.Hash(newPassword)
+	if err != nil {
+		return false, err
+	}
+
+	// No session is kept, every row in token is removed.
+	return usecase.repo.UpdatePassword(ctx, verification.MerchantID, hash, "")
+}
+
+func (usecase MerchantUsecase) Login(ctx context.Context, login entities.Login) (string, error) {
+
+	if !merchantEmail.MatchString(login.Email) {
 		return "", errors.New("invalid email")
 	}
 
@@ -86,7 +838,7 @@ func (usecase MerchantUsecase) Login(ctx context.Context, login entities.Login)
 		return "", errors.New("password not found")
 	}
 
-	merchant, err := usecase.repo.Login(ctx, login)
+	merchant, err := usecase.repo.GetByEmail(ctx, login.Email)
 	if err != nil {
 		return "", err
 	}
@@ -95,6 +847,29 @@ func (usecase MerchantUsecase) Login(ctx context.Context, login entities.Login)
 		return "", errors.New("invalid email or password")
 	}
 
+	ok, rehash, err := password.Verify(login.Password, merchant.Password)
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		return "", errors.New("invalid email or password")
+	}
+
+	// Plaintext passwords from before hashing and hashes with outdated
+	// settings are upgraded while the password is at hand.
+	if rehash {
+		hash, err := password.Hash(login.Password)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = usecase.repo.RehashPassword(ctx, merchant.ID, merchant.Password, hash)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	token := uuid.New().String()
 
 	token, err = usecase.repo.CreateToken(ctx, merchant.ID, token)
@@ -105,12 +880,106 @@ func (usecase MerchantUsecase) Login(ctx context.Context, login entities.Login)
 	return token, nil
 }
 
-func (usecase MerchantUsecase) Logout(ctx context.Context, id int64) (bool, error) {
+func (usecase MerchantUsecase) CreateDisplayKey(ctx context.Context, merchantID int64) (entities.DisplayKey, error) {
+
+	bytes := make([]byte, 24)
+
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return entities.DisplayKey{}, err
+	}
+
+	return usecase.repo.CreateDisplayKey(ctx, merchantID, hex.EncodeToString(bytes))
+}
+
+func (usecase MerchantUsecase) GetDisplayKeys(ctx context.Context, merchantID int64) ([]entities.DisplayKey, error) {
+
+	return usecase.repo.GetDisplayKeys(ctx, merchantID)
+}
+
+func (usecase MerchantUsecase) RevokeDisplayKey(ctx context.Context, merchantID int64, keyID int64) (bool, error) {
 
-	return usecase.repo.Logout(ctx, id)
+	return usecase.repo.RevokeDisplayKey(ctx, merchantID, keyID)
 }
 
+func (usecase MerchantUsecase) Logout(ctx context.Context, staff entities.Staff) (bool, error) {
+
+	return usecase.repo.Logout(ctx, staff)
+}
+
+// Delete hides the merchant and ends its sessions. It can be restored within
+// entities.RestoreWindow, after which it is purged.
 func (usecase MerchantUsecase) Delete(ctx context.Context, id int64) (bool, error) {
 
-	return usecase.repo.Delete(ctx, id)
+	done, err := usecase.repo.Delete(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	usecase.suggest.RemoveMerchant(id)
+
+	return done, nil
+}
+
+// GetDeleted lists deleted merchants that can still be restored, for admins.
+func (usecase MerchantUsecase) GetDeleted(ctx context.Context, paginator entities.Paginator) ([]entities.Merchant, error) {
+
+	return usecase.repo.GetDeleted(ctx, paginator)
+}
+
+// Restore undoes a deletion inside the restore window. Admins restore by id.
+func (usecase MerchantUsecase) Restore(ctx context.Context, id int64) (entities.Merchant, error) {
+
+	_, err := usecase.repo.Restore(ctx, id, time.Now().Add(-entities.RestoreWindow))
+	if err != nil {
+		return entities.Merchant{}, err
+	}
+
+	merchant, err := usecase.repo.GetSingle(ctx, id)
+	if err != nil {
+		return entities.Merchant{}, err
+	}
+
+	if merchant == nil {
+		return entities.Merchant{}, errors.New("not found")
+	}
+
+	usecase.syncSuggestion(*merchant)
+
+	fillMerchantImageURLs(usecase.blobs, merchant)
+
+	return *merchant, nil
+}
+
+// RestoreAccount lets owners undo the deletion of their own account with the
+// credentials they signed in with. They sign in again afterwards.
+func (usecase MerchantUsecase) RestoreAccount(ctx context.Context, login entities.Login) (entities.Merchant, error) {
+
+	if !merchantEmail.MatchString(login.Email) {
+		return entities.Merchant{}, errors.New("invalid email")
+	}
+
+	if len(login.Password) == 0 {
+		return entities.Merchant{}, errors.New("password not found")
+	}
+
+	merchant, err := usecase.repo.GetDeletedByEmail(ctx, login.Email)
+	if err != nil {
+		return entities.Merchant{}, err
+	}
+
+	if merchant == nil {
+		return entities.Merchant{}, errors.New("invalid email or password")
+	}
+
+	ok, _, err := password.Verify(login.Password, merchant.Password)
+	if err != nil {
+		return entities.Merchant{}, err
+	}
+
+	if !ok {
+		return entities.Merchant{}, errors.New("invalid email or password")
+	}
+
+	return usecase.Restore(ctx, merchant.ID)
 }
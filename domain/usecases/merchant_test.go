@@ -0,0 +1,35 @@
+package usecases
+
+import (
+	"context"
+	"no-q-solution/domain/entities"
+	"testing"
+)
+
+func TestCreateMerchantPassword(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		password string
+		err      string
+	}{
+		{name: "missing password", password: "", err: "password not found"},
+		{name: "short password", password: "1234567", err: "password must be at least 8 characters"},
+	}
+
+	usecase := NewMerchantUsecase(nil, nil, nil, nil)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := usecase.Create(context.Background(), entities.Merchant{
+				Name:     "Cafe",
+				Email:    "cafe@example.com",
+				Password: test.password,
+			})
+
+			if err == nil || err.Error() != test.err {
+				t.Errorf("Create() error = %v, want %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/utils/identity"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+type NoShowUsecase struct {
+	repo         interfaces.NoShowRepository
+	queueRepo    interfaces.QueueRepository
+	merchantRepo interfaces.MerchantRepository
+}
+
+func NewNoShowUsecase(repo interfaces.NoShowRepository, queueRepo interfaces.QueueRepository, merchantRepo interfaces.MerchantRepository) NoShowUsecase {
+	usecase := NoShowUsecase{
+		repo:         repo,
+		queueRepo:    queueRepo,
+		merchantRepo: merchantRepo,
+	}
+
+	return usecase
+}
+
+func (usecase NoShowUsecase) GetPolicy(ctx context.Context, merchantID int64) (entities.NoShowPolicy, error) {
+
+	return usecase.repo.GetPolicy(ctx, merchantID)
+}
+
+func (usecase NoShowUsecase) SavePolicy(ctx context.Context, policy entities.NoShowPolicy) (entities.NoShowPolicy, error) {
+
+	if policy.MaxNoShows < 1 || policy.WindowDays < 1 || policy.BlockDays < 1 {
+		return entities.NoShowPolicy{}, errors.New("no-shows, window and block days must be positive")
+	}
+
+	if policy.GraceMinutes < 0 {
+		return entities.NoShowPolicy{}, errors.New("grace minutes cannot be negative")
+	}
+
+	_, err := usecase.repo.SavePolicy(ctx, policy)
+	if err != nil {
+		return entities.NoShowPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+func (usecase NoShowUsecase) MarkNoShow(ctx context.Context, merchantID int64, tokenNo int64) (bool, error) {
+
+	reservation, err := usecase.queueRepo.GetReservation(ctx, tokenNo)
+	if err != nil {
+		return false, err
+	}
+
+	if reservation == nil {
+		return false, errors.New("there are no such token no")
+	}
+
+	_, err = usecase.queueRepo.IsQueueBelongsToMerchant(ctx, merchantID, reservation.QueueID)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+
+	if reservation.StartTime.After(now) {
+		return false, errors.New("reservation has not started yet")
+	}
+
+	return usecase.repo.MarkNoShow(ctx, tokenNo, now)
+}
+
+// DetectNoShows marks missed reservations for merchants with auto detection
+// turned on. It is run periodically by the scheduler.
+func (usecase NoShowUsecase) DetectNoShows(ctx context.Context) (int64, error) {
+
+	return usecase.repo.MarkMissedSlots(ctx, time.Now())
+}
+
+func (usecase NoShowUsecase) GetRestrictions(ctx context.Context, merchantID int64) ([]entities.CustomerRestriction, error) {
+
+	return usecase.repo.GetRestrictions(ctx, merchantID)
+}
+
+func (usecase NoShowUsecase) CreateRestriction(ctx context.Context, restriction entities.CustomerRestriction) (entities.CustomerRestriction, error) {
+
+	country, err := usecase.merchantRepo.GetCountry(ctx, restriction.MerchantID)
+	if err != nil {
+		return entities.CustomerRestriction{}, err
+	}
+
+	restriction.Phone, err = identity.ParsePhone(restriction.Phone, country)
+	if err != nil {
+		return entities.CustomerRestriction{}, err
+	}
+
+	if restriction.Mode != entities.RestrictionBlock && restriction.Mode != entities.RestrictionAllow {
+		return entities.CustomerRestriction{}, errors.New("mode must be block or allow")
+	}
+
+	if restriction.Until != nil && restriction.Until.Before(time.Now()) {
+		return entities.CustomerRestriction{}, errors.New("until must be in the future")
+	}
+
+	id, err := usecase.repo.CreateRestriction(ctx, restriction)
+	if err != nil {
+		return entities.CustomerRestriction{}, err
+	}
+
+	restriction.ID = id
+	restriction.CreatedAt = time.Now()
+
+	return restriction, nil
+}
+
+func (usecase NoShowUsecase) DeleteRestriction(ctx context.Context, merchantID int64, id int64) (bool, error) {
+
+	return usecase.repo.DeleteRestriction(ctx, merchantID, id)
+}
+
+// checkBookingAllowed applies the merchant's manual overrides first and then
+// its no-show policy to a customer trying to book one of its queues.
+func checkBookingAllowed(ctx context.Context, repo interfaces.NoShowRepository, queueID int64, phone string) error {
+
+	merchantID, err := repo.GetMerchantIDByQueue(ctx, queueID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	restriction, err := repo.GetActiveRestriction(ctx, merchantID, phone, now)
+	if err != nil {
+		return err
+	}
+
+	if restriction != nil {
+		if restriction.Mode == entities.RestrictionAllow {
+			return nil
+		}
+
+		return errors.New("online booking is blocked for this customer")
+	}
+
+	policy, err := repo.GetPolicy(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	if !policy.Enabled {
+		return nil
+	}
+
+	window := time.Duration(policy.WindowDays) * day
+	block := time.Duration(policy.BlockDays) * day
+
+	times, err := repo.GetNoShowTimes(ctx, merchantID, phone, now.Add(-window-block))
+	if err != nil {
+		return err
+	}
+
+	// a block starts at the no-show that reaches the limit within the window
+	for i := policy.MaxNoShows - 1; i < len(times); i++ {
+		first := times[i-policy.MaxNoShows+1]
+
+		if times[i].Sub(first) > window {
+			continue
+		}
+
+		if until := times[i].Add(block); until.After(now) {
+			return fmt.Errorf("online booking is blocked until %s after repeated no-shows", until.Format("2006-01-02"))
+		}
+	}
+
+	return nil
+}
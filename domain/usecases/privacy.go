@@ -0,0 +1,270 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/utils/identity"
+	"time"
+)
+
+type PrivacyUsecase struct {
+	repo         interfaces.PrivacyRepository
+	merchantRepo interfaces.MerchantRepository
+	blobs        interfaces.BlobStore
+}
+
+func NewPrivacyUsecase(repo interfaces.PrivacyRepository, merchantRepo interfaces.MerchantRepository, blobs interfaces.BlobStore) PrivacyUsecase {
+	usecase := PrivacyUsecase{
+		repo:         repo,
+		merchantRepo: merchantRepo,
+		blobs:        blobs,
+	}
+
+	return usecase
+}
+
+// Export collects everything held about the subject. Secrets such as
+// passwords, session tokens and code hashes are left out.
+func (usecase PrivacyUsecase) Export(ctx context.Context, subject entities.PrivacySubject, requestedBy string) (entities.PrivacyArchive, error) {
+
+	subject, err := normalizeSubject(subject)
+	if err != nil {
+		return entities.PrivacyArchive{}, err
+	}
+
+	archive := entities.PrivacyArchive{
+		Subject:     subject,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	affected := 0
+
+	if subject.MerchantID != 0 {
+		err = usecase.exportMerchant(ctx, subject.MerchantID, &archive)
+		if err != nil {
+			return entities.PrivacyArchive{}, err
+		}
+
+		affected++
+	} else {
+		err = usecase.exportCustomer(ctx, subject, &archive)
+		if err != nil {
+			return entities.PrivacyArchive{}, err
+		}
+
+		affected = len(archive.Customers)
+	}
+
+	_, err = usecase.repo.Audit(ctx, entities.PrivacyAudit{
+		Action:      entities.PrivacyExport,
+		Subject:     subject,
+		RequestedBy: requestedBy,
+		Affected:    affected,
+	})
+	if err != nil {
+		return entities.PrivacyArchive{}, err
+	}
+
+	return archive, nil
+}
+
+// Erase anonymizes the customer rows matching the subject, or removes the
+// merchant and everything under it including its image blobs.
+func (usecase PrivacyUsecase) Erase(ctx context.Context, subject entities.PrivacySubject, requestedBy string) (int, error) {
+
+	subject, err := normalizeSubject(subject)
+	if err != nil {
+		return 0, err
+	}
+
+	affected := 0
+
+	if subject.MerchantID != 0 {
+		merchant, err := usecase.repo.GetMerchant(ctx, subject.MerchantID)
+		if err != nil {
+			return 0, err
+		}
+
+		if merchant == nil {
+			return 0, errors.New("there are no such merchant exists")
+		}
+
+		images, err := usecase.repo.GetImages(ctx, subject.MerchantID)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = usecase.repo.EraseMerchant(ctx, subject.MerchantID)
+		if err != nil {
+			return 0, err
+		}
+
+		affected = 1
+
+		// the rows are gone, so a blob that cannot be deleted is only logged
+		for _, image := range images {
+			for _, key := range []string{image.Key, image.ThumbnailKey} {
+				err = usecase.blobs.Delete(ctx, key)
+				if err != nil {
+					log.Printf("could not delete blob %s: %v", key, err)
+				}
+			}
+		}
+	} else {
+		users, err := usecase.repo.FindUsers(ctx, subject.Phone, subject.Email)
+		if err != nil {
+			return 0, err
+		}
+
+		userIDs, phones := userKeys(users, subject.Phone)
+
+		_, err = usecase.repo.EraseUsers(ctx, userIDs, phones)
+		if err != nil {
+			return 0, err
+		}
+
+		affected = len(users)
+	}
+
+	_, err = usecase.repo.Audit(ctx, entities.PrivacyAudit{
+		Action:      entities.PrivacyErase,
+		Subject:     subject,
+		RequestedBy: requestedBy,
+		Affected:    affected,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
+
+func (usecase PrivacyUsecase) exportCustomer(ctx context.Context, subject entities.PrivacySubject, archive *entities.PrivacyArchive) error {
+
+	users, err := usecase.repo.FindUsers(ctx, subject.Phone, subject.Email)
+	if err != nil {
+		return err
+	}
+
+	userIDs, phones := userKeys(users, subject.Phone)
+
+	archive.Customers = users
+
+	archive.Bookings, err = usecase.repo.GetBookings(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	archive.OTPRequests, err = usecase.repo.GetOTPRequests(ctx, phones)
+	if err != nil {
+		return err
+	}
+
+	archive.CustomerSessions, err = usecase.repo.GetCustomerSessions(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	archive.Restrictions, err = usecase.repo.GetRestrictions(ctx, phones)
+	if err != nil {
+		return err
+	}
+
+	archive.Reviews, err = usecase.repo.GetReviews(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (usecase PrivacyUsecase) exportMerchant(ctx context.Context, merchantID int64, archive *entities.PrivacyArchive) error {
+
+	// deleted merchants and queues are still stored during the restore
+	// window, so they are looked up including deleted rows
+	merchant, err := usecase.repo.GetMerchant(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	if merchant == nil {
+		return errors.New("there are no such merchant exists")
+	}
+
+	archive.Merchant = merchant
+
+	archive.Queues, err = usecase.repo.GetQueues(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	archive.Branches, err = usecase.repo.GetBranches(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	archive.Staff, err = usecase.repo.GetStaff(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	archive.Images, err = usecase.repo.GetImages(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	for i := range archive.Images {
+		archive.Images[i].URL = usecase.blobs.URL(archive.Images[i].Key)
+		archive.Images[i].ThumbnailURL = usecase.blobs.URL(archive.Images[i].ThumbnailKey)
+	}
+
+	archive.DisplayKeys, err = usecase.merchantRepo.GetDisplayKeys(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	archive.MerchantSessions, err = usecase.repo.GetMerchantSessions(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func normalizeSubject(subject entities.PrivacySubject) (entities.PrivacySubject, error) {
+
+	subject.Phone = identity.NormalizePhone(subject.Phone)
+	subject.Email = identity.NormalizeEmail(subject.Email)
+
+	if subject.MerchantID == 0 && len(subject.Phone) == 0 && len(subject.Email) == 0 {
+		return entities.PrivacySubject{}, errors.New("phone, email or merchant id is required")
+	}
+
+	return subject, nil
+}
+
+func userKeys(users []entities.User, phone string) ([]int64, []string) {
+
+	userIDs := make([]int64, 0, len(users))
+	phones := make([]string, 0, len(users)+1)
+	seen := make(map[string]bool)
+
+	if len(phone) != 0 {
+		phones = append(phones, phone)
+		seen[phone] = true
+	}
+
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+
+		if len(user.Phone) != 0 && !seen[user.Phone] {
+			phones = append(phones, user.Phone)
+			seen[user.Phone] = true
+		}
+	}
+
+	return userIDs, phones
+}
@@ -0,0 +1,84 @@
+package usecases
+
+import (
+	"context"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"time"
+)
+
+// PurgeUsecase removes merchants and queues for good once they have been
+// deleted for longer than entities.RestoreWindow.
+type PurgeUsecase struct {
+	merchants interfaces.MerchantRepository
+	queues    interfaces.QueueRepository
+	images    interfaces.ImageRepository
+	blobs     interfaces.BlobStore
+}
+
+func NewPurgeUsecase(merchants interfaces.MerchantRepository, queues interfaces.QueueRepository, images interfaces.ImageRepository, blobs interfaces.BlobStore) PurgeUsecase {
+	usecase := PurgeUsecase{
+		merchants: merchants,
+		queues:    queues,
+		images:    images,
+		blobs:     blobs,
+	}
+
+	return usecase
+}
+
+// PurgeMerchants purges merchants deleted before the restore window and
+// deletes their image blobs. A merchant that fails is retried next run.
+func (usecase PurgeUsecase) PurgeMerchants(ctx context.Context) (int64, error) {
+
+	ids, err := usecase.merchants.GetPurgeable(ctx, time.Now().Add(-entities.RestoreWindow))
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+
+	for _, id := range ids {
+		keys := make([]string, 0)
+
+		for _, kind := range []string{entities.ImageLogo, entities.ImageGallery} {
+			images, err := usecase.images.GetByMerchant(ctx, id, kind)
+			if err != nil {
+				return purged, err
+			}
+
+			for _, image := range images {
+				keys = append(keys, image.Key, image.ThumbnailKey)
+			}
+		}
+
+		done, err := usecase.merchants.Purge(ctx, id)
+		if err != nil {
+			return purged, err
+		}
+
+		if !done {
+			continue
+		}
+
+		purged++
+
+		// the rows are gone, so a blob that cannot be deleted is only logged
+		for _, key := range keys {
+			err = usecase.blobs.Delete(ctx, key)
+			if err != nil {
+				log.Printf("could not delete blob %s: %v", key, err)
+			}
+		}
+	}
+
+	return purged, nil
+}
+
+// PurgeQueues purges queues deleted before the restore window together with
+// their reservations.
+func (usecase PurgeUsecase) PurgeQueues(ctx context.Context) (int64, error) {
+
+	return usecase.queues.Purge(ctx, time.Now().Add(-entities.RestoreWindow))
+}
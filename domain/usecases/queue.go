@@ -5,16 +5,25 @@ import (
 	"errors"
 	"no-q-solution/domain/entities"
 	"no-q-solution/domain/interfaces"
+	"no-q-solution/utils/identity"
 	"time"
 )
 
 type QueuetUsecase struct {
-	repo interfaces.QueueRepository
+	repo      interfaces.QueueRepository
+	customers interfaces.CustomerRepository
+	noShows   interfaces.NoShowRepository
+	checkIn   interfaces.CheckInSigner
+	hub       interfaces.EventHub
 }
 
-func NewQueuetUsecase(repo interfaces.QueueRepository) QueuetUsecase {
+func NewQueuetUsecase(repo interfaces.QueueRepository, customers interfaces.CustomerRepository, noShows interfaces.NoShowRepository, checkIn interfaces.CheckInSigner, hub interfaces.EventHub) QueuetUsecase {
 	usecase := QueuetUsecase{
-		repo: repo,
+		repo:      repo,
+		customers: customers,
+		noShows:   noShows,
+		checkIn:   checkIn,
+		hub:       hub,
 	}
 
 	return usecase
@@ -67,7 +76,21 @@ func (usecase QueuetUsecase) MakeDatesUnAvailable(ctx context.Context, merchantI
 		return false, err
 	}
 
-	return usecase.repo.MakeDatesUnAvailable(ctx, queueID, dates)
+	done, err := usecase.repo.MakeDatesUnAvailable(ctx, queueID, dates)
+	if err != nil {
+		return false, err
+	}
+
+	for _, date := range dates {
+		usecase.hub.Publish(entities.QueueEvent{
+			Type:       entities.EventDateClosed,
+			QueueID:    queueID,
+			Date:       date,
+			OccurredAt: time.Now(),
+		})
+	}
+
+	return done, nil
 }
 
 func (usecase QueuetUsecase) Create(ctx context.Context, queue entities.Queue) (entities.Queue, error) {
@@ -80,17 +103,287 @@ func (usecase QueuetUsecase) Create(ctx context.Context, queue entities.Queue) (
 		return entities.Queue{}, errors.New("given time range is wrong")
 	}
 
-	return usecase.repo.Create(ctx, queue)
+	queue, err := usecase.repo.Create(ctx, queue)
+	if err != nil {
+		return entities.Queue{}, err
+	}
+
+	usecase.hub.AddQueue(queue.MerchantID, queue.ID)
+
+	return queue, nil
+}
+
+// checkMerchantApproved refuses bookings at merchants that are not approved.
+func (usecase QueuetUsecase) checkMerchantApproved(ctx context.Context, queueID int64) error {
+
+	status, err := usecase.repo.GetMerchantStatus(ctx, queueID)
+	if err != nil {
+		return err
+	}
+
+	if status != entities.MerchantApproved {
+		return errors.New("this merchant is not taking reservations")
+	}
+
+	return nil
 }
 
 func (usecase QueuetUsecase) ReserveSlot(ctx context.Context, reserve entities.ReservedSlots) (entities.ReservedSlots, error) {
 
-	return usecase.repo.ReserveSlot(ctx, reserve)
+	err := usecase.checkMerchantApproved(ctx, reserve.QueueID)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	country, err := usecase.repo.GetMerchantCountry(ctx, reserve.QueueID)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	reserve.ReservedBy.Phone, err = identity.ParsePhone(reserve.ReservedBy.Phone, country)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	reserve.ReservedBy.Email = identity.NormalizeEmail(reserve.ReservedBy.Email)
+
+	err = checkBookingAllowed(ctx, usecase.noShows, reserve.QueueID, reserve.ReservedBy.Phone)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	user, err := usecase.customers.ResolveUser(ctx, reserve.ReservedBy)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	reserve.ReservedBy = user
+
+	reserve, err = usecase.repo.ReserveSlot(ctx, reserve)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	reserve.CheckInCode = usecase.checkIn.Sign(entities.CheckIn{
+		TokenNo:   reserve.TokenNo,
+		QueueID:   reserve.QueueID,
+		StartTime: reserve.StartTime,
+	})
+
+	usecase.publish(entities.EventSlotReserved, reserve)
+
+	return reserve, nil
 }
 
-func (usecase QueuetUsecase) UnReserveSlot(ctx context.Context, tokenNo int64) (bool, error) {
+func (usecase QueuetUsecase) GetReservation(ctx context.Context, tokenNo int64) (entities.ReservedSlots, error) {
 
-	return usecase.repo.UnReserveSlot(ctx, tokenNo)
+	reservation, err := usecase.repo.GetReservation(ctx, tokenNo)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	if reservation == nil {
+		return entities.ReservedSlots{}, errors.New("there are no such token no")
+	}
+
+	return *reservation, nil
+}
+
+func (usecase QueuetUsecase) RescheduleSlot(ctx context.Context, reservation entities.ReservedSlots, startTime time.Time, endTime time.Time) (entities.ReservedSlots, error) {
+
+	if startTime.After(endTime) {
+		return entities.ReservedSlots{}, errors.New("given time range is wrong")
+	}
+
+	if reservation.NoShowAt != nil {
+		return entities.ReservedSlots{}, errors.New("a token marked as no-show cannot be rescheduled")
+	}
+
+	err := usecase.checkMerchantApproved(ctx, reservation.QueueID)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	err = checkBookingAllowed(ctx, usecase.noShows, reservation.QueueID, reservation.ReservedBy.Phone)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	_, err = usecase.repo.RescheduleSlot(ctx, reservation.TokenNo, startTime, endTime)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	usecase.publish(entities.EventSlotFreed, reservation)
+
+	reservation.StartTime = startTime
+	reservation.EndTime = endTime
+	reservation.ArrivedAt = nil
+	reservation.CalledAt = nil
+
+	reservation.CheckInCode = usecase.checkIn.Sign(entities.CheckIn{
+		TokenNo:   reservation.TokenNo,
+		QueueID:   reservation.QueueID,
+		StartTime: reservation.StartTime,
+	})
+
+	usecase.publish(entities.EventSlotReserved, reservation)
+
+	return reservation, nil
+}
+
+func (usecase QueuetUsecase) VerifyCheckInCode(ctx context.Context, code string) (entities.CheckIn, error) {
+
+	return usecase.checkIn.Verify(code)
+}
+
+func (usecase QueuetUsecase) CheckIn(ctx context.Context, merchantID int64, code string) (entities.ReservedSlots, error) {
+
+	checkIn, err := usecase.checkIn.Verify(code)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	reservation, err := usecase.repo.GetReservation(ctx, checkIn.TokenNo)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	if reservation == nil || reservation.QueueID != checkIn.QueueID || !reservation.StartTime.Equal(checkIn.StartTime) {
+		return entities.ReservedSlots{}, errors.New("reservation not found")
+	}
+
+	_, err = usecase.repo.IsQueueBelongsToMerchant(ctx, merchantID, reservation.QueueID)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	now := time.Now().In(reservation.StartTime.Location())
+
+	if now.Format("2006-01-02") != reservation.StartTime.Format("2006-01-02") {
+		return entities.ReservedSlots{}, errors.New("reservation is not for today")
+	}
+
+	_, err = usecase.repo.CheckIn(ctx, reservation.TokenNo, now)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	reservation.ArrivedAt = &now
+
+	return *reservation, nil
+}
+
+func (usecase QueuetUsecase) CallToken(ctx context.Context, merchantID int64, tokenNo int64) (entities.ReservedSlots, error) {
+
+	reservation, err := usecase.repo.GetReservation(ctx, tokenNo)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	if reservation == nil {
+		return entities.ReservedSlots{}, errors.New("there are no such token no")
+	}
+
+	_, err = usecase.repo.IsQueueBelongsToMerchant(ctx, merchantID, reservation.QueueID)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	now := time.Now()
+
+	_, err = usecase.repo.CallToken(ctx, tokenNo, now)
+	if err != nil {
+		return entities.ReservedSlots{}, err
+	}
+
+	reservation.CalledAt = &now
+
+	usecase.publish(entities.EventTokenCalled, *reservation)
+
+	return *reservation, nil
+}
+
+// UnReserveSlot cancels a reservation on one of the merchant's queues.
+func (usecase QueuetUsecase) UnReserveSlot(ctx context.Context, merchantID int64, tokenNo int64) (bool, error) {
+
+	reservation, err := usecase.repo.GetReservation(ctx, tokenNo)
+	if err != nil {
+		return false, err
+	}
+
+	if reservation == nil {
+		return false, errors.New("there are no such token no")
+	}
+
+	_, err = usecase.repo.IsQueueBelongsToMerchant(ctx, merchantID, reservation.QueueID)
+	if err != nil {
+		return false, err
+	}
+
+	return usecase.unReserveSlot(ctx, tokenNo)
+}
+
+// unReserveSlot cancels a reservation without checking who asks for it.
+// Callers check that the reservation is theirs first.
+func (usecase QueuetUsecase) unReserveSlot(ctx context.Context, tokenNo int64) (bool, error) {
+
+	reservation, err := usecase.repo.GetReservation(ctx, tokenNo)
+	if err != nil {
+		return false, err
+	}
+
+	done, err := usecase.repo.UnReserveSlot(ctx, tokenNo)
+	if err != nil {
+		return false, err
+	}
+
+	if reservation != nil {
+		usecase.publish(entities.EventSlotFreed, *reservation)
+	}
+
+	return done, nil
+}
+
+func (usecase QueuetUsecase) Subscribe(ctx context.Context, queueIDs []int64) (<-chan entities.QueueEvent, func()) {
+
+	return usecase.hub.Subscribe(queueIDs)
+}
+
+// SubscribeMerchant streams the events of all the merchant's queues,
+// including queues created or restored while the stream is open.
+func (usecase QueuetUsecase) SubscribeMerchant(ctx context.Context, merchantID int64) (<-chan entities.QueueEvent, func(), error) {
+
+	// subscribe before listing so a queue created in between is not missed
+	events, unsubscribe := usecase.hub.SubscribeMerchant(merchantID)
+
+	queues, err := usecase.repo.GetByMerchant(ctx, merchantID)
+	if err != nil {
+		unsubscribe()
+		return nil, nil, err
+	}
+
+	for _, queue := range queues {
+		usecase.hub.AddQueue(merchantID, queue.ID)
+	}
+
+	return events, unsubscribe, nil
+}
+
+func (usecase QueuetUsecase) publish(eventType string, reservation entities.ReservedSlots) {
+
+	user := reservation.ReservedBy
+
+	usecase.hub.Publish(entities.QueueEvent{
+		Type:       eventType,
+		QueueID:    reservation.QueueID,
+		TokenNo:    reservation.TokenNo,
+		StartTime:  reservation.StartTime,
+		EndTime:    reservation.EndTime,
+		Date:       reservation.StartTime,
+		ReservedBy: &user,
+		OccurredAt: time.Now(),
+	})
 }
 
 func (usecase QueuetUsecase) Delete(ctx context.Context, merchantID int64, queueID int64) (bool, error) {
@@ -102,3 +395,22 @@ func (usecase QueuetUsecase) Delete(ctx context.Context, merchantID int64, queue
 
 	return usecase.repo.Delete(ctx, merchantID, queueID)
 }
+
+// GetDeleted lists the merchant's deleted queues that can still be restored.
+func (usecase QueuetUsecase) GetDeleted(ctx context.Context, merchantID int64) ([]entities.Queue, error) {
+
+	return usecase.repo.GetDeleted(ctx, merchantID)
+}
+
+// Restore undoes the deletion of a queue inside the restore window.
+func (usecase QueuetUsecase) Restore(ctx context.Context, merchantID int64, queueID int64) (bool, error) {
+
+	done, err := usecase.repo.Restore(ctx, merchantID, queueID, time.Now().Add(-entities.RestoreWindow))
+	if err != nil {
+		return false, err
+	}
+
+	usecase.hub.AddQueue(merchantID, queueID)
+
+	return done, nil
+}
@@ -0,0 +1,188 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"testing"
+	"time"
+)
+
+// fakeQueueRepository serves reservations from memory. Methods the tests
+// do not use panic through the embedded nil interface.
+type fakeQueueRepository struct {
+	interfaces.QueueRepository
+	queues       map[int64]int64 // queue id to merchant id
+	reservations map[int64]entities.ReservedSlots
+}
+
+func (repo *fakeQueueRepository) GetReservation(ctx context.Context, tokenNo int64) (*entities.ReservedSlots, error) {
+
+	reservation, ok := repo.reservations[tokenNo]
+	if !ok {
+		return nil, nil
+	}
+
+	return &reservation, nil
+}
+
+func (repo *fakeQueueRepository) IsQueueBelongsToMerchant(ctx context.Context, merchantID int64, queueID int64) (bool, error) {
+
+	if repo.queues[queueID] != merchantID {
+		return false, errors.New("queue is not blongs to merchant")
+	}
+
+	return true, nil
+}
+
+func (repo *fakeQueueRepository) UnReserveSlot(ctx context.Context, tokenNo int64) (bool, error) {
+
+	delete(repo.reservations, tokenNo)
+
+	return true, nil
+}
+
+func (repo *fakeQueueRepository) GetMerchantStatus(ctx context.Context, queueID int64) (string, error) {
+	return entities.MerchantApproved, nil
+}
+
+// RescheduleSlot mirrors the repository: arrival and call are reset, a
+// recorded no-show stays.
+func (repo *fakeQueueRepository) RescheduleSlot(ctx context.Context, tokenNo int64, startTime time.Time, endTime time.Time) (bool, error) {
+
+	reservation := repo.reservations[tokenNo]
+	reservation.StartTime = startTime
+	reservation.EndTime = endTime
+	reservation.ArrivedAt = nil
+	reservation.CalledAt = nil
+	repo.reservations[tokenNo] = reservation
+
+	return true, nil
+}
+
+// fakeNoShowRepository allows every booking.
+type fakeNoShowRepository struct {
+	interfaces.NoShowRepository
+}
+
+func (repo fakeNoShowRepository) GetMerchantIDByQueue(ctx context.Context, queueID int64) (int64, error) {
+	return 1, nil
+}
+
+func (repo fakeNoShowRepository) GetActiveRestriction(ctx context.Context, merchantID int64, phone string, now time.Time) (*entities.CustomerRestriction, error) {
+	return nil, nil
+}
+
+func (repo fakeNoShowRepository) GetPolicy(ctx context.Context, merchantID int64) (entities.NoShowPolicy, error) {
+	return entities.NoShowPolicy{}, nil
+}
+
+type fakeCheckInSigner struct {
+	interfaces.CheckInSigner
+}
+
+func (signer fakeCheckInSigner) Sign(checkIn entities.CheckIn) string {
+	return "code"
+}
+
+type fakeEventHub struct {
+	published []entities.QueueEvent
+}
+
+func (hub *fakeEventHub) Publish(event entities.QueueEvent) {
+	hub.published = append(hub.published, event)
+}
+
+func (hub *fakeEventHub) Subscribe(queueIDs []int64) (<-chan entities.QueueEvent, func()) {
+	return nil, func() {}
+}
+
+func (hub *fakeEventHub) SubscribeMerchant(merchantID int64) (<-chan entities.QueueEvent, func()) {
+	return nil, func() {}
+}
+
+func (hub *fakeEventHub) AddQueue(merchantID int64, queueID int64) {}
+
+func TestUnReserveSlot(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		merchantID int64
+		tokenNo    int64
+		wantErr    bool
+	}{
+		{name: "own queue", merchantID: 1, tokenNo: 10},
+		{name: "other merchant's queue", merchantID: 2, tokenNo: 10, wantErr: true},
+		{name: "unknown token", merchantID: 1, tokenNo: 99, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &fakeQueueRepository{
+				queues:       map[int64]int64{5: 1},
+				reservations: map[int64]entities.ReservedSlots{10: {TokenNo: 10, QueueID: 5}},
+			}
+			hub := &fakeEventHub{}
+
+			usecase := NewQueuetUsecase(repo, nil, nil, nil, hub)
+
+			_, err := usecase.UnReserveSlot(context.Background(), test.merchantID, test.tokenNo)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+
+			_, kept := repo.reservations[10]
+			if kept != test.wantErr {
+				t.Errorf("reservation kept = %v, want %v", kept, test.wantErr)
+			}
+
+			if test.wantErr && len(hub.published) > 0 {
+				t.Errorf("published %d events for a refused cancellation", len(hub.published))
+			}
+		})
+	}
+}
+
+func TestRescheduleSlot(t *testing.T) {
+
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	noShowAt := start.Add(15 * time.Minute)
+
+	tests := []struct {
+		name        string
+		reservation entities.ReservedSlots
+		wantErr     bool
+	}{
+		{name: "booked", reservation: entities.ReservedSlots{TokenNo: 10, QueueID: 5, StartTime: start, EndTime: start.Add(time.Hour)}},
+		{name: "no-show", reservation: entities.ReservedSlots{TokenNo: 10, QueueID: 5, StartTime: start, EndTime: start.Add(time.Hour), NoShowAt: &noShowAt}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &fakeQueueRepository{
+				queues:       map[int64]int64{5: 1},
+				reservations: map[int64]entities.ReservedSlots{10: test.reservation},
+			}
+
+			usecase := NewQueuetUsecase(repo, nil, fakeNoShowRepository{}, fakeCheckInSigner{}, &fakeEventHub{})
+
+			newStart := start.Add(24 * time.Hour)
+
+			_, err := usecase.RescheduleSlot(context.Background(), test.reservation, newStart, newStart.Add(time.Hour))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+
+			stored := repo.reservations[10]
+
+			if (stored.NoShowAt != nil) != (test.reservation.NoShowAt != nil) {
+				t.Errorf("no-show recorded = %v, want %v", stored.NoShowAt != nil, test.reservation.NoShowAt != nil)
+			}
+
+			if moved := stored.StartTime.Equal(newStart); moved == test.wantErr {
+				t.Errorf("reservation moved = %v, want %v", moved, !test.wantErr)
+			}
+		})
+	}
+}
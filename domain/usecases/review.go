@@ -0,0 +1,127 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxReviewLength = 2000
+
+type ReviewUsecase struct {
+	repo      interfaces.ReviewRepository
+	queueRepo interfaces.QueueRepository
+}
+
+func NewReviewUsecase(repo interfaces.ReviewRepository, queueRepo interfaces.QueueRepository) ReviewUsecase {
+	usecase := ReviewUsecase{
+		repo:      repo,
+		queueRepo: queueRepo,
+	}
+
+	return usecase
+}
+
+// Create reviews a reservation of the customer. Only visits the customer
+// actually checked in for can be reviewed, once each.
+func (usecase ReviewUsecase) Create(ctx context.Context, user entities.User, review entities.Review) (entities.Review, error) {
+
+	review.Body = strings.TrimSpace(review.Body)
+
+	if review.Rating < 1 || review.Rating > 5 {
+		return entities.Review{}, errors.New("rating must be inbetween 1 - 5")
+	}
+
+	if utf8.RuneCountInString(review.Body) > maxReviewLength {
+		return entities.Review{}, errors.New("review is too long")
+	}
+
+	reservation, err := usecase.queueRepo.GetReservation(ctx, review.TokenNo)
+	if err != nil {
+		return entities.Review{}, err
+	}
+
+	if reservation == nil || reservation.ReservedBy.Phone != user.Phone {
+		return entities.Review{}, errors.New("there are no such token no")
+	}
+
+	if reservation.ArrivedAt == nil || reservation.NoShowAt != nil {
+		return entities.Review{}, errors.New("only served visits can be reviewed")
+	}
+
+	review.UserID = user.ID
+
+	id, err := usecase.repo.Create(ctx, review)
+	if err != nil {
+		return entities.Review{}, err
+	}
+
+	created, err := usecase.repo.GetSingle(ctx, id)
+	if err != nil {
+		return entities.Review{}, err
+	}
+
+	if created == nil {
+		return entities.Review{}, errors.New("there are no such review")
+	}
+
+	return *created, nil
+}
+
+func (usecase ReviewUsecase) GetByMerchant(ctx context.Context, merchantID int64, paginator entities.Paginator) ([]entities.Review, error) {
+
+	return usecase.repo.GetByMerchant(ctx, merchantID, paginator)
+}
+
+func (usecase ReviewUsecase) Reply(ctx context.Context, merchantID int64, id int64, reply string) (bool, error) {
+
+	reply = strings.TrimSpace(reply)
+
+	if len(reply) == 0 {
+		return false, errors.New("reply not found")
+	}
+
+	if utf8.RuneCountInString(reply) > maxReviewLength {
+		return false, errors.New("reply is too long")
+	}
+
+	return usecase.repo.Reply(ctx, merchantID, id, reply)
+}
+
+func (usecase ReviewUsecase) Flag(ctx context.Context, merchantID int64, id int64, reason string) (bool, error) {
+
+	reason = strings.TrimSpace(reason)
+
+	if len(reason) == 0 {
+		return false, errors.New("reason not found")
+	}
+
+	return usecase.repo.Flag(ctx, merchantID, id, reason)
+}
+
+func (usecase ReviewUsecase) GetForModeration(ctx context.Context, status string, paginator entities.Paginator) ([]entities.Review, error) {
+
+	if len(status) == 0 {
+		status = entities.ReviewFlagged
+	}
+
+	if status != entities.ReviewPublished && status != entities.ReviewFlagged && status != entities.ReviewHidden {
+		return nil, errors.New("status must be published, flagged or hidden")
+	}
+
+	return usecase.repo.GetByStatus(ctx, status, paginator)
+}
+
+// Moderate settles a review. Hidden reviews drop out of the merchant's
+// rating; publishing a flagged review clears the report.
+func (usecase ReviewUsecase) Moderate(ctx context.Context, id int64, status string, reason string) (bool, error) {
+
+	if status != entities.ReviewPublished && status != entities.ReviewHidden {
+		return false, errors.New("status must be published or hidden")
+	}
+
+	return usecase.repo.SetStatus(ctx, id, status, strings.TrimSpace(reason))
+}
@@ -0,0 +1,202 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/utils/password"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// CheckPermission reports whether the staff member's role allows the action.
+func CheckPermission(staff entities.Staff, permission string) error {
+
+	for _, granted := range entities.RolePermissions[staff.Role] {
+		if granted == permission {
+			return nil
+		}
+	}
+
+	return errors.New("permission denied")
+}
+
+type StaffUsecase struct {
+	repo interfaces.StaffRepository
+}
+
+func NewStaffUsecase(repo interfaces.StaffRepository) StaffUsecase {
+	usecase := StaffUsecase{
+		repo: repo,
+	}
+
+	return usecase
+}
+
+func (usecase StaffUsecase) GetAll(ctx context.Context, merchantID int64) ([]entities.Staff, error) {
+
+	return usecase.repo.GetByMerchant(ctx, merchantID)
+}
+
+func (usecase StaffUsecase) Create(ctx context.Context, staff entities.Staff) (entities.Staff, error) {
+
+	staff.Name = strings.TrimSpace(staff.Name)
+	staff.Email = strings.TrimSpace(staff.Email)
+
+	if len(staff.Name) == 0 || len(staff.Name) > 120 {
+		return entities.Staff{}, errors.New("name must be inbetween 1 - 120 characters")
+	}
+
+	if !merchantEmail.MatchString(staff.Email) {
+		return entities.Staff{}, errors.New("invalid email")
+	}
+
+	err := validateStaffRole(staff.Role)
+	if err != nil {
+		return entities.Staff{}, err
+	}
+
+	if len(staff.Password) < 8 {
+		return entities.Staff{}, errors.New("password must be at least 8 characters")
+	}
+
+	hash, err := password.Hash(staff.Password)
+	if err != nil {
+		return entities.Staff{}, err
+	}
+
+	staff.Password = hash
+
+	id, err := usecase.repo.Create(ctx, staff)
+	if err != nil {
+		return entities.Staff{}, err
+	}
+
+	return usecase.get(ctx, staff.MerchantID, id)
+}
+
+// Update changes a staff member. A new password signs them out everywhere.
+func (usecase StaffUsecase) Update(ctx context.Context, merchantID int64, id int64, update entities.StaffUpdate) (entities.Staff, error) {
+
+	staff, err := usecase.get(ctx, merchantID, id)
+	if err != nil {
+		return entities.Staff{}, err
+	}
+
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+
+		if len(name) == 0 || len(name) > 120 {
+			return entities.Staff{}, errors.New("name must be inbetween 1 - 120 characters")
+		}
+
+		staff.Name = name
+	}
+
+	if update.Role != nil {
+		err = validateStaffRole(*update.Role)
+		if err != nil {
+			return entities.Staff{}, err
+		}
+
+		staff.Role = *update.Role
+	}
+
+	if update.Password != nil && len(*update.Password) < 8 {
+		return entities.Staff{}, errors.New("password must be at least 8 characters")
+	}
+
+	_, err = usecase.repo.Update(ctx, staff)
+	if err != nil {
+		return entities.Staff{}, err
+	}
+
+	if update.Password != nil {
+		hash, err := password.Hash(*update.Password)
+		if err != nil {
+			return entities.Staff{}, err
+		}
+
+		_, err = usecase.repo.UpdatePassword(ctx, staff.ID, hash)
+		if err != nil {
+			return entities.Staff{}, err
+		}
+	}
+
+	return usecase.get(ctx, merchantID, id)
+}
+
+func (usecase StaffUsecase) Delete(ctx context.Context, merchantID int64, id int64) (bool, error) {
+
+	return usecase.repo.Delete(ctx, merchantID, id)
+}
+
+func (usecase StaffUsecase) Login(ctx context.Context, login entities.Login) (string, error) {
+
+	if !merchantEmail.MatchString(login.Email) {
+		return "", errors.New("invalid email")
+	}
+
+	if len(login.Password) == 0 {
+		return "", errors.New("password not found")
+	}
+
+	staff, err := usecase.repo.GetByEmail(ctx, login.Email)
+	if err != nil {
+		return "", err
+	}
+
+	if staff == nil {
+		return "", errors.New("invalid email or password")
+	}
+
+	ok, rehash, err := password.Verify(login.Password, staff.Password)
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		return "", errors.New("invalid email or password")
+	}
+
+	if rehash {
+		hash, err := password.Hash(login.Password)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = usecase.repo.RehashPassword(ctx, staff.ID, staff.Password, hash)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return usecase.repo.CreateToken(ctx, *staff, uuid.New().String())
+}
+
+func (usecase StaffUsecase) get(ctx context.Context, merchantID int64, id int64) (entities.Staff, error) {
+
+	staff, err := usecase.repo.GetSingle(ctx, merchantID, id)
+	if err != nil {
+		return entities.Staff{}, err
+	}
+
+	if staff == nil {
+		return entities.Staff{}, errors.New("there are no such staff member")
+	}
+
+	return *staff, nil
+}
+
+// validateStaffRole rejects the owner role, which belongs to the merchant
+// account itself.
+func validateStaffRole(role string) error {
+
+	if role != entities.RoleManager && role != entities.RoleFrontDesk {
+		return errors.New("role must be manager or front_desk")
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package usecases
+
+import (
+	"no-q-solution/domain/entities"
+	"testing"
+)
+
+func TestCheckPermission(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		role       string
+		permission string
+		wantErr    bool
+	}{
+		{name: "owner manages account", role: entities.RoleOwner, permission: entities.PermissionManageAccount},
+		{name: "owner manages staff", role: entities.RoleOwner, permission: entities.PermissionManageStaff},
+		{name: "manager manages queues", role: entities.RoleManager, permission: entities.PermissionManageQueues},
+		{name: "manager views analytics", role: entities.RoleManager, permission: entities.PermissionViewAnalytics},
+		{name: "manager cannot manage staff", role: entities.RoleManager, permission: entities.PermissionManageStaff, wantErr: true},
+		{name: "manager cannot manage account", role: entities.RoleManager, permission: entities.PermissionManageAccount, wantErr: true},
+		{name: "front desk serves customers", role: entities.RoleFrontDesk, permission: entities.PermissionServeCustomers},
+		{name: "front desk views merchant", role: entities.RoleFrontDesk, permission: entities.PermissionViewMerchant},
+		{name: "front desk cannot manage queues", role: entities.RoleFrontDesk, permission: entities.PermissionManageQueues, wantErr: true},
+		{name: "front desk cannot view analytics", role: entities.RoleFrontDesk, permission: entities.PermissionViewAnalytics, wantErr: true},
+		{name: "unknown role", role: "janitor", permission: entities.PermissionViewMerchant, wantErr: true},
+		{name: "unknown permission", role: entities.RoleOwner, permission: "launch_rockets", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckPermission(entities.Staff{Role: test.role}, test.permission)
+			if (err != nil) != test.wantErr {
+				t.Errorf("CheckPermission() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// TestRolePermissions checks that every role is a strict subset of the one
+// above it, so a promotion never takes a permission away.
+func TestRolePermissions(t *testing.T) {
+
+	ladder := []string{entities.RoleFrontDesk, entities.RoleManager, entities.RoleOwner}
+
+	for i := 1; i < len(ladder); i++ {
+		lower, higher := ladder[i-1], ladder[i]
+
+		granted := make(map[string]bool)
+		for _, permission := range entities.RolePermissions[higher] {
+			granted[permission] = true
+		}
+
+		for _, permission := range entities.RolePermissions[lower] {
+			if !granted[permission] {
+				t.Errorf("%s may %s but %s may not", lower, permission, higher)
+			}
+		}
+
+		if len(entities.RolePermissions[lower]) >= len(entities.RolePermissions[higher]) {
+			t.Errorf("%s has as many permissions as %s", lower, higher)
+		}
+	}
+}
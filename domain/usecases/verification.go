@@ -0,0 +1,30 @@
+package usecases
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// newVerificationToken returns a random token for an emailed link together
+// with the hash that is stored in its place.
+func newVerificationToken() (string, string, error) {
+
+	bytes := make([]byte, 32)
+
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", "", err
+	}
+
+	token := hex.EncodeToString(bytes)
+
+	return token, hashVerificationToken(token), nil
+}
+
+func hashVerificationToken(token string) string {
+
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,34 @@
+package adapters
+
+import (
+	"crypto/subtle"
+	"errors"
+	"no-q-solution/domain/interfaces"
+)
+
+// AdminGuard authorizes platform administrators against the key in the app
+// configuration. Admin endpoints stay closed while no key is configured.
+type AdminGuard struct {
+	key []byte
+}
+
+func NewAdminGuard(key string) interfaces.AdminGuard {
+	guard := AdminGuard{
+		key: []byte(key),
+	}
+
+	return guard
+}
+
+func (guard AdminGuard) Validate(token string) error {
+
+	if len(guard.key) == 0 {
+		return errors.New("admin access is not configured")
+	}
+
+	if subtle.ConstantTimeCompare(guard.key, []byte(token)) != 1 {
+		return errors.New("token is not valid")
+	}
+
+	return nil
+}
@@ -0,0 +1,300 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/utils/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func NewBlobStore(conf config.Storage) (interfaces.BlobStore, error) {
+
+	switch conf.Driver {
+	case "", "local":
+		return NewLocalBlobStore(conf.Dir, conf.BaseURL)
+	case "s3":
+		return NewS3BlobStore(conf.S3)
+	}
+
+	return nil, fmt.Errorf("unknown storage driver %q", conf.Driver)
+}
+
+// LocalBlobStore keeps blobs in a directory and serves them itself, for
+// development and single server installs.
+type LocalBlobStore struct {
+	dir     string
+	baseURL string
+	files   http.Handler
+}
+
+func NewLocalBlobStore(dir string, baseURL string) (interfaces.BlobStore, error) {
+
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	store := LocalBlobStore{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		files:   http.FileServer(http.Dir(dir)),
+	}
+
+	return store, nil
+}
+
+func (store LocalBlobStore) Put(ctx context.Context, key string, contentType string, data []byte) error {
+
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	// write then rename so readers never see a half written file
+	tmp := path + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func (store LocalBlobStore) Delete(ctx context.Context, key string) error {
+
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (store LocalBlobStore) URL(key string) string {
+
+	return store.baseURL + "/" + key
+}
+
+// ServeHTTP serves stored files under the path of the base URL. Directory
+// listings are refused.
+func (store LocalBlobStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if strings.HasSuffix(r.URL.Path, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	store.files.ServeHTTP(w, r)
+}
+
+// Prefix is the request path the stored files are served under.
+func (store LocalBlobStore) Prefix() string {
+
+	base, err := url.Parse(store.baseURL)
+	if err != nil || len(base.Path) == 0 {
+		return "/media"
+	}
+
+	return strings.TrimRight(base.Path, "/")
+}
+
+func (store LocalBlobStore) path(key string) (string, error) {
+
+	clean := filepath.Clean("/" + key)
+
+	if clean == "/" || clean != "/"+key {
+		return "", errors.New("invalid blob key")
+	}
+
+	return filepath.Join(store.dir, filepath.FromSlash(clean)), nil
+}
+
+// S3BlobStore stores blobs in an S3 compatible bucket, signing requests with
+// AWS signature version 4.
+type S3BlobStore struct {
+	conf   config.S3
+	client *http.Client
+}
+
+func NewS3BlobStore(conf config.S3) (interfaces.BlobStore, error) {
+
+	if len(conf.Endpoint) == 0 || len(conf.Bucket) == 0 {
+		return nil, errors.New("s3 storage needs an endpoint and a bucket")
+	}
+
+	if len(conf.Region) == 0 {
+		conf.Region = "us-east-1"
+	}
+
+	conf.Endpoint = strings.TrimRight(conf.Endpoint, "/")
+	conf.PublicURL = strings.TrimRight(conf.PublicURL, "/")
+
+	store := S3BlobStore{
+		conf:   conf,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	return store, nil
+}
+
+func (store S3BlobStore) Put(ctx context.Context, key string, contentType string, data []byte) error {
+
+	return store.do(ctx, http.MethodPut, key, contentType, data)
+}
+
+func (store S3BlobStore) Delete(ctx context.Context, key string) error {
+
+	return store.do(ctx, http.MethodDelete, key, "", nil)
+}
+
+func (store S3BlobStore) URL(key string) string {
+
+	if len(store.conf.PublicURL) != 0 {
+		return store.conf.PublicURL + "/" + key
+	}
+
+	return store.objectURL(key).String()
+}
+
+func (store S3BlobStore) objectURL(key string) *url.URL {
+
+	endpoint, _ := url.Parse(store.conf.Endpoint)
+
+	object := *endpoint
+
+	if store.conf.PathStyle {
+		object.Path = "/" + store.conf.Bucket + "/" + key
+	} else {
+		object.Host = store.conf.Bucket + "." + endpoint.Host
+		object.Path = "/" + key
+	}
+
+	return &object
+}
+
+func (store S3BlobStore) do(ctx context.Context, method string, key string, contentType string, data []byte) error {
+
+	object := store.objectURL(key)
+
+	req, err := http.NewRequestWithContext(ctx, method, object.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if len(contentType) != 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	store.sign(req, data, time.Now().UTC())
+
+	resp, err := store.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && !(method == http.MethodDelete && resp.StatusCode == http.StatusNotFound) {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return fmt.Errorf("s3 %s %s failed: %s %s", method, key, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// sign adds an AWS signature version 4 Authorization header.
+func (store S3BlobStore) sign(req *http.Request, payload []byte, now time.Time) {
+
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	payloadHash := sha256.Sum256(payload)
+	hashed := hex.EncodeToString(payloadHash[:])
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hashed)
+
+	names := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if len(req.Header.Get("Content-Type")) != 0 {
+		names = []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+	}
+
+	headers := ""
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+
+		headers += name + ":" + strings.TrimSpace(value) + "\n"
+	}
+
+	signed := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		headers,
+		signed,
+		hashed,
+	}, "\n")
+
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	scope := day + "/" + store.conf.Region + "/s3/aws4_request"
+
+	toSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+store.conf.SecretKey), day)
+	key = hmacSHA256(key, store.conf.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		store.conf.AccessKey,
+		scope,
+		signed,
+		signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,94 @@
+package adapters
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"strings"
+	"time"
+)
+
+// signatureSize keeps the code short enough for a low density QR image.
+const signatureSize = 16
+
+// placeholderSecret was shipped in configurations/app.yaml by earlier
+// releases. Codes signed with it could be forged by anyone reading the
+// repository, so configurations still carrying it are refused.
+const placeholderSecret = "change-me"
+
+type CheckInSigner struct {
+	secret []byte
+}
+
+func NewCheckInSigner(secret string) (interfaces.CheckInSigner, error) {
+
+	if len(secret) == 0 {
+		return nil, errors.New("check-in secret not configured, set CHECK_IN_SECRET or check-in-secret in configurations/app.yaml")
+	}
+
+	if secret == placeholderSecret {
+		return nil, errors.New("check-in secret is still the placeholder, set check-in-secret in configurations/app.yaml")
+	}
+
+	signer := CheckInSigner{
+		secret: []byte(secret),
+	}
+
+	return signer, nil
+}
+
+func (signer CheckInSigner) Sign(checkIn entities.CheckIn) string {
+
+	payload := fmt.Sprintf("%d:%d:%d", checkIn.TokenNo, checkIn.QueueID, checkIn.StartTime.Unix())
+
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signer.signature(encoded))
+}
+
+func (signer CheckInSigner) Verify(code string) (entities.CheckIn, error) {
+
+	encoded, sig, ok := strings.Cut(code, ".")
+	if !ok {
+		return entities.CheckIn{}, errors.New("invalid check-in code")
+	}
+
+	givenSig, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return entities.CheckIn{}, errors.New("invalid check-in code")
+	}
+
+	if !hmac.Equal(givenSig, signer.signature(encoded)) {
+		return entities.CheckIn{}, errors.New("check-in code signature mismatch")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return entities.CheckIn{}, errors.New("invalid check-in code")
+	}
+
+	checkIn := entities.CheckIn{}
+
+	var startTime int64
+
+	_, err = fmt.Sscanf(string(payload), "%d:%d:%d", &checkIn.TokenNo, &checkIn.QueueID, &startTime)
+	if err != nil {
+		return entities.CheckIn{}, errors.New("invalid check-in code")
+	}
+
+	checkIn.StartTime = time.Unix(startTime, 0).UTC()
+
+	return checkIn, nil
+}
+
+func (signer CheckInSigner) signature(payload string) []byte {
+
+	mac := hmac.New(sha256.New, signer.secret)
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)[:signatureSize]
+}
@@ -0,0 +1,88 @@
+package adapters
+
+import (
+	"no-q-solution/domain/entities"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewCheckInSigner(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "configured", secret: "a-long-random-secret"},
+		{name: "empty", secret: "", wantErr: true},
+		{name: "placeholder", secret: "change-me", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewCheckInSigner(test.secret)
+			if (err != nil) != test.wantErr {
+				t.Errorf("NewCheckInSigner() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckInSigner(t *testing.T) {
+
+	signer, err := NewCheckInSigner("a-long-random-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewCheckInSigner("another-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkIn := entities.CheckIn{
+		TokenNo:   42,
+		QueueID:   7,
+		StartTime: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+	}
+
+	code := signer.Sign(checkIn)
+	encoded, sig, _ := strings.Cut(code, ".")
+
+	forged := other.Sign(entities.CheckIn{TokenNo: 43, QueueID: 7, StartTime: checkIn.StartTime})
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	tests := []struct {
+		name    string
+		signer  CheckInSigner
+		code    string
+		wantErr bool
+	}{
+		{name: "valid", code: code},
+		{name: "other secret", signer: other.(CheckInSigner), code: code, wantErr: true},
+		{name: "payload swapped", code: forgedPayload + "." + sig, wantErr: true},
+		{name: "signature tampered", code: encoded + "." + strings.Repeat("A", len(sig)), wantErr: true},
+		{name: "missing signature", code: encoded, wantErr: true},
+		{name: "not base64", code: encoded + ".***", wantErr: true},
+		{name: "empty", code: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier := test.signer
+			if verifier.secret == nil {
+				verifier = signer.(CheckInSigner)
+			}
+
+			got, err := verifier.Verify(test.code)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if !test.wantErr && (got.TokenNo != checkIn.TokenNo || got.QueueID != checkIn.QueueID || !got.StartTime.Equal(checkIn.StartTime)) {
+				t.Errorf("Verify() = %+v, want %+v", got, checkIn)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package adapters
+
+import (
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"sync"
+)
+
+// subscriberBuffer is the number of events a subscriber may lag behind
+// before it is dropped and has to reconnect.
+const subscriberBuffer = 32
+
+type subscriber struct {
+	events chan entities.QueueEvent
+	closed bool
+}
+
+type EventHub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[*subscriber]struct{}
+	merchants   map[int64]map[*subscriber]struct{}
+}
+
+func NewEventHub() interfaces.EventHub {
+	hub := &EventHub{
+		subscribers: make(map[int64]map[*subscriber]struct{}),
+		merchants:   make(map[int64]map[*subscriber]struct{}),
+	}
+
+	return hub
+}
+
+func (hub *EventHub) Publish(event entities.QueueEvent) {
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for sub := range hub.subscribers[event.QueueID] {
+		select {
+		case sub.events <- event:
+		default:
+			log.Printf("dropping slow subscriber of queue %d", event.QueueID)
+
+			hub.remove(sub)
+		}
+	}
+}
+
+func (hub *EventHub) Subscribe(queueIDs []int64) (<-chan entities.QueueEvent, func()) {
+
+	sub := &subscriber{
+		events: make(chan entities.QueueEvent, subscriberBuffer),
+	}
+
+	hub.mu.Lock()
+
+	for _, queueID := range queueIDs {
+		hub.attach(hub.subscribers, queueID, sub)
+	}
+
+	hub.mu.Unlock()
+
+	return sub.events, hub.unsubscribe(sub)
+}
+
+func (hub *EventHub) SubscribeMerchant(merchantID int64) (<-chan entities.QueueEvent, func()) {
+
+	sub := &subscriber{
+		events: make(chan entities.QueueEvent, subscriberBuffer),
+	}
+
+	hub.mu.Lock()
+
+	hub.attach(hub.merchants, merchantID, sub)
+
+	hub.mu.Unlock()
+
+	return sub.events, hub.unsubscribe(sub)
+}
+
+// AddQueue subscribes the merchant's subscribers to one of its queues, so
+// streams opened before the queue was created or restored follow it too.
+func (hub *EventHub) AddQueue(merchantID int64, queueID int64) {
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for sub := range hub.merchants[merchantID] {
+		hub.attach(hub.subscribers, queueID, sub)
+	}
+}
+
+// attach adds the subscriber under the key. The caller must hold the lock.
+func (hub *EventHub) attach(subscribers map[int64]map[*subscriber]struct{}, key int64, sub *subscriber) {
+
+	if subscribers[key] == nil {
+		subscribers[key] = make(map[*subscriber]struct{})
+	}
+
+	subscribers[key][sub] = struct{}{}
+}
+
+func (hub *EventHub) unsubscribe(sub *subscriber) func() {
+
+	return func() {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+
+		hub.remove(sub)
+	}
+}
+
+// remove detaches the subscriber from every queue and merchant and closes
+// its channel. The caller must hold the lock.
+func (hub *EventHub) remove(sub *subscriber) {
+
+	if sub.closed {
+		return
+	}
+
+	for _, subscribers := range []map[int64]map[*subscriber]struct{}{hub.subscribers, hub.merchants} {
+		for key, subs := range subscribers {
+			delete(subs, sub)
+
+			if len(subs) == 0 {
+				delete(subscribers, key)
+			}
+		}
+	}
+
+	sub.closed = true
+
+	close(sub.events)
+}
@@ -0,0 +1,121 @@
+package adapters
+
+import (
+	"no-q-solution/domain/entities"
+	"testing"
+)
+
+// drain reads the buffered events and reports whether the channel was closed.
+func drain(events <-chan entities.QueueEvent) (int, bool) {
+
+	count := 0
+
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return count, true
+			}
+
+			count++
+		default:
+			return count, false
+		}
+	}
+}
+
+func TestEventHubDropsSlowSubscribers(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		published  int
+		wantCount  int
+		wantClosed bool
+	}{
+		{name: "within buffer", published: subscriberBuffer, wantCount: subscriberBuffer},
+		{name: "one past buffer", published: subscriberBuffer + 1, wantCount: subscriberBuffer, wantClosed: true},
+		{name: "far past buffer", published: subscriberBuffer * 3, wantCount: subscriberBuffer, wantClosed: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := NewEventHub()
+
+			slow, unsubscribe := hub.Subscribe([]int64{1})
+			defer unsubscribe()
+
+			for i := 0; i < test.published; i++ {
+				hub.Publish(entities.QueueEvent{QueueID: 1, TokenNo: int64(i)})
+			}
+
+			count, closed := drain(slow)
+			if count != test.wantCount || closed != test.wantClosed {
+				t.Errorf("got %d events, closed %v; want %d, closed %v", count, closed, test.wantCount, test.wantClosed)
+			}
+		})
+	}
+}
+
+func TestEventHubKeepsOtherSubscribers(t *testing.T) {
+
+	hub := NewEventHub()
+
+	slow, unsubscribeSlow := hub.Subscribe([]int64{1})
+	defer unsubscribeSlow()
+
+	fast, unsubscribeFast := hub.Subscribe([]int64{1})
+	defer unsubscribeFast()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		hub.Publish(entities.QueueEvent{QueueID: 1})
+
+		<-fast
+	}
+
+	if _, closed := drain(slow); !closed {
+		t.Error("slow subscriber was not dropped")
+	}
+
+	hub.Publish(entities.QueueEvent{QueueID: 1})
+
+	if count, closed := drain(fast); count != 1 || closed {
+		t.Errorf("fast subscriber got %d events, closed %v; want 1, open", count, closed)
+	}
+
+	// unsubscribing after a drop must not close the channel twice
+	unsubscribeSlow()
+}
+
+func TestEventHubMerchantSubscription(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		merchant  int64
+		queue     int64
+		addQueue  bool
+		wantCount int
+	}{
+		{name: "added queue", merchant: 1, queue: 10, addQueue: true, wantCount: 1},
+		{name: "queue not added", merchant: 1, queue: 10},
+		{name: "other merchant's queue", merchant: 2, queue: 10, addQueue: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := NewEventHub()
+
+			events, unsubscribe := hub.SubscribeMerchant(1)
+			defer unsubscribe()
+
+			if test.addQueue {
+				hub.AddQueue(test.merchant, test.queue)
+			}
+
+			hub.Publish(entities.QueueEvent{QueueID: test.queue})
+
+			if count, _ := drain(events); count != test.wantCount {
+				t.Errorf("got %d events, want %d", count, test.wantCount)
+			}
+		})
+	}
+}
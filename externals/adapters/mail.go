@@ -0,0 +1,195 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"net/url"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/utils/config"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+func NewMailer(conf config.Mail) (interfaces.Mailer, error) {
+
+	base := strings.TrimRight(conf.BaseURL, "/")
+
+	if len(conf.ResetPasswordURL) == 0 {
+		return nil, fmt.Errorf("reset password url not configured")
+	}
+
+	switch conf.Driver {
+	case "", "log":
+		return LogMailer{baseURL: base, resetPasswordURL: conf.ResetPasswordURL}, nil
+	case "file":
+		return NewFileMailer(conf.Dir, conf.From, base, conf.ResetPasswordURL)
+	case "smtp":
+		return NewSMTPMailer(conf.SMTP, conf.From, base, conf.ResetPasswordURL)
+	}
+
+	return nil, fmt.Errorf("unknown mail driver %q", conf.Driver)
+}
+
+// LogMailer writes mails to the service log instead of sending them.
+type LogMailer struct {
+	baseURL          string
+	resetPasswordURL string
+}
+
+func (mailer LogMailer) Send(ctx context.Context, mail entities.Mail) error {
+
+	log.Printf("mail to %s: %s\n%s", mail.To, mail.Subject, mail.Body)
+
+	return nil
+}
+
+func (mailer LogMailer) URL(path string) string {
+
+	return mailer.baseURL + path
+}
+
+func (mailer LogMailer) ResetPasswordURL(token string) string {
+
+	return withToken(mailer.resetPasswordURL, token)
+}
+
+// FileMailer drops every mail as a separate .eml file into a directory so it
+// can be opened with a mail client during development.
+type FileMailer struct {
+	dir              string
+	from             string
+	baseURL          string
+	resetPasswordURL string
+	seq              *uint64
+}
+
+func NewFileMailer(dir string, from string, baseURL string, resetPasswordURL string) (interfaces.Mailer, error) {
+
+	if len(dir) == 0 {
+		return nil, fmt.Errorf("mail directory not configured")
+	}
+
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	mailer := FileMailer{
+		dir:              dir,
+		from:             from,
+		baseURL:          baseURL,
+		resetPasswordURL: resetPasswordURL,
+		seq:              new(uint64),
+	}
+
+	return mailer, nil
+}
+
+func (mailer FileMailer) Send(ctx context.Context, mail entities.Mail) error {
+
+	now := time.Now()
+
+	name := fmt.Sprintf("%s-%d.eml", now.Format("20060102T150405"), atomic.AddUint64(mailer.seq, 1))
+
+	return os.WriteFile(filepath.Join(mailer.dir, name), message(mailer.from, mail, now), 0o600)
+}
+
+func (mailer FileMailer) URL(path string) string {
+
+	return mailer.baseURL + path
+}
+
+func (mailer FileMailer) ResetPasswordURL(token string) string {
+
+	return withToken(mailer.resetPasswordURL, token)
+}
+
+// SMTPMailer delivers mails through an SMTP relay. The connection is
+// upgraded with STARTTLS whenever the server offers it.
+type SMTPMailer struct {
+	addr             string
+	auth             smtp.Auth
+	from             string
+	sender           string
+	baseURL          string
+	resetPasswordURL string
+}
+
+func NewSMTPMailer(conf config.SMTP, from string, baseURL string, resetPasswordURL string) (interfaces.Mailer, error) {
+
+	if len(conf.Host) == 0 || conf.Port == 0 {
+		return nil, fmt.Errorf("smtp host not configured")
+	}
+
+	address, err := netmail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mail sender: %w", err)
+	}
+
+	mailer := SMTPMailer{
+		addr:             net.JoinHostPort(conf.Host, strconv.Itoa(conf.Port)),
+		from:             from,
+		sender:           address.Address,
+		baseURL:          baseURL,
+		resetPasswordURL: resetPasswordURL,
+	}
+
+	if len(conf.Username) != 0 {
+		mailer.auth = smtp.PlainAuth("", conf.Username, conf.Password, conf.Host)
+	}
+
+	return mailer, nil
+}
+
+func (mailer SMTPMailer) Send(ctx context.Context, mail entities.Mail) error {
+
+	return smtp.SendMail(mailer.addr, mailer.auth, mailer.sender, []string{mail.To}, message(mailer.from, mail, time.Now()))
+}
+
+func (mailer SMTPMailer) URL(path string) string {
+
+	return mailer.baseURL + path
+}
+
+func (mailer SMTPMailer) ResetPasswordURL(token string) string {
+
+	return withToken(mailer.resetPasswordURL, token)
+}
+
+// withToken appends the token as a query parameter, keeping any query the
+// page URL already carries.
+func withToken(page string, token string) string {
+
+	separator := "?"
+	if strings.Contains(page, "?") {
+		separator = "&"
+	}
+
+	return page + separator + "token=" + url.QueryEscape(token)
+}
+
+// message renders a plain text RFC 5322 message.
+func message(from string, mail entities.Mail, date time.Time) []byte {
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mail.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
@@ -0,0 +1,78 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/utils/config"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+func NewSMSSender(conf config.SMS) (interfaces.SMSSender, error) {
+
+	switch conf.Driver {
+	case "", "log":
+		return LogSMSSender{}, nil
+	case "file":
+		return NewFileSMSSender(conf.File)
+	}
+
+	return nil, fmt.Errorf("unknown sms driver %q", conf.Driver)
+}
+
+// LogSMSSender writes messages to the service log instead of sending them.
+type LogSMSSender struct{}
+
+func (sender LogSMSSender) Send(ctx context.Context, phone string, message string) error {
+
+	log.Printf("sms to %s: %s", phone, message)
+
+	return nil
+}
+
+// FileSMSSender appends messages to a local file so they can be read back
+// during development.
+type FileSMSSender struct {
+	mu   *sync.Mutex
+	path string
+}
+
+func NewFileSMSSender(path string) (interfaces.SMSSender, error) {
+
+	if len(path) == 0 {
+		return nil, fmt.Errorf("sms file not configured")
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	sender := FileSMSSender{
+		mu:   &sync.Mutex{},
+		path: path,
+	}
+
+	return sender, nil
+}
+
+func (sender FileSMSSender) Send(ctx context.Context, phone string, message string) error {
+
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	file, err := os.OpenFile(sender.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), phone, message)
+
+	return err
+}
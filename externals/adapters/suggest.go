@@ -0,0 +1,224 @@
+package adapters
+
+import (
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/utils/search"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// suggestTerm is one searchable key. Every merchant name is indexed from
+// each of its words, so "den" finds "City Dentist".
+type suggestTerm struct {
+	key        string
+	merchantID int64
+	category   string
+	whole      bool
+}
+
+// SuggestIndex answers autocomplete queries from memory. Writes only mark
+// the sorted terms stale; they are rebuilt on the next lookup.
+type SuggestIndex struct {
+	mu         sync.RWMutex
+	merchants  map[int64]entities.Merchant
+	categories map[string]bool
+	terms      []suggestTerm
+	stale      bool
+}
+
+func NewSuggestIndex() interfaces.SuggestIndex {
+	index := &SuggestIndex{
+		merchants:  make(map[int64]entities.Merchant),
+		categories: make(map[string]bool),
+	}
+
+	return index
+}
+
+// Load replaces the whole index.
+func (index *SuggestIndex) Load(merchants []entities.Merchant, categories []entities.Category) {
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	index.merchants = make(map[int64]entities.Merchant, len(merchants))
+	index.categories = make(map[string]bool, len(categories))
+
+	for _, merchant := range merchants {
+		index.merchants[merchant.ID] = merchant
+	}
+
+	for _, category := range categories {
+		index.categories[category.Name] = true
+	}
+
+	index.stale = true
+}
+
+func (index *SuggestIndex) PutMerchant(merchant entities.Merchant) {
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	index.merchants[merchant.ID] = merchant
+	index.categories[merchant.Category] = true
+	index.stale = true
+}
+
+func (index *SuggestIndex) RemoveMerchant(id int64) {
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	delete(index.merchants, id)
+	index.stale = true
+}
+
+// Suggest returns categories and merchants with a word starting with the
+// prefix. Categories come first, then merchants whose name starts with the
+// prefix, then the rest, each by rating.
+func (index *SuggestIndex) Suggest(prefix string, limit int) []entities.Suggestion {
+
+	prefix = search.Normalize(prefix)
+
+	suggestions := make([]entities.Suggestion, 0)
+
+	if len(prefix) == 0 || limit <= 0 {
+		return suggestions
+	}
+
+	terms := index.sorted()
+
+	start := sort.Search(len(terms), func(i int) bool {
+		return terms[i].key >= prefix
+	})
+
+	categories := make([]string, 0)
+	merchants := make([]entities.Merchant, 0)
+	whole := make(map[int64]bool)
+	listed := make(map[int64]bool)
+	seen := make(map[string]bool)
+
+	index.mu.RLock()
+
+	for i := start; i < len(terms) && strings.HasPrefix(terms[i].key, prefix); i++ {
+		term := terms[i]
+
+		if term.merchantID == 0 {
+			if !seen[term.category] {
+				seen[term.category] = true
+				categories = append(categories, term.category)
+			}
+
+			continue
+		}
+
+		merchant, ok := index.merchants[term.merchantID]
+		if !ok {
+			continue
+		}
+
+		if term.whole {
+			whole[merchant.ID] = true
+		}
+
+		if !listed[merchant.ID] {
+			listed[merchant.ID] = true
+			merchants = append(merchants, merchant)
+		}
+	}
+
+	index.mu.RUnlock()
+
+	sort.Strings(categories)
+
+	sort.SliceStable(merchants, func(i, j int) bool {
+		if whole[merchants[i].ID] != whole[merchants[j].ID] {
+			return whole[merchants[i].ID]
+		}
+
+		if merchants[i].Rating != merchants[j].Rating {
+			return merchants[i].Rating > merchants[j].Rating
+		}
+
+		return merchants[i].Name < merchants[j].Name
+	})
+
+	for _, category := range categories {
+		if len(suggestions) == limit {
+			return suggestions
+		}
+
+		suggestions = append(suggestions, entities.Suggestion{
+			Type:     entities.SuggestionCategory,
+			Text:     category,
+			Category: category,
+		})
+	}
+
+	for _, merchant := range merchants {
+		if len(suggestions) == limit {
+			return suggestions
+		}
+
+		suggestions = append(suggestions, entities.Suggestion{
+			Type:       entities.SuggestionMerchant,
+			Text:       merchant.Name,
+			MerchantID: merchant.ID,
+			Category:   merchant.Category,
+		})
+	}
+
+	return suggestions
+}
+
+// sorted returns the terms, rebuilding them first when the index changed.
+func (index *SuggestIndex) sorted() []suggestTerm {
+
+	index.mu.RLock()
+
+	if !index.stale {
+		terms := index.terms
+		index.mu.RUnlock()
+
+		return terms
+	}
+
+	index.mu.RUnlock()
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	if !index.stale {
+		return index.terms
+	}
+
+	terms := make([]suggestTerm, 0, len(index.merchants)*2+len(index.categories))
+
+	for category := range index.categories {
+		terms = append(terms, suggestTerm{key: search.Normalize(category), category: category, whole: true})
+	}
+
+	for _, merchant := range index.merchants {
+		words := strings.Fields(search.Normalize(merchant.Name))
+
+		for i := range words {
+			terms = append(terms, suggestTerm{
+				key:        strings.Join(words[i:], " "),
+				merchantID: merchant.ID,
+				whole:      i == 0,
+			})
+		}
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		return terms[i].key < terms[j].key
+	})
+
+	index.terms = terms
+	index.stale = false
+
+	return terms
+}
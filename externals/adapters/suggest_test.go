@@ -0,0 +1,105 @@
+package adapters
+
+import (
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"reflect"
+	"testing"
+)
+
+func category(name string) entities.Suggestion {
+	return entities.Suggestion{Type: entities.SuggestionCategory, Text: name, Category: name}
+}
+
+func merchant(merchant entities.Merchant) entities.Suggestion {
+	return entities.Suggestion{Type: entities.SuggestionMerchant, Text: merchant.Name, MerchantID: merchant.ID, Category: merchant.Category}
+}
+
+func TestSuggestIndex(t *testing.T) {
+
+	city := entities.Merchant{ID: 1, Name: "City Dentist", Category: "Health", Rating: 4.5}
+	dental := entities.Merchant{ID: 2, Name: "Dental Care", Category: "Health", Rating: 3}
+	dentro := entities.Merchant{ID: 3, Name: "Dentro Café", Category: "Food", Rating: 4.8}
+	clinic := entities.Merchant{ID: 4, Name: "Denver Clinic", Category: "Dental Clinics", Rating: 5}
+
+	tests := []struct {
+		name   string
+		change func(index interfaces.SuggestIndex)
+		prefix string
+		limit  int
+		want   []entities.Suggestion
+	}{
+		{
+			name:   "categories, then name prefixes, then other words",
+			prefix: "Den",
+			limit:  10,
+			want:   []entities.Suggestion{category("Dentist"), merchant(dentro), merchant(dental), merchant(city)},
+		},
+		{
+			name:   "limit",
+			prefix: "den",
+			limit:  2,
+			want:   []entities.Suggestion{category("Dentist"), merchant(dentro)},
+		},
+		{
+			name:   "several words",
+			prefix: "city  d",
+			limit:  10,
+			want:   []entities.Suggestion{merchant(city)},
+		},
+		{
+			name:   "accented letters",
+			prefix: "café",
+			limit:  10,
+			want:   []entities.Suggestion{merchant(dentro)},
+		},
+		{
+			name:   "removed merchant",
+			change: func(index interfaces.SuggestIndex) { index.RemoveMerchant(3) },
+			prefix: "den",
+			limit:  10,
+			want:   []entities.Suggestion{category("Dentist"), merchant(dental), merchant(city)},
+		},
+		{
+			name:   "put merchant adds its category",
+			change: func(index interfaces.SuggestIndex) { index.PutMerchant(clinic) },
+			prefix: "den",
+			limit:  10,
+			want:   []entities.Suggestion{category("Dental Clinics"), category("Dentist"), merchant(clinic), merchant(dentro), merchant(dental), merchant(city)},
+		},
+		{
+			name: "renamed merchant",
+			change: func(index interfaces.SuggestIndex) {
+				index.PutMerchant(entities.Merchant{ID: 1, Name: "Smile Studio", Category: "Health"})
+			},
+			prefix: "den",
+			limit:  10,
+			want:   []entities.Suggestion{category("Dentist"), merchant(dentro), merchant(dental)},
+		},
+		{name: "no match", prefix: "xyz", limit: 10, want: []entities.Suggestion{}},
+		{name: "blank prefix", prefix: " - ", limit: 10, want: []entities.Suggestion{}},
+		{name: "zero limit", prefix: "den", limit: 0, want: []entities.Suggestion{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			index := NewSuggestIndex()
+
+			index.Load(
+				[]entities.Merchant{city, dental, dentro},
+				[]entities.Category{{Name: "Health"}, {Name: "Food"}, {Name: "Dentist"}},
+			)
+
+			if test.change != nil {
+				// build the terms once so the change has to mark them stale
+				index.Suggest("den", 1)
+				test.change(index)
+			}
+
+			got := index.Suggest(test.prefix, test.limit)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Suggest() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,504 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"sort"
+	"time"
+)
+
+type AnalyticsRepository struct {
+	db *sql.DB
+}
+
+func NewAnalyticsRepository(db *sql.DB) interfaces.AnalyticsRepository {
+	repo := &AnalyticsRepository{
+		db: db,
+	}
+
+	return repo
+}
+
+// analyticsQueue is what Compute needs of a queue definition. Slots is the
+// number of intervals its daily hours fit.
+type analyticsQueue struct {
+	id         int64
+	merchantID int64
+	available  bool
+	createdOn  time.Time
+	slots      int
+}
+
+func analyticsDay(day time.Time) string {
+
+	return day.Format("2006-01-02")
+}
+
+// Compute works the daily and hourly figures out from reserved_slots,
+// cancelled_slots and the queue definitions. Offered slots follow the
+// queue's current hours and availability, minus the days it was closed and
+// the days before it was created.
+func (repo AnalyticsRepository) Compute(ctx context.Context, query entities.AnalyticsQuery) ([]entities.QueueDay, []entities.QueueHour, error) {
+
+	days := make([]entities.QueueDay, 0)
+	hours := make([]entities.QueueHour, 0)
+
+	queues, err := repo.getQueues(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(queues) == 0 {
+		return days, hours, nil
+	}
+
+	ids := make([]int64, 0, len(queues))
+	merchants := make(map[int64]int64, len(queues))
+
+	for _, queue := range queues {
+		ids = append(ids, queue.id)
+		merchants[queue.id] = queue.merchantID
+	}
+
+	from := query.From
+	to := query.To.AddDate(0, 0, 1)
+
+	closed, err := repo.getClosedDays(ctx, ids, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type queueDay struct {
+		queueID int64
+		day     string
+	}
+
+	index := make(map[queueDay]int)
+
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, queue := range queues {
+			stats := entities.QueueDay{
+				QueueID:    queue.id,
+				MerchantID: queue.merchantID,
+				Day:        day,
+			}
+
+			if queue.available && !queue.createdOn.After(day) && !closed[queue.id][analyticsDay(day)] {
+				stats.Offered = queue.slots
+			}
+
+			index[queueDay{queue.id, analyticsDay(day)}] = len(days)
+			days = append(days, stats)
+		}
+	}
+
+	args := append(int64Args(ids), from, to)
+
+	rows, err := repo.db.QueryContext(
+		ctx,
+		`SELECT rs.queue_id, DATE(rs.start_time), HOUR(rs.start_time), COUNT(*),
+			SUM(rs.arrived_at IS NOT NULL), SUM(rs.no_show_at IS NOT NULL),
+			SUM(GREATEST(TIMESTAMPDIFF(MINUTE, rs.created_at, rs.start_time), 0))
+		FROM reserved_slots rs
+		WHERE rs.queue_id IN (`+placeholders(len(ids))+`) AND rs.start_time >= ? AND rs.start_time < ?
+		GROUP BY rs.queue_id, DATE(rs.start_time), HOUR(rs.start_time);`,
+		args...,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		hour := entities.QueueHour{}
+
+		var checkedIn, noShows int
+		var lead int64
+
+		err := rows.Scan(&hour.QueueID, &hour.Day, &hour.Hour, &hour.Bookings, &checkedIn, &noShows, &lead)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		i, ok := index[queueDay{hour.QueueID, analyticsDay(hour.Day)}]
+		if !ok {
+			continue
+		}
+
+		days[i].Booked += hour.Bookings
+		days[i].CheckedIn += checkedIn
+		days[i].NoShows += noShows
+		days[i].LeadMinutes += lead
+
+		hour.MerchantID = merchants[hour.QueueID]
+		hour.Weekday = hour.Day.Weekday()
+
+		hours = append(hours, hour)
+	}
+
+	rows, err = repo.db.QueryContext(
+		ctx,
+		`SELECT queue_id, DATE(start_time), COUNT(*)
+		FROM cancelled_slots
+		WHERE queue_id IN (`+placeholders(len(ids))+`) AND start_time >= ? AND start_time < ?
+		GROUP BY queue_id, DATE(start_time);`,
+		args...,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var queueID int64
+		var day time.Time
+		var cancelled int
+
+		err := rows.Scan(&queueID, &day, &cancelled)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		i, ok := index[queueDay{queueID, analyticsDay(day)}]
+		if ok {
+			days[i].Cancelled += cancelled
+		}
+	}
+
+	sort.Slice(hours, func(i, j int) bool {
+		if !hours[i].Day.Equal(hours[j].Day) {
+			return hours[i].Day.Before(hours[j].Day)
+		}
+
+		if hours[i].QueueID != hours[j].QueueID {
+			return hours[i].QueueID < hours[j].QueueID
+		}
+
+		return hours[i].Hour < hours[j].Hour
+	})
+
+	return days, hours, nil
+}
+
+func (repo AnalyticsRepository) getQueues(ctx context.Context, query entities.AnalyticsQuery) ([]analyticsQueue, error) {
+
+	stmt, err := repo.db.PrepareContext(ctx, `
+		SELECT q.id, q.merchant_id, q.is_available, DATE(q.created_at),
+			COALESCE(FLOOR(GREATEST(TIME_TO_SEC(TIMEDIFF(TIME(q.end_time), TIME(q.start_time))), 0) / 60 / q.intervals), 0)
+		FROM queue q INNER JOIN merchant m on q.merchant_id = m.id
+		WHERE q.deleted_at IS NULL AND m.deleted_at IS NULL
+			AND (? = 0 OR q.merchant_id = ?) AND (? = 0 OR q.id = ?)
+		ORDER BY q.id ASC;`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, query.MerchantID, query.MerchantID, query.QueueID, query.QueueID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	queues := make([]analyticsQueue, 0)
+
+	for rows.Next() {
+		queue := analyticsQueue{}
+
+		err := rows.Scan(&queue.id, &queue.merchantID, &queue.available, &queue.createdOn, &queue.slots)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		queues = append(queues, queue)
+	}
+
+	return queues, nil
+}
+
+func (repo AnalyticsRepository) getClosedDays(ctx context.Context, queueIDs []int64, from time.Time, to time.Time) (map[int64]map[string]bool, error) {
+
+	closed := make(map[int64]map[string]bool, len(queueIDs))
+
+	for _, id := range queueIDs {
+		closed[id] = make(map[string]bool)
+	}
+
+	rows, err := repo.db.QueryContext(
+		ctx,
+		`SELECT queue_id, DATE(date) FROM unavailable
+		WHERE queue_id IN (`+placeholders(len(queueIDs))+`) AND date >= ? AND date < ?;`,
+		append(int64Args(queueIDs), from, to)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var queueID int64
+		var day time.Time
+
+		err := rows.Scan(&queueID, &day)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		closed[queueID][analyticsDay(day)] = true
+	}
+
+	return closed, nil
+}
+
+// SumRollups adds the rolled up figures in the range up per queue, and the
+// hourly bookings per queue, weekday and hour.
+func (repo AnalyticsRepository) SumRollups(ctx context.Context, query entities.AnalyticsQuery) ([]entities.QueueDay, []entities.QueueHour, error) {
+
+	args := []interface{}{query.From, query.To, query.MerchantID, query.MerchantID, query.QueueID, query.QueueID}
+
+	stmt, err := repo.db.PrepareContext(ctx, `
+		SELECT s.queue_id, s.merchant_id, SUM(s.offered), SUM(s.booked), SUM(s.cancelled), SUM(s.no_shows), SUM(s.checked_in), SUM(s.lead_minutes)
+		FROM queue_daily_stats s
+		INNER JOIN queue q on s.queue_id = q.id
+		INNER JOIN merchant m on s.merchant_id = m.id
+		WHERE s.day BETWEEN ? AND ? AND q.deleted_at IS NULL AND m.deleted_at IS NULL
+			AND (? = 0 OR s.merchant_id = ?) AND (? = 0 OR s.queue_id = ?)
+		GROUP BY s.queue_id, s.merchant_id
+		ORDER BY s.queue_id ASC;`)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	days := make([]entities.QueueDay, 0)
+
+	for rows.Next() {
+		day := entities.QueueDay{}
+
+		err := rows.Scan(
+			&day.QueueID,
+			&day.MerchantID,
+			&day.Offered,
+			&day.Booked,
+			&day.Cancelled,
+			&day.NoShows,
+			&day.CheckedIn,
+			&day.LeadMinutes,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		days = append(days, day)
+	}
+
+	stmt, err = repo.db.PrepareContext(ctx, `
+		SELECT s.queue_id, s.merchant_id, DAYOFWEEK(s.day) - 1, s.hour, SUM(s.bookings)
+		FROM queue_hourly_stats s
+		INNER JOIN queue q on s.queue_id = q.id
+		INNER JOIN merchant m on s.merchant_id = m.id
+		WHERE s.day BETWEEN ? AND ? AND q.deleted_at IS NULL AND m.deleted_at IS NULL
+			AND (? = 0 OR s.merchant_id = ?) AND (? = 0 OR s.queue_id = ?)
+		GROUP BY s.queue_id, s.merchant_id, DAYOFWEEK(s.day), s.hour
+		ORDER BY s.queue_id ASC, DAYOFWEEK(s.day) ASC, s.hour ASC;`)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err = stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	hours := make([]entities.QueueHour, 0)
+
+	for rows.Next() {
+		hour := entities.QueueHour{}
+
+		err := rows.Scan(&hour.QueueID, &hour.MerchantID, &hour.Weekday, &hour.Hour, &hour.Bookings)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		hours = append(hours, hour)
+	}
+
+	return days, hours, nil
+}
+
+// GetRolledDays returns the days in the range that have been rolled up.
+func (repo AnalyticsRepository) GetRolledDays(ctx context.Context, from time.Time, to time.Time) ([]time.Time, error) {
+
+	stmt, err := repo.db.PrepareContext(ctx, `SELECT day FROM analytics_rollup WHERE day BETWEEN ? AND ? ORDER BY day ASC;`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	days := make([]time.Time, 0)
+
+	for rows.Next() {
+		var day time.Time
+
+		err := rows.Scan(&day)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		days = append(days, day)
+	}
+
+	return days, nil
+}
+
+// GetRollupStart returns the first day that has not been rolled up: the day
+// after the last rollup, or the day of the first reservation. It is nil when
+// there is nothing to roll up.
+func (repo AnalyticsRepository) GetRollupStart(ctx context.Context) (*time.Time, error) {
+
+	var last sql.NullTime
+
+	err := repo.db.QueryRowContext(ctx, `SELECT MAX(day) FROM analytics_rollup;`).Scan(&last)
+	if err != nil {
+		return nil, err
+	}
+
+	if last.Valid {
+		next := last.Time.AddDate(0, 0, 1)
+		return &next, nil
+	}
+
+	var first sql.NullTime
+
+	err = repo.db.QueryRowContext(ctx, `SELECT DATE(MIN(start_time)) FROM reserved_slots;`).Scan(&first)
+	if err != nil {
+		return nil, err
+	}
+
+	if !first.Valid {
+		return nil, nil
+	}
+
+	return &first.Time, nil
+}
+
+// Rollup computes the figures of every queue on the day and replaces the
+// stored rollup of that day. Days without any activity store no rows.
+func (repo AnalyticsRepository) Rollup(ctx context.Context, day time.Time) (bool, error) {
+
+	days, hours, err := repo.Compute(ctx, entities.AnalyticsQuery{From: day, To: day})
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM queue_daily_stats WHERE day = ?;`, day)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM queue_hourly_stats WHERE day = ?;`, day)
+	if err != nil {
+		return false, err
+	}
+
+	dailyStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO queue_daily_stats (queue_id, merchant_id, day, offered, booked, cancelled, no_shows, checked_in, lead_minutes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);`)
+	if err != nil {
+		return false, err
+	}
+
+	defer dailyStmt.Close()
+
+	for _, stats := range days {
+		if stats.Offered == 0 && stats.Booked == 0 && stats.Cancelled == 0 {
+			continue
+		}
+
+		_, err = dailyStmt.ExecContext(
+			ctx,
+			stats.QueueID,
+			stats.MerchantID,
+			day,
+			stats.Offered,
+			stats.Booked,
+			stats.Cancelled,
+			stats.NoShows,
+			stats.CheckedIn,
+			stats.LeadMinutes,
+		)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	hourlyStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO queue_hourly_stats (queue_id, merchant_id, day, hour, bookings) VALUES (?, ?, ?, ?, ?);`)
+	if err != nil {
+		return false, err
+	}
+
+	defer hourlyStmt.Close()
+
+	for _, hour := range hours {
+		_, err = hourlyStmt.ExecContext(ctx, hour.QueueID, hour.MerchantID, day, hour.Hour, hour.Bookings)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO analytics_rollup (day) VALUES (?) ON DUPLICATE KEY UPDATE rolled_at = CURRENT_TIMESTAMP;`, day)
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,307 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+)
+
+type BranchRepository struct {
+	db *sql.DB
+}
+
+func NewBranchRepository(db *sql.DB) interfaces.BranchRepository {
+	repo := &BranchRepository{
+		db: db,
+	}
+
+	return repo
+}
+
+func (repo BranchRepository) GetByMerchant(ctx context.Context, merchantID int64) ([]entities.Branch, error) {
+
+	branches, err := loadBranches(ctx, repo.db, []int64{merchantID}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return branches[merchantID], nil
+}
+
+func (repo BranchRepository) GetSingle(ctx context.Context, merchantID int64, id int64) (*entities.Branch, error) {
+
+	branches, err := repo.GetByMerchant(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, branch := range branches {
+		if branch.ID == id {
+			return &branch, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (repo BranchRepository) Create(ctx context.Context, branch entities.Branch) (int64, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO branch (merchant_id, name, address, city, postal_code, country, latitude, longitude, phone)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		branch.MerchantID,
+		branch.Name,
+		branch.Address,
+		branch.City,
+		branch.PostalCode,
+		branch.Country,
+		branch.Latitude,
+		branch.Longitude,
+		branch.Phone,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	err = saveHours(ctx, tx, id, branch.Hours)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Update overwrites the branch and replaces its opening hours.
+func (repo BranchRepository) Update(ctx context.Context, branch entities.Branch) (bool, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE branch SET name = ?, address = ?, city = ?, postal_code = ?, country = ?, latitude = ?, longitude = ?, phone = ?
+		WHERE id = ? AND merchant_id = ?;`,
+		branch.Name,
+		branch.Address,
+		branch.City,
+		branch.PostalCode,
+		branch.Country,
+		branch.Latitude,
+		branch.Longitude,
+		branch.Phone,
+		branch.ID,
+		branch.MerchantID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM branch_hours WHERE branch_id = ?;`, branch.ID)
+	if err != nil {
+		return false, err
+	}
+
+	err = saveHours(ctx, tx, branch.ID, branch.Hours)
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repo BranchRepository) Delete(ctx context.Context, merchantID int64, id int64) (bool, error) {
+
+	// deleted queues count until they are purged, so they can still be
+	// restored into their branch
+	query := `SELECT EXISTS(SELECT 1 FROM queue WHERE branch_id = ?);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	var exists bool
+
+	err = stmt.QueryRowContext(ctx, id).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	if exists {
+		return false, errors.New("branch still has queues")
+	}
+
+	query = `DELETE FROM branch WHERE id = ? AND merchant_id = ?;`
+
+	stmt, err = repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, id, merchantID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 0 {
+		return false, errors.New("there are no such branch")
+	}
+
+	return true, nil
+}
+
+func saveHours(ctx context.Context, tx *sql.Tx, branchID int64, hours []entities.OpeningHours) error {
+
+	for _, h := range hours {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO branch_hours (branch_id, weekday, opens_at, closes_at) VALUES (?, ?, ?, ?);`,
+			branchID,
+			h.Weekday,
+			h.Opens,
+			h.Closes,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadBranches returns the branches of the given merchants with their
+// opening hours, keyed by merchant id. Branches of deleted merchants are
+// left out unless includeDeleted is set.
+func loadBranches(ctx context.Context, db *sql.DB, merchantIDs []int64, includeDeleted bool) (map[int64][]entities.Branch, error) {
+
+	branches := make(map[int64][]entities.Branch, len(merchantIDs))
+
+	if len(merchantIDs) == 0 {
+		return branches, nil
+	}
+
+	ids := int64Args(merchantIDs)
+
+	rows, err := db.QueryContext(
+		ctx,
+		`SELECT b.id, b.merchant_id, b.name, b.address, b.city, b.postal_code, b.country, b.latitude, b.longitude, b.phone,
+			b.created_at, b.updated_at, h.weekday, TIME_FORMAT(h.opens_at, '%H:%i'), TIME_FORMAT(h.closes_at, '%H:%i')
+		FROM branch b
+		INNER JOIN merchant m on m.id = b.merchant_id
+		LEFT JOIN branch_hours h on h.branch_id = b.id
+		WHERE b.merchant_id IN (`+placeholders(len(ids))+`) AND (? OR m.deleted_at IS NULL)
+		ORDER BY b.id ASC, h.weekday ASC, h.opens_at ASC;`,
+		append(ids, includeDeleted)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	order := make([]int64, 0)
+	byID := make(map[int64]*entities.Branch)
+
+	for rows.Next() {
+		branch := entities.Branch{}
+
+		var latitude, longitude sql.NullFloat64
+		var weekday sql.NullInt64
+		var opens, closes sql.NullString
+
+		err := rows.Scan(
+			&branch.ID,
+			&branch.MerchantID,
+			&branch.Name,
+			&branch.Address,
+			&branch.City,
+			&branch.PostalCode,
+			&branch.Country,
+			&latitude,
+			&longitude,
+			&branch.Phone,
+			&branch.CreatedAt,
+			&branch.UpdatedAt,
+			&weekday,
+			&opens,
+			&closes,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		current, ok := byID[branch.ID]
+		if !ok {
+			if latitude.Valid && longitude.Valid {
+				branch.Latitude = &latitude.Float64
+				branch.Longitude = &longitude.Float64
+			}
+
+			branch.Hours = make([]entities.OpeningHours, 0)
+
+			current = &branch
+			byID[branch.ID] = current
+			order = append(order, branch.ID)
+		}
+
+		if weekday.Valid {
+			current.Hours = append(current.Hours, entities.OpeningHours{
+				Weekday: int(weekday.Int64),
+				Opens:   opens.String,
+				Closes:  closes.String,
+			})
+		}
+	}
+
+	for _, id := range merchantIDs {
+		branches[id] = make([]entities.Branch, 0)
+	}
+
+	for _, id := range order {
+		branch := byID[id]
+		branches[branch.MerchantID] = append(branches[branch.MerchantID], *branch)
+	}
+
+	return branches, nil
+}
@@ -0,0 +1,277 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+)
+
+type CategoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryRepository(db *sql.DB) interfaces.CategoryRepository {
+	repo := &CategoryRepository{
+		db: db,
+	}
+
+	return repo
+}
+
+// GetAll returns every category, retired ones included, in display order.
+func (repo CategoryRepository) GetAll(ctx context.Context) ([]entities.Category, error) {
+
+	query := `
+		SELECT category, parent, icon, position, retired_at, created_at, updated_at
+		FROM category ORDER BY position ASC, category ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	categories := make([]entities.Category, 0)
+
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		categories = append(categories, category)
+	}
+
+	return categories, nil
+}
+
+func (repo CategoryRepository) GetSingle(ctx context.Context, name string) (*entities.Category, error) {
+
+	query := `
+		SELECT category, parent, icon, position, retired_at, created_at, updated_at
+		FROM category WHERE category = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	category, err := scanCategory(stmt.QueryRowContext(ctx, name))
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (repo CategoryRepository) Create(ctx context.Context, category entities.Category) (bool, error) {
+
+	query := `INSERT INTO category (category, parent, icon, position) VALUES (?, ?, ?, ?);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, category.Name, nullString(category.Parent), category.Icon, category.Position)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Update saves the parent and icon and, when the name changed, renames the
+// category. Merchants reference categories by name, so a rename copies the
+// row under the new name, moves merchants and subcategories over and then
+// drops the old row, all in one transaction.
+func (repo CategoryRepository) Update(ctx context.Context, name string, category entities.Category) (bool, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
+		ctx,
+		`UPDATE category SET parent = ?, icon = ? WHERE category = ?;`,
+		nullString(category.Parent),
+		category.Icon,
+		name,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 0 {
+		var exists bool
+
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM category WHERE category = ?);`, name).Scan(&exists)
+		if err != nil {
+			return false, err
+		}
+
+		if !exists {
+			return false, errors.New("there are no such category")
+		}
+	}
+
+	if category.Name != name {
+		statements := []struct {
+			query string
+			args  []interface{}
+		}{
+			{
+				`INSERT INTO category (category, parent, icon, position, retired_at, created_at)
+				SELECT ?, parent, icon, position, retired_at, created_at FROM category WHERE category = ?;`,
+				[]interface{}{category.Name, name},
+			},
+			{`UPDATE merchant SET category = ?, updated_at = updated_at WHERE category = ?;`, []interface{}{category.Name, name}},
+			{`UPDATE category SET parent = ? WHERE parent = ?;`, []interface{}{category.Name, name}},
+			{`DELETE FROM category WHERE category = ?;`, []interface{}{name}},
+		}
+
+		for _, statement := range statements {
+			_, err = tx.ExecContext(ctx, statement.query, statement.args...)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Reorder sets the position of each category to its index in names.
+func (repo CategoryRepository) Reorder(ctx context.Context, names []string) (bool, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	for position, name := range names {
+		_, err = tx.ExecContext(ctx, `UPDATE category SET position = ? WHERE category = ?;`, position, name)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repo CategoryRepository) Retire(ctx context.Context, name string) (bool, error) {
+
+	query := `UPDATE category SET retired_at = CURRENT_TIMESTAMP WHERE category = ? AND retired_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, name)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repo CategoryRepository) Restore(ctx context.Context, name string) (bool, error) {
+
+	query := `UPDATE category SET retired_at = NULL WHERE category = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, name)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+type categoryScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCategory(row categoryScanner) (entities.Category, error) {
+
+	category := entities.Category{}
+
+	var parent sql.NullString
+	var retiredAt sql.NullTime
+
+	err := row.Scan(
+		&category.Name,
+		&parent,
+		&category.Icon,
+		&category.Position,
+		&retiredAt,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
+	if err != nil {
+		return category, err
+	}
+
+	category.Parent = parent.String
+
+	if retiredAt.Valid {
+		category.RetiredAt = &retiredAt.Time
+	}
+
+	return category, nil
+}
+
+func nullString(value string) sql.NullString {
+
+	return sql.NullString{String: value, Valid: len(value) != 0}
+}
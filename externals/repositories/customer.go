@@ -0,0 +1,520 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"strings"
+	"time"
+)
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) interfaces.CustomerRepository {
+	repo := &CustomerRepository{
+		db: db,
+	}
+
+	return repo
+}
+
+func (repo CustomerRepository) CreateOTP(ctx context.Context, phone string, codeHash string, expiresAt time.Time) (int64, error) {
+
+	query := `INSERT INTO customer_otp (phone, code_hash, expires_at) VALUES (?, ?, ?);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, phone, codeHash, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo CustomerRepository) GetLatestOTP(ctx context.Context, phone string) (*entities.OTP, error) {
+
+	query := `
+		SELECT id, phone, code_hash, attempts, expires_at, consumed_at, created_at
+		FROM customer_otp WHERE phone = ? ORDER BY id DESC LIMIT 1;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	otp := entities.OTP{}
+
+	var consumedAt sql.NullTime
+
+	err = stmt.QueryRowContext(ctx, phone).Scan(
+		&otp.ID,
+		&otp.Phone,
+		&otp.CodeHash,
+		&otp.Attempts,
+		&otp.ExpiresAt,
+		&consumedAt,
+		&otp.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if consumedAt.Valid {
+		otp.ConsumedAt = &consumedAt.Time
+	}
+
+	return &otp, nil
+}
+
+// IncrementOTPAttempts counts an attempt against the code unless max
+// attempts were made already. The check and the increment are one
+// statement, so parallel guesses cannot all slip under the limit.
+func (repo CustomerRepository) IncrementOTPAttempts(ctx context.Context, id int64, max int) (bool, error) {
+
+	query := `UPDATE customer_otp SET attempts = attempts + 1 WHERE id = ? AND attempts < ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, id, max)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (repo CustomerRepository) ConsumeOTP(ctx context.Context, id int64) (bool, error) {
+
+	query := `UPDATE customer_otp SET consumed_at = CURRENT_TIMESTAMP WHERE id = ? AND consumed_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 0 {
+		return false, errors.New("code already used")
+	}
+
+	return true, nil
+}
+
+// ResolveUser returns the existing user with the given phone, or creates a
+// new one. Bookings are not authenticated, so an existing user's name and
+// email are only filled in when missing, never overwritten, and the email
+// is not used to match: anyone could type in someone else's address.
+func (repo CustomerRepository) ResolveUser(ctx context.Context, user entities.User) (entities.User, error) {
+
+	query := `
+		SELECT id, name, phone, email, created_at FROM user
+		WHERE erased_at IS NULL AND phone = ?
+		ORDER BY id ASC LIMIT 1;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	defer stmt.Close()
+
+	existing := entities.User{}
+
+	var email sql.NullString
+
+	err = stmt.QueryRowContext(ctx, user.Phone).Scan(
+		&existing.ID,
+		&existing.Name,
+		&existing.Phone,
+		&email,
+		&existing.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return repo.createUser(ctx, user)
+	}
+
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	existing.Email = email.String
+
+	changed := false
+
+	if len(existing.Name) == 0 && len(user.Name) != 0 {
+		existing.Name = user.Name
+		changed = true
+	}
+
+	if len(existing.Email) == 0 && len(user.Email) != 0 {
+		existing.Email = user.Email
+		changed = true
+	}
+
+	if !changed {
+		return existing, nil
+	}
+
+	query = `UPDATE user SET name = ?, email = ? WHERE id = ?;`
+
+	stmt, err = repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, existing.Name, existing.Email, existing.ID)
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	return existing, nil
+}
+
+func (repo CustomerRepository) createUser(ctx context.Context, user entities.User) (entities.User, error) {
+
+	query := `INSERT INTO user (name, phone, email) VALUES (?, ?, ?);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, user.Name, user.Phone, user.Email)
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	user.ID = id
+	user.CreatedAt = time.Now()
+
+	return user, nil
+}
+
+func (repo CustomerRepository) GetUsers(ctx context.Context) ([]entities.User, error) {
+
+	query := `SELECT id, name, phone, email, created_at FROM user WHERE erased_at IS NULL ORDER BY id ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	users := make([]entities.User, 0)
+
+	for rows.Next() {
+		user := entities.User{}
+
+		var email sql.NullString
+
+		err := rows.Scan(
+			&user.ID,
+			&user.Name,
+			&user.Phone,
+			&email,
+			&user.CreatedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		user.Email = email.String
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// MergeUsers re-points everything owned by the duplicates to the keeper and
+// removes the duplicate rows in a single transaction.
+func (repo CustomerRepository) MergeUsers(ctx context.Context, keeper entities.User, duplicateIDs []int64) (bool, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	queries := []string{
+		`UPDATE reserved_slots SET reserved_by = ? WHERE reserved_by = ?;`,
+		`UPDATE customer_token SET user_id = ? WHERE user_id = ?;`,
+		`UPDATE review SET user_id = ? WHERE user_id = ?;`,
+	}
+
+	for _, query := range queries {
+		for _, id := range duplicateIDs {
+			_, err = tx.ExecContext(ctx, query, keeper.ID, id)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	for _, id := range duplicateIDs {
+		_, err = tx.ExecContext(ctx, `DELETE FROM user WHERE id = ?;`, id)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE user SET name = ?, phone = ?, email = ? WHERE id = ?;`,
+		keeper.Name,
+		keeper.Phone,
+		keeper.Email,
+		keeper.ID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repo CustomerRepository) CreateToken(ctx context.Context, userID int64, token string) (string, error) {
+
+	query := `INSERT INTO customer_token (user_id, auth_token) VALUES (?, ?);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, userID, token)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (repo CustomerRepository) ValidateToken(ctx context.Context, token string) (entities.User, error) {
+
+	query := `
+		SELECT u.id, u.name, u.phone, u.email, u.created_at
+		FROM customer_token ct INNER JOIN user u on ct.user_id = u.id
+		WHERE ct.auth_token = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	defer stmt.Close()
+
+	user := entities.User{}
+
+	var email sql.NullString
+
+	err = stmt.QueryRowContext(ctx, token).Scan(
+		&user.ID,
+		&user.Name,
+		&user.Phone,
+		&email,
+		&user.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return entities.User{}, errors.New("token is not valid")
+	}
+
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	user.Email = email.String
+
+	return user, nil
+}
+
+func (repo CustomerRepository) Logout(ctx context.Context, userID int64) (bool, error) {
+
+	query := `DELETE FROM customer_token WHERE user_id = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repo CustomerRepository) GetBookings(ctx context.Context, phone string, filter entities.BookingFilter, now time.Time) ([]entities.Booking, error) {
+
+	conditions := []string{"u.phone = ?"}
+	args := []interface{}{phone}
+
+	order := "ASC"
+
+	if filter.Upcoming != nil {
+		if *filter.Upcoming {
+			conditions = append(conditions, "rs.end_time >= ?")
+		} else {
+			conditions = append(conditions, "rs.end_time < ?")
+			order = "DESC"
+		}
+
+		args = append(args, now)
+	}
+
+	if filter.From != nil {
+		conditions = append(conditions, "rs.start_time >= ?")
+		args = append(args, *filter.From)
+	}
+
+	if filter.To != nil {
+		conditions = append(conditions, "rs.start_time < ?")
+		args = append(args, *filter.To)
+	}
+
+	offset := (filter.Paginator.Page - 1) * filter.Paginator.Size
+
+	args = append(args, filter.Paginator.Size, offset)
+
+	query := `
+		SELECT rs.token_no, rs.queue_id, q.name, m.id, m.name, rs.start_time, rs.end_time, rs.arrived_at, rs.called_at, rs.no_show_at, rs.created_at
+		FROM reserved_slots rs
+		INNER JOIN user u on rs.reserved_by = u.id
+		INNER JOIN queue q on rs.queue_id = q.id
+		INNER JOIN merchant m on q.merchant_id = m.id
+		WHERE q.deleted_at IS NULL AND m.deleted_at IS NULL AND ` + strings.Join(conditions, " AND ") + `
+		ORDER BY rs.start_time ` + order + `, rs.token_no ` + order + ` LIMIT ? OFFSET ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	bookings := make([]entities.Booking, 0)
+
+	for rows.Next() {
+		booking := entities.Booking{}
+
+		var arrivedAt, calledAt, noShowAt sql.NullTime
+
+		err := rows.Scan(
+			&booking.TokenNo,
+			&booking.QueueID,
+			&booking.QueueName,
+			&booking.MerchantID,
+			&booking.MerchantName,
+			&booking.StartTime,
+			&booking.EndTime,
+			&arrivedAt,
+			&calledAt,
+			&noShowAt,
+			&booking.CreatedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if arrivedAt.Valid {
+			booking.ArrivedAt = &arrivedAt.Time
+		}
+
+		if calledAt.Valid {
+			booking.CalledAt = &calledAt.Time
+		}
+
+		if noShowAt.Valid {
+			booking.NoShowAt = &noShowAt.Time
+		}
+
+		bookings = append(bookings, booking)
+	}
+
+	return bookings, nil
+}
@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+)
+
+type ImageRepository struct {
+	db *sql.DB
+}
+
+func NewImageRepository(db *sql.DB) interfaces.ImageRepository {
+	repo := &ImageRepository{
+		db: db,
+	}
+
+	return repo
+}
+
+func (repo ImageRepository) GetByMerchant(ctx context.Context, merchantID int64, kind string) ([]entities.MerchantImage, error) {
+
+	images, err := loadImages(ctx, repo.db, []int64{merchantID}, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return images[merchantID], nil
+}
+
+func (repo ImageRepository) Create(ctx context.Context, image entities.MerchantImage) (int64, error) {
+
+	query := `
+		INSERT INTO merchant_image (merchant_id, kind, blob_key, thumbnail_key, width, height)
+		VALUES (?, ?, ?, ?, ?, ?);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(
+		ctx,
+		image.MerchantID,
+		image.Kind,
+		image.Key,
+		image.ThumbnailKey,
+		image.Width,
+		image.Height,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Delete removes the image row and returns it so its blobs can be removed.
+func (repo ImageRepository) Delete(ctx context.Context, merchantID int64, id int64) (*entities.MerchantImage, error) {
+
+	query := `
+		SELECT id, merchant_id, kind, blob_key, thumbnail_key, width, height, created_at
+		FROM merchant_image WHERE id = ? AND merchant_id = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	image, err := scanImage(stmt.QueryRowContext(ctx, id, merchantID))
+
+	if err == sql.ErrNoRows {
+		return nil, errors.New("there are no such image")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	query = `DELETE FROM merchant_image WHERE id = ? AND merchant_id = ?;`
+
+	stmt, err = repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, id, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &image, nil
+}
+
+type imageScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanImage(row imageScanner) (entities.MerchantImage, error) {
+
+	image := entities.MerchantImage{}
+
+	err := row.Scan(
+		&image.ID,
+		&image.MerchantID,
+		&image.Kind,
+		&image.Key,
+		&image.ThumbnailKey,
+		&image.Width,
+		&image.Height,
+		&image.CreatedAt,
+	)
+
+	return image, err
+}
+
+// loadImages returns the images of one kind for the given merchants, oldest
+// first, keyed by merchant id.
+func loadImages(ctx context.Context, db *sql.DB, merchantIDs []int64, kind string) (map[int64][]entities.MerchantImage, error) {
+
+	images := make(map[int64][]entities.MerchantImage, len(merchantIDs))
+
+	if len(merchantIDs) == 0 {
+		return images, nil
+	}
+
+	for _, id := range merchantIDs {
+		images[id] = make([]entities.MerchantImage, 0)
+	}
+
+	rows, err := db.QueryContext(
+		ctx,
+		`SELECT id, merchant_id, kind, blob_key, thumbnail_key, width, height, created_at
+		FROM merchant_image WHERE kind = ? AND merchant_id IN (`+placeholders(len(merchantIDs))+`)
+		ORDER BY id ASC;`,
+		append([]interface{}{kind}, int64Args(merchantIDs)...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		image, err := scanImage(rows)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		images[image.MerchantID] = append(images[image.MerchantID], image)
+	}
+
+	return images, nil
+}
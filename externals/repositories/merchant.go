@@ -6,8 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"no-q-solution/domain/entities"
 	"no-q-solution/domain/interfaces"
+	"no-q-solution/utils/search"
+	"sort"
+	"strings"
+	"time"
 )
 
 type MerchantRepository struct {
@@ -22,12 +27,77 @@ func NewMerchantRepository(db *sql.DB) interfaces.MerchantRepository {
 	return repo
 }
 
-func (repo MerchantRepository) GetAll(ctx context.Context, paginator entities.Paginator) ([]entities.Merchant, error) {
+// merchantOrder maps a sort option to its ORDER BY clause. Unknown options
+// fall back to insertion order so callers never reach the query text.
+func merchantOrder(sort string) string {
+	switch sort {
+	case entities.MerchantSortRating:
+		return "rating_avg DESC, rating_count DESC, id ASC"
+	default:
+		return "id ASC"
+	}
+}
+
+// merchantAvailability turns the filter into conditions on the merchant's
+// queues. A queue is open now when it is available, not closed for the day,
+// inside its daily hours and inside its branch's opening hours, if the
+// branch has any. It has free slots on a day when it is available, not
+// closed that day and has fewer reservations than its hours fit intervals.
+func merchantAvailability(filter entities.MerchantFilter) (string, []interface{}) {
+
+	conditions := ""
+	args := make([]interface{}, 0)
+
+	now := filter.Now.Format("15:04:05")
+	today := filter.Now.Format("2006-01-02")
+
+	if filter.OpenNow {
+		conditions += `
+		AND EXISTS (
+			SELECT 1 FROM queue q INNER JOIN branch b on b.id = q.branch_id
+			WHERE q.merchant_id = merchant.id AND q.deleted_at IS NULL AND q.is_available = 1
+				AND TIME(q.start_time) <= ? AND TIME(q.end_time) > ?
+				AND NOT EXISTS (SELECT 1 FROM unavailable ua WHERE ua.queue_id = q.id AND DATE(ua.date) = ?)
+				AND (
+					NOT EXISTS (SELECT 1 FROM branch_hours h WHERE h.branch_id = b.id)
+					OR EXISTS (SELECT 1 FROM branch_hours h WHERE h.branch_id = b.id AND h.weekday = ? AND h.opens_at <= ? AND h.closes_at > ?)
+				)
+		)`
+		args = append(args, now, now, today, int(filter.Now.Weekday()), now, now)
+	}
+
+	if filter.FreeOn != nil {
+		day := filter.FreeOn.Format("2006-01-02")
+		next := filter.FreeOn.AddDate(0, 0, 1).Format("2006-01-02")
+
+		// slots that already ended today cannot be booked any more
+		endsAfter := "00:00:00"
+		if day == today {
+			endsAfter = now
+		}
+
+		conditions += `
+		AND EXISTS (
+			SELECT 1 FROM queue q
+			WHERE q.merchant_id = merchant.id AND q.deleted_at IS NULL AND q.is_available = 1 AND TIME(q.end_time) > ?
+				AND NOT EXISTS (SELECT 1 FROM unavailable ua WHERE ua.queue_id = q.id AND DATE(ua.date) = ?)
+				AND (SELECT COUNT(*) FROM reserved_slots rs WHERE rs.queue_id = q.id AND rs.start_time >= ? AND rs.start_time < ?)
+					< FLOOR(TIME_TO_SEC(TIMEDIFF(TIME(q.end_time), TIME(q.start_time))) / 60 / q.intervals)
+		)`
+		args = append(args, endsAfter, day, day, next)
+	}
+
+	return conditions, args
+}
+
+func (repo MerchantRepository) GetAll(ctx context.Context, paginator entities.Paginator, sort string, filter entities.MerchantFilter) ([]entities.Merchant, error) {
 	offset := (paginator.Page - 1) * paginator.Size
 
+	conditions, args := merchantAvailability(filter)
+
 	query := `
-		SELECT id, name, category, email, facebook, instagram, website, created_at, updated_at
-		FROM merchant ORDER BY id ASC LIMIT ? OFFSET ?;`
+		SELECT id, name, category, email, facebook, instagram, website, rating_avg, rating_count, created_at, updated_at
+		FROM merchant WHERE verified_at IS NOT NULL AND status = 'approved' AND deleted_at IS NULL` + conditions + ` ORDER BY ` + merchantOrder(sort) + ` LIMIT ? OFFSET ?;`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -36,7 +106,7 @@ func (repo MerchantRepository) GetAll(ctx context.Context, paginator entities.Pa
 
 	defer stmt.Close()
 
-	rows, err := stmt.QueryContext(ctx, paginator.Size, offset)
+	rows, err := stmt.QueryContext(ctx, append(args, paginator.Size, offset)...)
 	if err != nil {
 		return nil, err
 	}
@@ -56,6 +126,8 @@ func (repo MerchantRepository) GetAll(ctx context.Context, paginator entities.Pa
 			&merchant.Facebook,
 			&merchant.Instagram,
 			&merchant.Website,
+			&merchant.Rating,
+			&merchant.RatingCount,
 			&merchant.CreatedAt,
 			&merchant.UpdatedAt,
 		)
@@ -68,14 +140,41 @@ func (repo MerchantRepository) GetAll(ctx context.Context, paginator entities.Pa
 		merchantes = append(merchantes, merchant)
 	}
 
+	return repo.withDetails(ctx, merchantes)
+}
+
+// withDetails attaches every merchant's branches and logo so listings can
+// show where each merchant is located.
+func (repo MerchantRepository) withDetails(ctx context.Context, merchantes []entities.Merchant) ([]entities.Merchant, error) {
+	ids := make([]int64, 0, len(merchantes))
+
+	for _, merchant := range merchantes {
+		ids = append(ids, merchant.ID)
+	}
+
+	branches, err := loadBranches(ctx, repo.db, ids, false)
+	if err != nil {
+		return nil, err
+	}
+
+	logos, err := loadImages(ctx, repo.db, ids, entities.ImageLogo)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range merchantes {
+		merchantes[i].Branches = branches[merchantes[i].ID]
+		merchantes[i].Logo = latestImage(logos[merchantes[i].ID])
+	}
+
 	return merchantes, nil
 }
 
-func (repo MerchantRepository) GetCategories(ctx context.Context) ([]entities.Category, error) {
+// GetListed returns the names and categories of every listed merchant for
+// the suggestion index.
+func (repo MerchantRepository) GetListed(ctx context.Context) ([]entities.Merchant, error) {
 
-	query := `
-		SELECT category, created_at, updated_at
-		FROM category ORDER BY category ASC;`
+	query := `SELECT id, name, category, rating_avg FROM merchant WHERE verified_at IS NOT NULL AND status = 'approved' AND deleted_at IS NULL;`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -91,15 +190,16 @@ func (repo MerchantRepository) GetCategories(ctx context.Context) ([]entities.Ca
 
 	defer rows.Close()
 
-	categories := make([]entities.Category, 0)
+	merchantes := make([]entities.Merchant, 0)
 
 	for rows.Next() {
-		category := entities.Category{}
+		merchant := entities.Merchant{}
 
 		err := rows.Scan(
-			&category.Name,
-			&category.CreatedAt,
-			&category.UpdatedAt,
+			&merchant.ID,
+			&merchant.Name,
+			&merchant.Category,
+			&merchant.Rating,
 		)
 
 		if err != nil {
@@ -107,17 +207,65 @@ func (repo MerchantRepository) GetCategories(ctx context.Context) ([]entities.Ca
 			continue
 		}
 
+		merchantes = append(merchantes, merchant)
+	}
+
+	return merchantes, nil
+}
+
+// latestImage returns the newest of the images, or nil when there are none.
+func latestImage(images []entities.MerchantImage) *entities.MerchantImage {
+
+	if len(images) == 0 {
+		return nil
+	}
+
+	return &images[len(images)-1]
+}
+
+func (repo MerchantRepository) GetCategories(ctx context.Context) ([]entities.Category, error) {
+
+	query := `
+		SELECT category, parent, icon, position, retired_at, created_at, updated_at
+		FROM category WHERE retired_at IS NULL ORDER BY position ASC, category ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	categories := make([]entities.Category, 0)
+
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
 		categories = append(categories, category)
 	}
 
 	return categories, nil
 }
 
-func (repo MerchantRepository) GetByCategory(ctx context.Context, category string) ([]entities.Merchant, error) {
+func (repo MerchantRepository) GetByCategory(ctx context.Context, category string, sort string, filter entities.MerchantFilter) ([]entities.Merchant, error) {
+
+	conditions, args := merchantAvailability(filter)
 
 	query := `
-		SELECT id, name, category, email, facebook, instagram, website, created_at, updated_at
-		FROM merchant WHERE category = ? ORDER BY id ASC;
+		SELECT id, name, category, email, facebook, instagram, website, rating_avg, rating_count, created_at, updated_at
+		FROM merchant WHERE (category = ? OR category IN (SELECT c.category FROM category c WHERE c.parent = ?))
+			AND verified_at IS NOT NULL AND status = 'approved' AND deleted_at IS NULL` + conditions + ` ORDER BY ` + merchantOrder(sort) + `;
 	`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
@@ -127,7 +275,7 @@ func (repo MerchantRepository) GetByCategory(ctx context.Context, category strin
 
 	defer stmt.Close()
 
-	rows, err := stmt.QueryContext(ctx, category)
+	rows, err := stmt.QueryContext(ctx, append([]interface{}{category, category}, args...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -147,6 +295,8 @@ func (repo MerchantRepository) GetByCategory(ctx context.Context, category strin
 			&merchant.Facebook,
 			&merchant.Instagram,
 			&merchant.Website,
+			&merchant.Rating,
+			&merchant.RatingCount,
 			&merchant.CreatedAt,
 			&merchant.UpdatedAt,
 		)
@@ -159,12 +309,12 @@ func (repo MerchantRepository) GetByCategory(ctx context.Context, category strin
 		merchantes = append(merchantes, merchant)
 	}
 
-	return merchantes, nil
+	return repo.withDetails(ctx, merchantes)
 }
 
 func (repo MerchantRepository) GetSingle(ctx context.Context, id int64) (*entities.Merchant, error) {
 
-	query := `SELECT EXISTS(SELECT 1 FROM merchant WHERE id = ?);`
+	query := `SELECT EXISTS(SELECT 1 FROM merchant WHERE id = ? AND deleted_at IS NULL);`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
 
@@ -191,8 +341,12 @@ func (repo MerchantRepository) GetSingle(ctx context.Context, id int64) (*entiti
 	merchant := entities.Merchant{}
 
 	query = `
-	SELECT id, name, category, email, facebook, instagram, website, created_at, updated_at
-	FROM merchant WHERE id = ?;
+	SELECT id, name, category, email, country, facebook, instagram, website, rating_avg, rating_count, verified_at,
+		status, status_reason, status_changed_at, created_at, updated_at,
+		(SELECT v.email FROM merchant_verification v
+			WHERE v.merchant_id = merchant.id AND v.purpose = 'email_change' AND v.used_at IS NULL AND v.expires_at > CURRENT_TIMESTAMP
+			ORDER BY v.id DESC LIMIT 1)
+	FROM merchant WHERE id = ? AND deleted_at IS NULL;
 	`
 
 	stmt, err = repo.db.PrepareContext(ctx, query)
@@ -204,16 +358,27 @@ func (repo MerchantRepository) GetSingle(ctx context.Context, id int64) (*entiti
 
 	row = stmt.QueryRowContext(ctx, id)
 
+	var verifiedAt, statusAt sql.NullTime
+	var pendingEmail sql.NullString
+
 	err = row.Scan(
 		&merchant.ID,
 		&merchant.Name,
 		&merchant.Category,
 		&merchant.Email,
+		&merchant.Country,
 		&merchant.Facebook,
 		&merchant.Instagram,
 		&merchant.Website,
+		&merchant.Rating,
+		&merchant.RatingCount,
+		&verifiedAt,
+		&merchant.Status,
+		&merchant.StatusReason,
+		&statusAt,
 		&merchant.CreatedAt,
 		&merchant.UpdatedAt,
+		&pendingEmail,
 	)
 
 	if err == sql.ErrNoRows {
@@ -224,14 +389,90 @@ func (repo MerchantRepository) GetSingle(ctx context.Context, id int64) (*entiti
 		return nil, err
 	}
 
+	merchant.PendingEmail = pendingEmail.String
+
+	if statusAt.Valid {
+		merchant.StatusAt = &statusAt.Time
+	}
+
+	if verifiedAt.Valid {
+		merchant.VerifiedAt = &verifiedAt.Time
+	}
+
+	branches, err := loadBranches(ctx, repo.db, []int64{merchant.ID}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	merchant.Branches = branches[merchant.ID]
+
+	logos, err := loadImages(ctx, repo.db, []int64{merchant.ID}, entities.ImageLogo)
+	if err != nil {
+		return nil, err
+	}
+
+	gallery, err := loadImages(ctx, repo.db, []int64{merchant.ID}, entities.ImageGallery)
+	if err != nil {
+		return nil, err
+	}
+
+	merchant.Logo = latestImage(logos[merchant.ID])
+	merchant.Gallery = gallery[merchant.ID]
+
 	return &merchant, nil
 }
 
-func (repo MerchantRepository) Search(ctx context.Context, input string) ([]entities.Merchant, error) {
-	query := fmt.Sprintf(`
-		SELECT id, name, category, facebook, instagram, website, created_at, updated_at
-		FROM merchant WHERE name LIKE '%%%s%%' ORDER BY id ASC;
-	`, input)
+func (repo MerchantRepository) GetCountry(ctx context.Context, id int64) (string, error) {
+
+	query := `SELECT country FROM merchant WHERE id = ? AND deleted_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+
+	defer stmt.Close()
+
+	var country string
+
+	err = stmt.QueryRowContext(ctx, id).Scan(&country)
+
+	if err == sql.ErrNoRows {
+		return "", errors.New("there are no such merchant exists")
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return country, nil
+}
+
+// searchThreshold is the lowest relevance a merchant needs to be returned.
+const searchThreshold = 0.3
+
+// Search ranks listed merchants by how well their name or category matches
+// the input, tolerating typos. Every merchant matching one of the LIKE
+// patterns on the whole input or its trigrams is scored, so pages cover all
+// matches; full rows are only loaded for the requested page.
+func (repo MerchantRepository) Search(ctx context.Context, input string, paginator entities.Paginator) ([]entities.Merchant, error) {
+
+	patterns := search.Patterns(input)
+	if len(patterns) == 0 {
+		return make([]entities.Merchant, 0), nil
+	}
+
+	conditions := make([]string, 0, len(patterns))
+	args := make([]interface{}, 0, len(patterns)*2)
+
+	for _, pattern := range patterns {
+		conditions = append(conditions, "name LIKE ? OR category LIKE ?")
+		args = append(args, pattern, pattern)
+	}
+
+	query := `
+		SELECT id, name, category, rating_avg
+		FROM merchant WHERE verified_at IS NOT NULL AND status = 'approved' AND deleted_at IS NULL AND (` + strings.Join(conditions, " OR ") + `);`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -240,14 +481,14 @@ func (repo MerchantRepository) Search(ctx context.Context, input string) ([]enti
 
 	defer stmt.Close()
 
-	rows, err := stmt.QueryContext(ctx)
+	rows, err := stmt.QueryContext(ctx, args...)
 	if err != nil {
 		return nil, err
 	}
 
 	defer rows.Close()
 
-	merchantes := make([]entities.Merchant, 0)
+	candidates := make([]entities.Merchant, 0)
 
 	for rows.Next() {
 		merchant := entities.Merchant{}
@@ -256,11 +497,7 @@ func (repo MerchantRepository) Search(ctx context.Context, input string) ([]enti
 			&merchant.ID,
 			&merchant.Name,
 			&merchant.Category,
-			&merchant.Facebook,
-			&merchant.Instagram,
-			&merchant.Website,
-			&merchant.CreatedAt,
-			&merchant.UpdatedAt,
+			&merchant.Rating,
 		)
 
 		if err != nil {
@@ -268,161 +505,989 @@ func (repo MerchantRepository) Search(ctx context.Context, input string) ([]enti
 			continue
 		}
 
-		merchantes = append(merchantes, merchant)
+		candidates = append(candidates, merchant)
 	}
 
-	return merchantes, nil
-}
+	ranked := rankMerchants(input, candidates)
 
-func (repo MerchantRepository) Create(ctx context.Context, merchant entities.Merchant) (int64, error) {
+	ids := make([]int64, 0, paginator.Size)
 
-	query := `INSERT INTO merchant (category, name, email, password, facebook, instagram, website) VALUES (?, ?, ?, ?, ?, ?, ?);`
+	for i := (paginator.Page - 1) * paginator.Size; i >= 0 && i < len(ranked) && len(ids) < paginator.Size; i++ {
+		ids = append(ids, ranked[i].ID)
+	}
 
-	stmt, err := repo.db.PrepareContext(ctx, query)
+	merchantes, err := repo.getByIDs(ctx, ids)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	defer stmt.Close()
+	return repo.withDetails(ctx, merchantes)
+}
 
-	result, err := stmt.ExecContext(
-		ctx,
-		merchant.Category,
-		merchant.Name,
-		merchant.Email,
-		merchant.Password,
-		merchant.Facebook,
-		merchant.Instagram,
-		merchant.Website,
-	)
-	if err != nil {
-		return 0, err
-	}
+// rankMerchants keeps the candidates that reach searchThreshold, best match
+// first and then by rating. A category match is worth a little less than the
+// same name match.
+func rankMerchants(input string, candidates []entities.Merchant) []entities.Merchant {
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, err
+	type ranked struct {
+		merchant entities.Merchant
+		score    float64
 	}
 
-	return id, nil
-}
-
-func (repo MerchantRepository) Login(ctx context.Context, login entities.Login) (*entities.Merchant, error) {
-	merchant := entities.Merchant{}
+	matches := make([]ranked, 0)
 
-	query := `
-	SELECT id, name, category, facebook, instagram, website, created_at, updated_at
-	FROM merchant WHERE email = ? and password = ?;
-	`
+	for _, merchant := range candidates {
+		score := math.Max(search.Score(input, merchant.Name), 0.9*search.Score(input, merchant.Category))
 
-	stmt, err := repo.db.PrepareContext(ctx, query)
-	if err != nil {
-		return nil, err
+		if score >= searchThreshold {
+			matches = append(matches, ranked{merchant: merchant, score: score})
+		}
 	}
 
-	defer stmt.Close()
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
 
-	row := stmt.QueryRowContext(ctx, login.Email, login.Password)
+		if matches[i].merchant.Rating != matches[j].merchant.Rating {
+			return matches[i].merchant.Rating > matches[j].merchant.Rating
+		}
 
-	err = row.Scan(
-		&merchant.ID,
-		&merchant.Name,
-		&merchant.Category,
-		&merchant.Facebook,
-		&merchant.Instagram,
-		&merchant.Website,
-		&merchant.CreatedAt,
-		&merchant.UpdatedAt,
-	)
+		return matches[i].merchant.ID < matches[j].merchant.ID
+	})
 
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
+	merchantes := make([]entities.Merchant, 0, len(matches))
 
-	if err != nil {
-		return nil, err
+	for _, match := range matches {
+		merchantes = append(merchantes, match.merchant)
 	}
 
-	return &merchant, nil
+	return merchantes
 }
 
-func (repo MerchantRepository) CreateToken(ctx context.Context, merchantID int64, token string) (string, error) {
+// getByIDs loads the listing columns of the given merchants in the order of
+// the ids.
+func (repo MerchantRepository) getByIDs(ctx context.Context, ids []int64) ([]entities.Merchant, error) {
 
-	query := `INSERT INTO token (merchant_id, auth_token) VALUES (?, ?);`
+	merchantes := make([]entities.Merchant, 0, len(ids))
+
+	if len(ids) == 0 {
+		return merchantes, nil
+	}
+
+	query := `
+		SELECT id, name, category, email, facebook, instagram, website, rating_avg, rating_count, created_at, updated_at
+		FROM merchant WHERE id IN (` + placeholders(len(ids)) + `);`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	defer stmt.Close()
 
-	result, err := stmt.ExecContext(ctx, merchantID, token)
+	rows, err := stmt.QueryContext(ctx, int64Args(ids)...)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	_, err = result.LastInsertId()
-	if err != nil {
-		return "", err
-	}
+	defer rows.Close()
 
-	return token, nil
-}
+	byID := make(map[int64]entities.Merchant, len(ids))
 
-func (repo MerchantRepository) ValidateToken(ctx context.Context, token string) (int64, error) {
+	for rows.Next() {
+		merchant := entities.Merchant{}
 
-	query := `SELECT merchant_id FROM token WHERE auth_token = ?;`
+		err := rows.Scan(
+			&merchant.ID,
+			&merchant.Name,
+			&merchant.Category,
+			&merchant.Email,
+			&merchant.Facebook,
+			&merchant.Instagram,
+			&merchant.Website,
+			&merchant.Rating,
+			&merchant.RatingCount,
+			&merchant.CreatedAt,
+			&merchant.UpdatedAt,
+		)
 
-	stmt, err := repo.db.PrepareContext(ctx, query)
-	if err != nil {
-		return 0, err
-	}
+		if err != nil {
+			log.Println(err)
+			continue
+		}
 
-	defer stmt.Close()
+		byID[merchant.ID] = merchant
+	}
 
-	row := stmt.QueryRowContext(ctx, token)
+	for _, id := range ids {
+		if merchant, ok := byID[id]; ok {
+			merchantes = append(merchantes, merchant)
+		}
+	}
 
-	var merchantID int64
+	return merchantes, nil
+}
 
-	err = row.Scan(&merchantID)
+// earthRadius is the mean radius of the earth in kilometres.
+const earthRadius = 6371.0
 
-	if err == sql.ErrNoRows {
-		return 0, errors.New("token is not valid")
-	}
+// Nearby returns branches of listed merchants within the radius, closest
+// first. A bounding box on the indexed coordinates narrows the rows before
+// the haversine distance is computed.
+func (repo MerchantRepository) Nearby(ctx context.Context, geo entities.GeoQuery) ([]entities.NearbyBranch, error) {
 
-	if err != nil {
-		return 0, err
-	}
+	latDelta := geo.Radius / earthRadius * 180 / math.Pi
+	minLat, maxLat := geo.Latitude-latDelta, geo.Latitude+latDelta
+	minLng, maxLng := -180.0, 180.0
 
-	return merchantID, nil
-}
+	// near the poles or across the antimeridian the box covers every longitude
+	if maxLat < 90 && minLat > -90 {
+		lngDelta := latDelta / math.Cos(geo.Latitude*math.Pi/180)
 
-func (repo MerchantRepository) Logout(ctx context.Context, merchantID int64) (bool, error) {
+		if geo.Longitude-lngDelta >= -180 && geo.Longitude+lngDelta <= 180 {
+			minLng, maxLng = geo.Longitude-lngDelta, geo.Longitude+lngDelta
+		}
+	}
 
-	query := `DELETE FROM token WHERE merchant_id = ?;`
+	query := `
+		SELECT m.id, m.name, m.category, m.email, m.facebook, m.instagram, m.website, m.rating_avg, m.rating_count, m.created_at, m.updated_at,
+			b.id, (? * 2 * ASIN(SQRT(
+				POWER(SIN(RADIANS(b.latitude - ?) / 2), 2) +
+				COS(RADIANS(?)) * COS(RADIANS(b.latitude)) * POWER(SIN(RADIANS(b.longitude - ?) / 2), 2)
+			))) AS distance
+		FROM branch b INNER JOIN merchant m on m.id = b.merchant_id
+		WHERE b.latitude BETWEEN ? AND ? AND b.longitude BETWEEN ? AND ?
+			AND m.verified_at IS NOT NULL AND m.status = 'approved' AND m.deleted_at IS NULL
+			AND (? = '' OR m.category = ? OR m.category IN (SELECT c.category FROM category c WHERE c.parent = ?))
+		HAVING distance <= ?
+		ORDER BY distance ASC, b.id ASC LIMIT ?;`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
-
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	defer stmt.Close()
 
-	_, err = stmt.Exec(merchantID)
+	rows, err := stmt.QueryContext(
+		ctx,
+		earthRadius,
+		geo.Latitude,
+		geo.Latitude,
+		geo.Longitude,
+		math.Max(minLat, -90),
+		math.Min(maxLat, 90),
+		minLng,
+		maxLng,
+		geo.Category,
+		geo.Category,
+		geo.Category,
+		geo.Radius,
+		geo.Limit,
+	)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	return true, nil
-}
+	defer rows.Close()
 
-func (repo MerchantRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	results := make([]entities.NearbyBranch, 0)
+	merchantIDs := make([]int64, 0)
 
-	query := `SELECT EXISTS(SELECT 1 FROM merchant WHERE id = ?);`
+	for rows.Next() {
+		result := entities.NearbyBranch{}
 
-	stmt, err := repo.db.PrepareContext(ctx, query)
+		err := rows.Scan(
+			&result.Merchant.ID,
+			&result.Merchant.Name,
+			&result.Merchant.Category,
+			&result.Merchant.Email,
+			&result.Merchant.Facebook,
+			&result.Merchant.Instagram,
+			&result.Merchant.Website,
+			&result.Merchant.Rating,
+			&result.Merchant.RatingCount,
+			&result.Merchant.CreatedAt,
+			&result.Merchant.UpdatedAt,
+			&result.Branch.ID,
+			&result.Distance,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		results = append(results, result)
+		merchantIDs = append(merchantIDs, result.Merchant.ID)
+	}
+
+	branches, err := loadBranches(ctx, repo.db, merchantIDs, false)
+	if err != nil {
+		return nil, err
+	}
+
+	logos, err := loadImages(ctx, repo.db, merchantIDs, entities.ImageLogo)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].Merchant.Logo = latestImage(logos[results[i].Merchant.ID])
+
+		for _, branch := range branches[results[i].Merchant.ID] {
+			if branch.ID == results[i].Branch.ID {
+				results[i].Branch = branch
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func (repo MerchantRepository) Create(ctx context.Context, merchant entities.Merchant) (int64, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO merchant (category, name, email, password, country, facebook, instagram, website) VALUES (?, ?, ?, ?, ?, ?, ?, ?);`,
+		merchant.Category,
+		merchant.Name,
+		merchant.Email,
+		merchant.Password,
+		merchant.Country,
+		merchant.Facebook,
+		merchant.Instagram,
+		merchant.Website,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	// every merchant starts with one branch so queues always have a home
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO branch (merchant_id, name, country) VALUES (?, ?, ?);`,
+		id,
+		entities.BranchDefaultName,
+		merchant.Country,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo MerchantRepository) Update(ctx context.Context, merchant entities.Merchant) (bool, error) {
+
+	query := `
+		UPDATE merchant SET name = ?, category = ?, country = ?, facebook = ?, instagram = ?, website = ?
+		WHERE id = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(
+		ctx,
+		merchant.Name,
+		merchant.Category,
+		merchant.Country,
+		merchant.Facebook,
+		merchant.Instagram,
+		merchant.Website,
+		merchant.ID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repo MerchantRepository) GetPassword(ctx context.Context, id int64) (string, error) {
+
+	query := `SELECT password FROM merchant WHERE id = ? AND deleted_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+
+	defer stmt.Close()
+
+	var password string
+
+	err = stmt.QueryRowContext(ctx, id).Scan(&password)
+
+	if err == sql.ErrNoRows {
+		return "", errors.New("there are no such merchant exists")
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return password, nil
+}
+
+// UpdatePassword changes the password and signs out every session except
+// the one given in keepToken.
+func (repo MerchantRepository) UpdatePassword(ctx context.Context, id int64, password string, keepToken string) (bool, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `UPDATE merchant SET password = ? WHERE id = ?;`, password, id)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM token WHERE merchant_id = ? AND auth_token <> ?;`, id, keepToken)
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// MarkVerified records that the merchant confirmed its email address.
+// Merchants that are verified already or deleted are left alone.
+func (repo MerchantRepository) MarkVerified(ctx context.Context, id int64) (bool, error) {
+
+	query := `UPDATE merchant SET verified_at = CURRENT_TIMESTAMP WHERE id = ? AND verified_at IS NULL AND deleted_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetByStatus lists merchants in a moderation status, oldest change first,
+// whether or not they verified their email.
+func (repo MerchantRepository) GetByStatus(ctx context.Context, status string, paginator entities.Paginator) ([]entities.Merchant, error) {
+	offset := (paginator.Page - 1) * paginator.Size
+
+	query := `
+		SELECT id, name, category, email, country, facebook, instagram, website, verified_at,
+			status, status_reason, status_changed_at, created_at, updated_at
+		FROM merchant WHERE status = ? AND deleted_at IS NULL ORDER BY COALESCE(status_changed_at, created_at) ASC, id ASC LIMIT ? OFFSET ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, status, paginator.Size, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	merchantes := make([]entities.Merchant, 0)
+
+	for rows.Next() {
+		merchant := entities.Merchant{}
+
+		var verifiedAt, statusAt sql.NullTime
+
+		err := rows.Scan(
+			&merchant.ID,
+			&merchant.Name,
+			&merchant.Category,
+			&merchant.Email,
+			&merchant.Country,
+			&merchant.Facebook,
+			&merchant.Instagram,
+			&merchant.Website,
+			&verifiedAt,
+			&merchant.Status,
+			&merchant.StatusReason,
+			&statusAt,
+			&merchant.CreatedAt,
+			&merchant.UpdatedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if verifiedAt.Valid {
+			merchant.VerifiedAt = &verifiedAt.Time
+		}
+
+		if statusAt.Valid {
+			merchant.StatusAt = &statusAt.Time
+		}
+
+		merchantes = append(merchantes, merchant)
+	}
+
+	return merchantes, nil
+}
+
+func (repo MerchantRepository) SetStatus(ctx context.Context, id int64, status string, reason string) (bool, error) {
+
+	query := `UPDATE merchant SET status = ?, status_reason = ?, status_changed_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, status, reason, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 0 {
+		return false, errors.New("there are no such merchant exists")
+	}
+
+	return true, nil
+}
+
+// RehashPassword replaces a stored password with a stronger hash of the same
+// password. Nothing is written when the password changed in the meantime.
+func (repo MerchantRepository) RehashPassword(ctx context.Context, id int64, old string, hash string) (bool, error) {
+
+	query := `UPDATE merchant SET password = ? WHERE id = ? AND password = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, hash, id, old)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (repo MerchantRepository) UpdateEmail(ctx context.Context, id int64, email string) (bool, error) {
+
+	// deleted merchants release their address; restoring one whose address
+	// was taken in the meantime is refused
+	query := `SELECT EXISTS(SELECT 1 FROM merchant WHERE email = ? AND id <> ? AND deleted_at IS NULL);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	var exists bool
+
+	err = stmt.QueryRowContext(ctx, email, id).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	if exists {
+		return false, errors.New("email is already in use")
+	}
+
+	query = `UPDATE merchant SET email = ? WHERE id = ?;`
+
+	stmt, err = repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, email, id)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repo MerchantRepository) CreateVerification(ctx context.Context, verification entities.MerchantVerification) (int64, error) {
+
+	query := `
+		INSERT INTO merchant_verification (merchant_id, purpose, email, token_hash, expires_at)
+		VALUES (?, ?, ?, ?, ?);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(
+		ctx,
+		verification.MerchantID,
+		verification.Purpose,
+		verification.Email,
+		verification.TokenHash,
+		verification.ExpiresAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo MerchantRepository) GetVerification(ctx context.Context, purpose string, tokenHash string) (*entities.MerchantVerification, error) {
+
+	query := `
+		SELECT id, merchant_id, purpose, email, token_hash, expires_at, used_at, created_at
+		FROM merchant_verification WHERE purpose = ? AND token_hash = ?;`
+
+	return repo.verification(ctx, query, purpose, tokenHash)
+}
+
+func (repo MerchantRepository) GetLatestVerification(ctx context.Context, merchantID int64, purpose string) (*entities.MerchantVerification, error) {
+
+	query := `
+		SELECT id, merchant_id, purpose, email, token_hash, expires_at, used_at, created_at
+		FROM merchant_verification WHERE merchant_id = ? AND purpose = ?
+		ORDER BY id DESC LIMIT 1;`
+
+	return repo.verification(ctx, query, merchantID, purpose)
+}
+
+func (repo MerchantRepository) verification(ctx context.Context, query string, args ...interface{}) (*entities.MerchantVerification, error) {
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	verification := entities.MerchantVerification{}
+
+	var usedAt sql.NullTime
+
+	err = stmt.QueryRowContext(ctx, args...).Scan(
+		&verification.ID,
+		&verification.MerchantID,
+		&verification.Purpose,
+		&verification.Email,
+		&verification.TokenHash,
+		&verification.ExpiresAt,
+		&usedAt,
+		&verification.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		verification.UsedAt = &usedAt.Time
+	}
+
+	return &verification, nil
+}
+
+// RevokeVerifications invalidates every outstanding link of the purpose.
+func (repo MerchantRepository) RevokeVerifications(ctx context.Context, merchantID int64, purpose string) (bool, error) {
+
+	query := `
+		UPDATE merchant_verification SET used_at = CURRENT_TIMESTAMP
+		WHERE merchant_id = ? AND purpose = ? AND used_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, merchantID, purpose)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ConsumeVerification marks the verification used. It reports false when
+// it was already used, so a token cannot be redeemed twice concurrently.
+func (repo MerchantRepository) ConsumeVerification(ctx context.Context, id int64) (bool, error) {
+
+	query := `UPDATE merchant_verification SET used_at = CURRENT_TIMESTAMP WHERE id = ? AND used_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// GetByEmail returns the merchant including its stored password, so the
+// password can be verified by the caller.
+func (repo MerchantRepository) GetByEmail(ctx context.Context, email string) (*entities.Merchant, error) {
+	merchant := entities.Merchant{}
+
+	query := `
+	SELECT id, name, category, email, password, facebook, instagram, website, verified_at, created_at, updated_at
+	FROM merchant WHERE email = ? AND deleted_at IS NULL;
+	`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, email)
+
+	var verifiedAt sql.NullTime
+
+	err = row.Scan(
+		&merchant.ID,
+		&merchant.Name,
+		&merchant.Category,
+		&merchant.Email,
+		&merchant.Password,
+		&merchant.Facebook,
+		&merchant.Instagram,
+		&merchant.Website,
+		&verifiedAt,
+		&merchant.CreatedAt,
+		&merchant.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if verifiedAt.Valid {
+		merchant.VerifiedAt = &verifiedAt.Time
+	}
+
+	return &merchant, nil
+}
+
+func (repo MerchantRepository) CreateToken(ctx context.Context, merchantID int64, token string) (string, error) {
+
+	query := `INSERT INTO token (merchant_id, auth_token) VALUES (?, ?);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, merchantID, token)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = result.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// ValidateToken resolves a token to the staff member it was issued to.
+// Tokens without a staff member belong to the owner.
+func (repo MerchantRepository) ValidateToken(ctx context.Context, token string) (entities.Staff, error) {
+
+	query := `
+		SELECT t.merchant_id, COALESCE(s.id, 0), COALESCE(s.name, m.name), COALESCE(s.email, m.email), COALESCE(s.role, 'owner')
+		FROM token t
+		INNER JOIN merchant m on t.merchant_id = m.id
+		LEFT JOIN staff s on t.staff_id = s.id
+		WHERE t.auth_token = ? AND m.deleted_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return entities.Staff{}, err
+	}
+
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, token)
+
+	staff := entities.Staff{}
+
+	err = row.Scan(
+		&staff.MerchantID,
+		&staff.ID,
+		&staff.Name,
+		&staff.Email,
+		&staff.Role,
+	)
+
+	if err == sql.ErrNoRows {
+		return entities.Staff{}, errors.New("token is not valid")
+	}
+
+	if err != nil {
+		return entities.Staff{}, err
+	}
+
+	return staff, nil
+}
+
+func (repo MerchantRepository) CreateDisplayKey(ctx context.Context, merchantID int64, key string) (entities.DisplayKey, error) {
+
+	query := `INSERT INTO display_key (merchant_id, display_key) VALUES (?, ?);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return entities.DisplayKey{}, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, merchantID, key)
+	if err != nil {
+		return entities.DisplayKey{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return entities.DisplayKey{}, err
+	}
+
+	displayKey := entities.DisplayKey{
+		ID:         id,
+		MerchantID: merchantID,
+		Key:        key,
+		CreatedAt:  time.Now(),
+	}
+
+	return displayKey, nil
+}
+
+func (repo MerchantRepository) GetDisplayKeys(ctx context.Context, merchantID int64) ([]entities.DisplayKey, error) {
+
+	query := `
+		SELECT id, merchant_id, display_key, created_at, revoked_at
+		FROM display_key WHERE merchant_id = ? ORDER BY id ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := make([]entities.DisplayKey, 0)
+
+	for rows.Next() {
+		key := entities.DisplayKey{}
+
+		var revokedAt sql.NullTime
+
+		err := rows.Scan(
+			&key.ID,
+			&key.MerchantID,
+			&key.Key,
+			&key.CreatedAt,
+			&revokedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if revokedAt.Valid {
+			key.RevokedAt = &revokedAt.Time
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (repo MerchantRepository) RevokeDisplayKey(ctx context.Context, merchantID int64, keyID int64) (bool, error) {
+
+	query := `UPDATE display_key SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND merchant_id = ? AND revoked_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, keyID, merchantID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 0 {
+		return false, errors.New("there are no such display key")
+	}
+
+	return true, nil
+}
+
+func (repo MerchantRepository) ValidateDisplayKey(ctx context.Context, key string) (int64, error) {
+
+	query := `
+		SELECT d.merchant_id FROM display_key d INNER JOIN merchant m on m.id = d.merchant_id
+		WHERE d.display_key = ? AND d.revoked_at IS NULL AND m.deleted_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	var merchantID int64
+
+	err = stmt.QueryRowContext(ctx, key).Scan(&merchantID)
+
+	if err == sql.ErrNoRows {
+		return 0, errors.New("display key is not valid")
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return merchantID, nil
+}
+
+// Logout signs the staff member out on every device. Sessions of the rest
+// of the team are kept.
+func (repo MerchantRepository) Logout(ctx context.Context, staff entities.Staff) (bool, error) {
+
+	query := `DELETE FROM token WHERE merchant_id = ? AND staff_id IS NULL;`
+	args := []interface{}{staff.MerchantID}
+
+	if staff.ID != 0 {
+		query = `DELETE FROM token WHERE staff_id = ?;`
+		args = []interface{}{staff.ID}
+	}
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repo MerchantRepository) Delete(ctx context.Context, id int64) (bool, error) {
+
+	query := `SELECT EXISTS(SELECT 1 FROM merchant WHERE id = ? AND deleted_at IS NULL);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
 
 	if err != nil {
 		return false, err
@@ -444,20 +1509,280 @@ func (repo MerchantRepository) Delete(ctx context.Context, id int64) (bool, erro
 		return false, errors.New("there are no such merchant exists")
 	}
 
-	query = `DELETE FROM merchant WHERE id = ?;`
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
 
-	stmt, err = repo.db.PrepareContext(ctx, query)
+	// the merchant is only marked deleted so it can be restored; its
+	// sessions end right away
+	_, err = tx.ExecContext(ctx, `UPDATE merchant SET deleted_at = CURRENT_TIMESTAMP, updated_at = updated_at WHERE id = ?;`, id)
+	if err != nil {
+		return false, err
+	}
+
+	// staff go with the merchant so their emails are free to sign up again
+	_, err = tx.ExecContext(ctx, `
+		UPDATE staff s INNER JOIN merchant m on m.id = s.merchant_id
+		SET s.deleted_at = m.deleted_at, s.updated_at = s.updated_at
+		WHERE m.id = ?;`, id)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM token WHERE merchant_id = ?;`, id)
+	if err != nil {
+		return false, err
+	}
 
+	err = tx.Commit()
 	if err != nil {
 		return false, err
 	}
 
+	return true, nil
+}
+
+// GetDeleted lists deleted merchants that have not been purged yet, most
+// recently deleted first.
+func (repo MerchantRepository) GetDeleted(ctx context.Context, paginator entities.Paginator) ([]entities.Merchant, error) {
+	offset := (paginator.Page - 1) * paginator.Size
+
+	query := `
+		SELECT id, name, category, email, country, verified_at, status, deleted_at, created_at, updated_at
+		FROM merchant WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC LIMIT ? OFFSET ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, paginator.Size, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	merchantes := make([]entities.Merchant, 0)
+
+	for rows.Next() {
+		merchant := entities.Merchant{}
+
+		var verifiedAt, deletedAt sql.NullTime
+
+		err := rows.Scan(
+			&merchant.ID,
+			&merchant.Name,
+			&merchant.Category,
+			&merchant.Email,
+			&merchant.Country,
+			&verifiedAt,
+			&merchant.Status,
+			&deletedAt,
+			&merchant.CreatedAt,
+			&merchant.UpdatedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if verifiedAt.Valid {
+			merchant.VerifiedAt = &verifiedAt.Time
+		}
+
+		if deletedAt.Valid {
+			merchant.DeletedAt = &deletedAt.Time
+		}
+
+		merchantes = append(merchantes, merchant)
+	}
+
+	return merchantes, nil
+}
+
+// GetDeletedByEmail returns a deleted merchant including its stored
+// password, so the owner can prove who they are before restoring it.
+func (repo MerchantRepository) GetDeletedByEmail(ctx context.Context, email string) (*entities.Merchant, error) {
+	merchant := entities.Merchant{}
+
+	query := `
+		SELECT id, name, email, password, deleted_at FROM merchant
+		WHERE email = ? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC LIMIT 1;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
 	defer stmt.Close()
 
-	_, err = stmt.Exec(id)
+	var deletedAt time.Time
+
+	err = stmt.QueryRowContext(ctx, email).Scan(
+		&merchant.ID,
+		&merchant.Name,
+		&merchant.Email,
+		&merchant.Password,
+		&deletedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	merchant.DeletedAt = &deletedAt
+
+	return &merchant, nil
+}
+
+// Restore undoes the deletion of a merchant deleted after since, together
+// with its staff. Deleted merchants and staff release their emails, so the
+// restore is refused when another account has taken one of them since.
+func (repo MerchantRepository) Restore(ctx context.Context, id int64, since time.Time) (bool, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	var email string
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT email FROM merchant
+		WHERE id = ? AND deleted_at IS NOT NULL AND deleted_at > ? FOR UPDATE;`, id, since).Scan(&email)
+
+	if err == sql.ErrNoRows {
+		return false, errors.New("there are no such deleted merchant within the restore window")
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	var taken bool
+
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM merchant WHERE email = ? AND deleted_at IS NULL);`, email).Scan(&taken)
+	if err != nil {
+		return false, err
+	}
+
+	if taken {
+		return false, errors.New("another merchant has signed up with this email since it was deleted")
+	}
+
+	var staffEmail string
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT s.email FROM staff s
+		WHERE s.merchant_id = ? AND EXISTS(SELECT 1 FROM staff o WHERE o.email = s.email AND o.deleted_at IS NULL)
+		LIMIT 1;`, id).Scan(&staffEmail)
+
+	if err == nil {
+		return false, fmt.Errorf("staff email %s has been taken by another account since the merchant was deleted", staffEmail)
+	}
+
+	if err != sql.ErrNoRows {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE merchant SET deleted_at = NULL, updated_at = updated_at WHERE id = ?;`, id)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE staff SET deleted_at = NULL, updated_at = updated_at WHERE merchant_id = ?;`, id)
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit()
 	if err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
+
+// GetPurgeable returns the ids of merchants deleted before the given time.
+func (repo MerchantRepository) GetPurgeable(ctx context.Context, before time.Time) ([]int64, error) {
+
+	query := `SELECT id FROM merchant WHERE deleted_at IS NOT NULL AND deleted_at <= ? ORDER BY id ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+
+	for rows.Next() {
+		var id int64
+
+		err := rows.Scan(&id)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// Purge removes a deleted merchant for good together with the reservations
+// on its queues. Queues, branches, staff, images and the other merchant
+// data follow through their foreign keys.
+func (repo MerchantRepository) Purge(ctx context.Context, id int64) (bool, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE rs FROM reserved_slots rs INNER JOIN queue q on rs.queue_id = q.id WHERE q.merchant_id = ?;`, id)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM merchant WHERE id = ? AND deleted_at IS NOT NULL;`, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
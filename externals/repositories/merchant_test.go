@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"fmt"
+	"no-q-solution/domain/entities"
+	"testing"
+)
+
+func TestRankMerchants(t *testing.T) {
+
+	// more loose matches than the old 500 row candidate cap, with the best
+	// match holding the highest id
+	loose := make([]entities.Merchant, 0, 601)
+
+	for i := 1; i <= 600; i++ {
+		loose = append(loose, entities.Merchant{ID: int64(i), Name: fmt.Sprintf("Dent Repairs %d", i), Category: "Garage", Rating: 5})
+	}
+
+	loose = append(loose, entities.Merchant{ID: 601, Name: "City Dentist", Category: "Health", Rating: 1})
+
+	tests := []struct {
+		name       string
+		input      string
+		candidates []entities.Merchant
+		wantIDs    []int64
+	}{
+		{
+			name:       "best match beyond the first ids",
+			input:      "dentist",
+			candidates: loose,
+			wantIDs:    []int64{601},
+		},
+		{
+			name:  "score, then rating, then id",
+			input: "dental",
+			candidates: []entities.Merchant{
+				{ID: 1, Name: "Smile Dental", Category: "Health", Rating: 3},
+				{ID: 2, Name: "Dental Care", Category: "Health", Rating: 4},
+				{ID: 3, Name: "Dental Studio", Category: "Health", Rating: 4},
+				{ID: 4, Name: "Bakery", Category: "Dental Clinics", Rating: 5},
+			},
+			wantIDs: []int64{2, 3, 4, 1},
+		},
+		{
+			name:  "typo",
+			input: "dentsit",
+			candidates: []entities.Merchant{
+				{ID: 1, Name: "Bakery", Category: "Food"},
+				{ID: 2, Name: "City Dentist", Category: "Health"},
+			},
+			wantIDs: []int64{2},
+		},
+		{
+			name:       "nothing above the threshold",
+			input:      "xyz",
+			candidates: []entities.Merchant{{ID: 1, Name: "Bakery", Category: "Food"}},
+			wantIDs:    []int64{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ranked := rankMerchants(test.input, test.candidates)
+
+			if len(ranked) < len(test.wantIDs) {
+				t.Fatalf("rankMerchants() returned %d merchants, want at least %d", len(ranked), len(test.wantIDs))
+			}
+
+			for i, id := range test.wantIDs {
+				if ranked[i].ID != id {
+					t.Errorf("rankMerchants()[%d] = %d, want %d", i, ranked[i].ID, id)
+				}
+			}
+
+			if len(test.wantIDs) == 0 && len(ranked) != 0 {
+				t.Errorf("rankMerchants() = %d merchants, want none", len(ranked))
+			}
+		})
+	}
+}
@@ -0,0 +1,392 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"time"
+)
+
+type NoShowRepository struct {
+	db *sql.DB
+}
+
+func NewNoShowRepository(db *sql.DB) interfaces.NoShowRepository {
+	repo := &NoShowRepository{
+		db: db,
+	}
+
+	return repo
+}
+
+// GetPolicy returns the merchant's policy, or a disabled default policy when
+// the merchant has not configured one.
+func (repo NoShowRepository) GetPolicy(ctx context.Context, merchantID int64) (entities.NoShowPolicy, error) {
+
+	query := `
+		SELECT merchant_id, enabled, max_no_shows, window_days, block_days, auto_detect, grace_minutes
+		FROM no_show_policy WHERE merchant_id = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return entities.NoShowPolicy{}, err
+	}
+
+	defer stmt.Close()
+
+	policy := entities.NoShowPolicy{}
+
+	err = stmt.QueryRowContext(ctx, merchantID).Scan(
+		&policy.MerchantID,
+		&policy.Enabled,
+		&policy.MaxNoShows,
+		&policy.WindowDays,
+		&policy.BlockDays,
+		&policy.AutoDetect,
+		&policy.GraceMinutes,
+	)
+
+	if err == sql.ErrNoRows {
+		policy = entities.NoShowPolicy{
+			MerchantID:   merchantID,
+			MaxNoShows:   3,
+			WindowDays:   60,
+			BlockDays:    30,
+			GraceMinutes: 15,
+		}
+
+		return policy, nil
+	}
+
+	if err != nil {
+		return entities.NoShowPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+func (repo NoShowRepository) SavePolicy(ctx context.Context, policy entities.NoShowPolicy) (bool, error) {
+
+	// auto_detect_since is assigned first so it still sees the old
+	// auto_detect value; only slots ending after it are detected.
+	query := `
+		INSERT INTO no_show_policy (merchant_id, enabled, max_no_shows, window_days, block_days, auto_detect, grace_minutes, auto_detect_since)
+		VALUES (?, ?, ?, ?, ?, ?, ?, IF(?, CURRENT_TIMESTAMP, NULL))
+		ON DUPLICATE KEY UPDATE
+			auto_detect_since = IF(VALUES(auto_detect) = 1 AND auto_detect = 0, CURRENT_TIMESTAMP, auto_detect_since),
+			enabled = VALUES(enabled),
+			max_no_shows = VALUES(max_no_shows),
+			window_days = VALUES(window_days),
+			block_days = VALUES(block_days),
+			auto_detect = VALUES(auto_detect),
+			grace_minutes = VALUES(grace_minutes);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(
+		ctx,
+		policy.MerchantID,
+		policy.Enabled,
+		policy.MaxNoShows,
+		policy.WindowDays,
+		policy.BlockDays,
+		policy.AutoDetect,
+		policy.GraceMinutes,
+		policy.AutoDetect,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repo NoShowRepository) GetMerchantIDByQueue(ctx context.Context, queueID int64) (int64, error) {
+
+	query := `SELECT merchant_id FROM queue WHERE id = ? AND deleted_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	var merchantID int64
+
+	err = stmt.QueryRowContext(ctx, queueID).Scan(&merchantID)
+
+	if err == sql.ErrNoRows {
+		return 0, errors.New("there are no such queue exists")
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return merchantID, nil
+}
+
+func (repo NoShowRepository) MarkNoShow(ctx context.Context, tokenNo int64, at time.Time) (bool, error) {
+
+	query := `UPDATE reserved_slots SET no_show_at = ? WHERE token_no = ? AND arrived_at IS NULL AND no_show_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, at, tokenNo)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 0 {
+		return false, errors.New("reservation already checked in or marked")
+	}
+
+	return true, nil
+}
+
+// MarkMissedSlots flags reservations that ended more than the grace period
+// ago without a check-in, for merchants that turned on auto detection.
+func (repo NoShowRepository) MarkMissedSlots(ctx context.Context, now time.Time) (int64, error) {
+
+	query := `
+		UPDATE reserved_slots rs
+		INNER JOIN queue q on rs.queue_id = q.id
+		INNER JOIN merchant m on q.merchant_id = m.id
+		INNER JOIN no_show_policy p on q.merchant_id = p.merchant_id
+		SET rs.no_show_at = ?
+		WHERE p.auto_detect = 1 AND rs.arrived_at IS NULL AND rs.no_show_at IS NULL
+		AND q.deleted_at IS NULL AND m.deleted_at IS NULL
+		AND rs.end_time >= p.auto_detect_since
+		AND rs.end_time < ? - INTERVAL p.grace_minutes MINUTE;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, now, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (repo NoShowRepository) GetNoShowTimes(ctx context.Context, merchantID int64, phone string, since time.Time) ([]time.Time, error) {
+
+	query := `
+		SELECT rs.start_time
+		FROM reserved_slots rs
+		INNER JOIN user u on rs.reserved_by = u.id
+		INNER JOIN queue q on rs.queue_id = q.id
+		WHERE q.merchant_id = ? AND q.deleted_at IS NULL AND u.phone = ? AND rs.no_show_at IS NOT NULL AND rs.start_time >= ?
+		ORDER BY rs.start_time ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, merchantID, phone, since)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	times := make([]time.Time, 0)
+
+	for rows.Next() {
+		var startTime time.Time
+
+		err := rows.Scan(&startTime)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		times = append(times, startTime)
+	}
+
+	return times, nil
+}
+
+func (repo NoShowRepository) GetRestrictions(ctx context.Context, merchantID int64) ([]entities.CustomerRestriction, error) {
+
+	query := `
+		SELECT id, merchant_id, phone, mode, until, reason, created_at
+		FROM customer_restriction WHERE merchant_id = ? ORDER BY id DESC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	restrictions := make([]entities.CustomerRestriction, 0)
+
+	for rows.Next() {
+		restriction := entities.CustomerRestriction{}
+
+		var until sql.NullTime
+
+		err := rows.Scan(
+			&restriction.ID,
+			&restriction.MerchantID,
+			&restriction.Phone,
+			&restriction.Mode,
+			&until,
+			&restriction.Reason,
+			&restriction.CreatedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if until.Valid {
+			restriction.Until = &until.Time
+		}
+
+		restrictions = append(restrictions, restriction)
+	}
+
+	return restrictions, nil
+}
+
+// GetActiveRestriction returns the newest manual override for the phone that
+// has not expired yet.
+func (repo NoShowRepository) GetActiveRestriction(ctx context.Context, merchantID int64, phone string, now time.Time) (*entities.CustomerRestriction, error) {
+
+	query := `
+		SELECT id, merchant_id, phone, mode, until, reason, created_at
+		FROM customer_restriction
+		WHERE merchant_id = ? AND phone = ? AND (until IS NULL OR until > ?)
+		ORDER BY id DESC LIMIT 1;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	restriction := entities.CustomerRestriction{}
+
+	var until sql.NullTime
+
+	err = stmt.QueryRowContext(ctx, merchantID, phone, now).Scan(
+		&restriction.ID,
+		&restriction.MerchantID,
+		&restriction.Phone,
+		&restriction.Mode,
+		&until,
+		&restriction.Reason,
+		&restriction.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if until.Valid {
+		restriction.Until = &until.Time
+	}
+
+	return &restriction, nil
+}
+
+func (repo NoShowRepository) CreateRestriction(ctx context.Context, restriction entities.CustomerRestriction) (int64, error) {
+
+	query := `INSERT INTO customer_restriction (merchant_id, phone, mode, until, reason) VALUES (?, ?, ?, ?, ?);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(
+		ctx,
+		restriction.MerchantID,
+		restriction.Phone,
+		restriction.Mode,
+		restriction.Until,
+		restriction.Reason,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo NoShowRepository) DeleteRestriction(ctx context.Context, merchantID int64, id int64) (bool, error) {
+
+	query := `DELETE FROM customer_restriction WHERE id = ? AND merchant_id = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, id, merchantID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 0 {
+		return false, errors.New("there are no such restriction")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,771 @@
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"strings"
+	"time"
+)
+
+type PrivacyRepository struct {
+	db *sql.DB
+}
+
+func NewPrivacyRepository(db *sql.DB) interfaces.PrivacyRepository {
+	repo := &PrivacyRepository{
+		db: db,
+	}
+
+	return repo
+}
+
+func (repo PrivacyRepository) FindUsers(ctx context.Context, phone string, email string) ([]entities.User, error) {
+
+	query := `
+		SELECT id, name, phone, email, created_at FROM user
+		WHERE erased_at IS NULL AND ((? != '' AND phone = ?) OR (? != '' AND email = ?))
+		ORDER BY id ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, phone, phone, email, email)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	users := make([]entities.User, 0)
+
+	for rows.Next() {
+		user := entities.User{}
+
+		var userEmail sql.NullString
+
+		err := rows.Scan(
+			&user.ID,
+			&user.Name,
+			&user.Phone,
+			&userEmail,
+			&user.CreatedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		user.Email = userEmail.String
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// GetBookings includes bookings at deleted queues and merchants, since the
+// export has to cover everything still stored about the customer.
+func (repo PrivacyRepository) GetBookings(ctx context.Context, userIDs []int64) ([]entities.Booking, error) {
+
+	bookings := make([]entities.Booking, 0)
+
+	if len(userIDs) == 0 {
+		return bookings, nil
+	}
+
+	query := `
+		SELECT rs.token_no, rs.queue_id, q.name, m.id, m.name, rs.start_time, rs.end_time, rs.arrived_at, rs.called_at, rs.no_show_at, rs.created_at
+		FROM reserved_slots rs
+		INNER JOIN queue q on rs.queue_id = q.id
+		INNER JOIN merchant m on q.merchant_id = m.id
+		WHERE rs.reserved_by IN (` + placeholders(len(userIDs)) + `)
+		ORDER BY rs.start_time ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, int64Args(userIDs)...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		booking := entities.Booking{}
+
+		var arrivedAt, calledAt, noShowAt sql.NullTime
+
+		err := rows.Scan(
+			&booking.TokenNo,
+			&booking.QueueID,
+			&booking.QueueName,
+			&booking.MerchantID,
+			&booking.MerchantName,
+			&booking.StartTime,
+			&booking.EndTime,
+			&arrivedAt,
+			&calledAt,
+			&noShowAt,
+			&booking.CreatedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if arrivedAt.Valid {
+			booking.ArrivedAt = &arrivedAt.Time
+		}
+
+		if calledAt.Valid {
+			booking.CalledAt = &calledAt.Time
+		}
+
+		if noShowAt.Valid {
+			booking.NoShowAt = &noShowAt.Time
+		}
+
+		bookings = append(bookings, booking)
+	}
+
+	return bookings, nil
+}
+
+func (repo PrivacyRepository) GetOTPRequests(ctx context.Context, phones []string) ([]entities.OTP, error) {
+
+	otps := make([]entities.OTP, 0)
+
+	if len(phones) == 0 {
+		return otps, nil
+	}
+
+	query := `
+		SELECT id, phone, attempts, expires_at, consumed_at, created_at
+		FROM customer_otp WHERE phone IN (` + placeholders(len(phones)) + `) ORDER BY id ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, stringArgs(phones)...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		otp := entities.OTP{}
+
+		var consumedAt sql.NullTime
+
+		err := rows.Scan(
+			&otp.ID,
+			&otp.Phone,
+			&otp.Attempts,
+			&otp.ExpiresAt,
+			&consumedAt,
+			&otp.CreatedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if consumedAt.Valid {
+			otp.ConsumedAt = &consumedAt.Time
+		}
+
+		otps = append(otps, otp)
+	}
+
+	return otps, nil
+}
+
+func (repo PrivacyRepository) GetCustomerSessions(ctx context.Context, userIDs []int64) ([]entities.Session, error) {
+
+	if len(userIDs) == 0 {
+		return make([]entities.Session, 0), nil
+	}
+
+	query := `SELECT created_at FROM customer_token WHERE user_id IN (` + placeholders(len(userIDs)) + `) ORDER BY token_id ASC;`
+
+	return repo.sessions(ctx, query, int64Args(userIDs)...)
+}
+
+// GetRestrictions returns the booking overrides merchants have set on the
+// given phone numbers.
+func (repo PrivacyRepository) GetRestrictions(ctx context.Context, phones []string) ([]entities.CustomerRestriction, error) {
+
+	restrictions := make([]entities.CustomerRestriction, 0)
+
+	if len(phones) == 0 {
+		return restrictions, nil
+	}
+
+	query := `
+		SELECT id, merchant_id, phone, mode, until, reason, created_at
+		FROM customer_restriction WHERE phone IN (` + placeholders(len(phones)) + `) ORDER BY id ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, stringArgs(phones)...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		restriction := entities.CustomerRestriction{}
+
+		var until sql.NullTime
+
+		err := rows.Scan(
+			&restriction.ID,
+			&restriction.MerchantID,
+			&restriction.Phone,
+			&restriction.Mode,
+			&until,
+			&restriction.Reason,
+			&restriction.CreatedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if until.Valid {
+			restriction.Until = &until.Time
+		}
+
+		restrictions = append(restrictions, restriction)
+	}
+
+	return restrictions, nil
+}
+
+// GetReviews returns the reviews the given users wrote, whatever their
+// moderation status.
+func (repo PrivacyRepository) GetReviews(ctx context.Context, userIDs []int64) ([]entities.Review, error) {
+
+	reviews := make([]entities.Review, 0)
+
+	if len(userIDs) == 0 {
+		return reviews, nil
+	}
+
+	query := `
+		SELECT id, token_no, merchant_id, user_id, rating, body, reply, replied_at, status, moderation_reason, created_at
+		FROM review WHERE user_id IN (` + placeholders(len(userIDs)) + `) ORDER BY id ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, int64Args(userIDs)...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		review := entities.Review{}
+
+		var reply sql.NullString
+		var repliedAt sql.NullTime
+
+		err := rows.Scan(
+			&review.ID,
+			&review.TokenNo,
+			&review.MerchantID,
+			&review.UserID,
+			&review.Rating,
+			&review.Body,
+			&reply,
+			&repliedAt,
+			&review.Status,
+			&review.ModerationReason,
+			&review.CreatedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		review.Reply = reply.String
+
+		if repliedAt.Valid {
+			review.RepliedAt = &repliedAt.Time
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	return reviews, nil
+}
+
+// GetMerchant returns the merchant's profile. Unlike the merchant
+// repository it includes merchants that are deleted but not purged yet,
+// since their data is still stored.
+func (repo PrivacyRepository) GetMerchant(ctx context.Context, merchantID int64) (*entities.Merchant, error) {
+
+	query := `
+		SELECT id, name, category, email, country, facebook, instagram, website, rating_avg, rating_count, verified_at,
+			status, status_reason, status_changed_at, deleted_at, created_at, updated_at
+		FROM merchant WHERE id = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	merchant := entities.Merchant{}
+
+	var verifiedAt, statusAt, deletedAt sql.NullTime
+
+	err = stmt.QueryRowContext(ctx, merchantID).Scan(
+		&merchant.ID,
+		&merchant.Name,
+		&merchant.Category,
+		&merchant.Email,
+		&merchant.Country,
+		&merchant.Facebook,
+		&merchant.Instagram,
+		&merchant.Website,
+		&merchant.Rating,
+		&merchant.RatingCount,
+		&verifiedAt,
+		&merchant.Status,
+		&merchant.StatusReason,
+		&statusAt,
+		&deletedAt,
+		&merchant.CreatedAt,
+		&merchant.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if verifiedAt.Valid {
+		merchant.VerifiedAt = &verifiedAt.Time
+	}
+
+	if statusAt.Valid {
+		merchant.StatusAt = &statusAt.Time
+	}
+
+	if deletedAt.Valid {
+		merchant.DeletedAt = &deletedAt.Time
+	}
+
+	return &merchant, nil
+}
+
+// GetQueues returns all of the merchant's queues, deleted ones included.
+func (repo PrivacyRepository) GetQueues(ctx context.Context, merchantID int64) ([]entities.Queue, error) {
+
+	query := `
+		SELECT id, name, merchant_id, branch_id, intervals, start_time, end_time, is_available, created_at, deleted_at
+		FROM queue WHERE merchant_id = ? ORDER BY id ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	queues := make([]entities.Queue, 0)
+	index := make(map[int64]int)
+
+	for rows.Next() {
+		queue := entities.Queue{}
+
+		var deletedAt sql.NullTime
+
+		err := rows.Scan(
+			&queue.ID,
+			&queue.Name,
+			&queue.MerchantID,
+			&queue.BranchID,
+			&queue.Interval,
+			&queue.StartTime,
+			&queue.EndTime,
+			&queue.IsAvailable,
+			&queue.CreatedAt,
+			&deletedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if deletedAt.Valid {
+			queue.DeletedAt = &deletedAt.Time
+		}
+
+		index[queue.ID] = len(queues)
+		queues = append(queues, queue)
+	}
+
+	if len(queues) == 0 {
+		return queues, nil
+	}
+
+	ids := make([]int64, 0, len(queues))
+
+	for _, queue := range queues {
+		ids = append(ids, queue.ID)
+	}
+
+	dates, err := repo.db.QueryContext(
+		ctx,
+		`SELECT queue_id, date FROM unavailable WHERE queue_id IN (`+placeholders(len(ids))+`) ORDER BY date ASC;`,
+		int64Args(ids)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer dates.Close()
+
+	for dates.Next() {
+		var queueID int64
+		var date time.Time
+
+		err := dates.Scan(&queueID, &date)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		i := index[queueID]
+		queues[i].UnavailableDates = append(queues[i].UnavailableDates, date)
+	}
+
+	return queues, nil
+}
+
+// GetBranches returns the merchant's branches with their opening hours,
+// including those of a deleted merchant.
+func (repo PrivacyRepository) GetBranches(ctx context.Context, merchantID int64) ([]entities.Branch, error) {
+
+	branches, err := loadBranches(ctx, repo.db, []int64{merchantID}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return branches[merchantID], nil
+}
+
+// GetStaff returns the merchant's staff accounts without their passwords.
+func (repo PrivacyRepository) GetStaff(ctx context.Context, merchantID int64) ([]entities.Staff, error) {
+
+	query := `
+		SELECT id, merchant_id, name, email, role, created_at, updated_at
+		FROM staff WHERE merchant_id = ? ORDER BY id ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	members := make([]entities.Staff, 0)
+
+	for rows.Next() {
+		staff := entities.Staff{}
+
+		err := rows.Scan(
+			&staff.ID,
+			&staff.MerchantID,
+			&staff.Name,
+			&staff.Email,
+			&staff.Role,
+			&staff.CreatedAt,
+			&staff.UpdatedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		members = append(members, staff)
+	}
+
+	return members, nil
+}
+
+// GetImages returns the merchant's logos and gallery images.
+func (repo PrivacyRepository) GetImages(ctx context.Context, merchantID int64) ([]entities.MerchantImage, error) {
+
+	images := make([]entities.MerchantImage, 0)
+
+	for _, kind := range []string{entities.ImageLogo, entities.ImageGallery} {
+		byMerchant, err := loadImages(ctx, repo.db, []int64{merchantID}, kind)
+		if err != nil {
+			return nil, err
+		}
+
+		images = append(images, byMerchant[merchantID]...)
+	}
+
+	return images, nil
+}
+
+func (repo PrivacyRepository) GetMerchantSessions(ctx context.Context, merchantID int64) ([]entities.Session, error) {
+
+	query := `SELECT created_at FROM token WHERE merchant_id = ? ORDER BY token_id ASC;`
+
+	return repo.sessions(ctx, query, merchantID)
+}
+
+func (repo PrivacyRepository) sessions(ctx context.Context, query string, args ...interface{}) ([]entities.Session, error) {
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	sessions := make([]entities.Session, 0)
+
+	for rows.Next() {
+		session := entities.Session{}
+
+		err := rows.Scan(&session.CreatedAt)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// EraseUsers blanks the personal fields of the given users but keeps the
+// rows, so reservations still count towards merchant statistics. Review
+// texts are blanked while their ratings stay in the merchant's average.
+// One-time codes and merchant restrictions on their phone numbers are
+// deleted.
+func (repo PrivacyRepository) EraseUsers(ctx context.Context, userIDs []int64, phones []string) (bool, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	if len(userIDs) != 0 {
+		ids := int64Args(userIDs)
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM customer_token WHERE user_id IN (`+placeholders(len(ids))+`);`, ids...)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE user SET name = '', phone = '', email = '', erased_at = CURRENT_TIMESTAMP WHERE id IN (`+placeholders(len(ids))+`);`, ids...)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE review SET body = '', updated_at = updated_at WHERE user_id IN (`+placeholders(len(ids))+`);`, ids...)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if len(phones) != 0 {
+		_, err = tx.ExecContext(ctx, `DELETE FROM customer_otp WHERE phone IN (`+placeholders(len(phones))+`);`, stringArgs(phones)...)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM customer_restriction WHERE phone IN (`+placeholders(len(phones))+`);`, stringArgs(phones)...)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// EraseMerchant removes the merchant together with the reservations on its
+// queues. Tokens, queues, unavailable dates and display keys follow through
+// their foreign keys.
+func (repo PrivacyRepository) EraseMerchant(ctx context.Context, merchantID int64) (bool, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	queries := []string{
+		`DELETE FROM token WHERE merchant_id = ?;`,
+		`DELETE FROM display_key WHERE merchant_id = ?;`,
+		`DELETE rs FROM reserved_slots rs INNER JOIN queue q on rs.queue_id = q.id WHERE q.merchant_id = ?;`,
+		`DELETE FROM merchant WHERE id = ?;`,
+	}
+
+	for _, query := range queries {
+		_, err = tx.ExecContext(ctx, query, merchantID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Audit records a privacy request. Phone and email are stored hashed so the
+// audit trail does not keep the data it is meant to erase.
+func (repo PrivacyRepository) Audit(ctx context.Context, audit entities.PrivacyAudit) (int64, error) {
+
+	query := `
+		INSERT INTO privacy_audit (action, subject_phone_hash, subject_email_hash, subject_merchant_id, requested_by, affected)
+		VALUES (?, ?, ?, ?, ?, ?);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(
+		ctx,
+		audit.Action,
+		hashSubject(audit.Subject.Phone),
+		hashSubject(audit.Subject.Email),
+		audit.Subject.MerchantID,
+		audit.RequestedBy,
+		audit.Affected,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func hashSubject(value string) string {
+
+	if len(value) == 0 {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(value))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func placeholders(n int) string {
+
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func int64Args(values []int64) []interface{} {
+
+	args := make([]interface{}, 0, len(values))
+
+	for _, value := range values {
+		args = append(args, value)
+	}
+
+	return args
+}
+
+func stringArgs(values []string) []interface{} {
+
+	args := make([]interface{}, 0, len(values))
+
+	for _, value := range values {
+		args = append(args, value)
+	}
+
+	return args
+}
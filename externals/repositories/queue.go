@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
 	"no-q-solution/domain/entities"
 	"no-q-solution/domain/interfaces"
@@ -26,8 +27,11 @@ func NewQueueRepository(db *sql.DB) interfaces.QueueRepository {
 func (repo QueueRepository) GetByMerchant(ctx context.Context, merchantID int64) ([]entities.Queue, error) {
 
 	query := `
-		SELECT q.id, q.name, q.merchant_id, q.intervals, q.start_time, q.end_time, q.is_available, GROUP_CONCAT(ua.date) as unavailable_dates, q.created_at 
-		FROM queue q LEFT JOIN unavailable ua on q.id = ua.queue_id WHERE merchant_id = ? 
+		SELECT q.id, q.name, q.merchant_id, q.branch_id, q.intervals, q.start_time, q.end_time, q.is_available, GROUP_CONCAT(ua.date) as unavailable_dates, q.created_at 
+		FROM queue q
+		INNER JOIN merchant m on q.merchant_id = m.id
+		LEFT JOIN unavailable ua on q.id = ua.queue_id
+		WHERE q.merchant_id = ? AND q.deleted_at IS NULL AND m.deleted_at IS NULL
 		GROUP BY q.id;`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
@@ -55,6 +59,7 @@ func (repo QueueRepository) GetByMerchant(ctx context.Context, merchantID int64)
 			&queue.ID,
 			&queue.Name,
 			&queue.MerchantID,
+			&queue.BranchID,
 			&queue.Interval,
 			&queue.StartTime,
 			&queue.EndTime,
@@ -92,7 +97,7 @@ func (repo QueueRepository) GetByMerchant(ctx context.Context, merchantID int64)
 
 func (repo QueueRepository) GetSlotsByDate(ctx context.Context, queueID int64, date time.Time) (entities.Queue, error) {
 
-	query := `SELECT EXISTS(SELECT 1 FROM queue WHERE id = ?);`
+	query := `SELECT EXISTS(SELECT 1 FROM queue q INNER JOIN merchant m on q.merchant_id = m.id WHERE q.id = ? AND q.deleted_at IS NULL AND m.deleted_at IS NULL);`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
 
@@ -121,7 +126,7 @@ func (repo QueueRepository) GetSlotsByDate(ctx context.Context, queueID int64, d
 	queue.ID = queueID
 
 	query = `
-		SELECT rs.token_no, rs.queue_id, rs.start_time, rs.end_time, rs.created_at, u.id, u.name, u.phone, u.email  
+		SELECT rs.token_no, rs.queue_id, rs.start_time, rs.end_time, rs.arrived_at, rs.called_at, rs.no_show_at, rs.created_at, u.id, u.name, u.phone, u.email  
 		FROM reserved_slots rs INNER JOIN user u on rs.reserved_by = u.id
 		WHERE rs.queue_id = ? AND DATE(rs.start_time) = DATE(?);`
 
@@ -146,11 +151,16 @@ func (repo QueueRepository) GetSlotsByDate(ctx context.Context, queueID int64, d
 		reservedSlot := entities.ReservedSlots{}
 		user := entities.User{}
 
+		var arrivedAt, calledAt, noShowAt sql.NullTime
+
 		err := rows.Scan(
 			&reservedSlot.TokenNo,
 			&reservedSlot.QueueID,
 			&reservedSlot.StartTime,
 			&reservedSlot.EndTime,
+			&arrivedAt,
+			&calledAt,
+			&noShowAt,
 			&reservedSlot.CreatedAt,
 			&user.ID,
 			&user.Name,
@@ -163,6 +173,18 @@ func (repo QueueRepository) GetSlotsByDate(ctx context.Context, queueID int64, d
 			continue
 		}
 
+		if arrivedAt.Valid {
+			reservedSlot.ArrivedAt = &arrivedAt.Time
+		}
+
+		if calledAt.Valid {
+			reservedSlot.CalledAt = &calledAt.Time
+		}
+
+		if noShowAt.Valid {
+			reservedSlot.NoShowAt = &noShowAt.Time
+		}
+
 		reservedSlot.ReservedBy = user
 
 		reservedSlots = append(reservedSlots, reservedSlot)
@@ -175,7 +197,7 @@ func (repo QueueRepository) GetSlotsByDate(ctx context.Context, queueID int64, d
 
 func (repo QueueRepository) MakeItAvailable(ctx context.Context, merchantID int64, queueID int64) (bool, error) {
 
-	query := `UPDATE queue SET is_available = 1 WHERE id = ? AND merchant_id = ?;`
+	query := `UPDATE queue SET is_available = 1 WHERE id = ? AND merchant_id = ? AND deleted_at IS NULL;`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -194,7 +216,7 @@ func (repo QueueRepository) MakeItAvailable(ctx context.Context, merchantID int6
 
 func (repo QueueRepository) MakeItUnAvailable(ctx context.Context, merchantID int64, queueID int64) (bool, error) {
 
-	query := `UPDATE queue SET is_available = 0 WHERE id = ? AND merchant_id = ?;`
+	query := `UPDATE queue SET is_available = 0 WHERE id = ? AND merchant_id = ? AND deleted_at IS NULL;`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -262,9 +284,11 @@ func (repo QueueRepository) MakeDatesAvailable(ctx context.Context, queueID int6
 	return true, nil
 }
 
+// Create places the queue in the given branch, or in the merchant's first
+// branch when none is given.
 func (repo QueueRepository) Create(ctx context.Context, queue entities.Queue) (entities.Queue, error) {
 
-	query := `INSERT INTO queue (merchant_id, name, intervals, start_time, end_time) VALUES (?, ?, ?, ?, ?);`
+	query := `SELECT id FROM branch WHERE merchant_id = ? AND (? = 0 OR id = ?) ORDER BY id ASC LIMIT 1;`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -273,9 +297,29 @@ func (repo QueueRepository) Create(ctx context.Context, queue entities.Queue) (e
 
 	defer stmt.Close()
 
+	err = stmt.QueryRowContext(ctx, queue.MerchantID, queue.BranchID, queue.BranchID).Scan(&queue.BranchID)
+
+	if err == sql.ErrNoRows {
+		return entities.Queue{}, errors.New("there are no such branch")
+	}
+
+	if err != nil {
+		return entities.Queue{}, err
+	}
+
+	query = `INSERT INTO queue (merchant_id, branch_id, name, intervals, start_time, end_time) VALUES (?, ?, ?, ?, ?, ?);`
+
+	stmt, err = repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return entities.Queue{}, err
+	}
+
+	defer stmt.Close()
+
 	result, err := stmt.ExecContext(
 		ctx,
 		queue.MerchantID,
+		queue.BranchID,
 		queue.Name,
 		queue.Interval,
 		queue.StartTime,
@@ -297,7 +341,7 @@ func (repo QueueRepository) Create(ctx context.Context, queue entities.Queue) (e
 
 func (repo QueueRepository) ReserveSlot(ctx context.Context, reserve entities.ReservedSlots) (entities.ReservedSlots, error) {
 
-	query := `SELECT EXISTS(SELECT 1 FROM queue WHERE id = ?);`
+	query := `SELECT EXISTS(SELECT 1 FROM queue q INNER JOIN merchant m on q.merchant_id = m.id WHERE q.id = ? AND q.deleted_at IS NULL AND m.deleted_at IS NULL);`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
 
@@ -344,7 +388,7 @@ func (repo QueueRepository) ReserveSlot(ctx context.Context, reserve entities.Re
 		return entities.ReservedSlots{}, errors.New("the slot already reserved")
 	}
 
-	query = `INSERT INTO user (name, phone, email) VALUES (?, ?, ?);`
+	query = `INSERT INTO reserved_slots (queue_id, start_time, end_time, reserved_by) VALUES (?, ?, ?, ?);`
 
 	stmt, err = repo.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -355,9 +399,10 @@ func (repo QueueRepository) ReserveSlot(ctx context.Context, reserve entities.Re
 
 	result, err := stmt.ExecContext(
 		ctx,
-		reserve.ReservedBy.Name,
-		reserve.ReservedBy.Phone,
-		reserve.ReservedBy.Email,
+		reserve.QueueID,
+		reserve.StartTime,
+		reserve.EndTime,
+		reserve.ReservedBy.ID,
 	)
 	if err != nil {
 		return entities.ReservedSlots{}, err
@@ -368,41 +413,175 @@ func (repo QueueRepository) ReserveSlot(ctx context.Context, reserve entities.Re
 		return entities.ReservedSlots{}, err
 	}
 
-	reserve.ReservedBy.ID = id
+	reserve.TokenNo = id
 
-	query = `INSERT INTO reserved_slots (queue_id, start_time, end_time, reserved_by) VALUES (?, ?, ?, ?);`
+	return reserve, nil
+}
 
-	stmt, err = repo.db.PrepareContext(ctx, query)
+func (repo QueueRepository) GetReservation(ctx context.Context, tokenNo int64) (*entities.ReservedSlots, error) {
+
+	query := `
+		SELECT rs.token_no, rs.queue_id, rs.start_time, rs.end_time, rs.arrived_at, rs.called_at, rs.no_show_at, rs.created_at, u.id, u.name, u.phone, u.email
+		FROM reserved_slots rs
+		INNER JOIN user u on rs.reserved_by = u.id
+		INNER JOIN queue q on rs.queue_id = q.id
+		INNER JOIN merchant m on q.merchant_id = m.id
+		WHERE rs.token_no = ? AND q.deleted_at IS NULL AND m.deleted_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
 	if err != nil {
-		return entities.ReservedSlots{}, err
+		return nil, err
 	}
 
 	defer stmt.Close()
 
-	result, err = stmt.ExecContext(
-		ctx,
-		reserve.QueueID,
-		reserve.StartTime,
-		reserve.EndTime,
-		id,
+	reservedSlot := entities.ReservedSlots{}
+	user := entities.User{}
+
+	var arrivedAt, calledAt, noShowAt sql.NullTime
+	var email sql.NullString
+
+	err = stmt.QueryRowContext(ctx, tokenNo).Scan(
+		&reservedSlot.TokenNo,
+		&reservedSlot.QueueID,
+		&reservedSlot.StartTime,
+		&reservedSlot.EndTime,
+		&arrivedAt,
+		&calledAt,
+		&noShowAt,
+		&reservedSlot.CreatedAt,
+		&user.ID,
+		&user.Name,
+		&user.Phone,
+		&email,
 	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
 	if err != nil {
-		return entities.ReservedSlots{}, err
+		return nil, err
+	}
+
+	if arrivedAt.Valid {
+		reservedSlot.ArrivedAt = &arrivedAt.Time
+	}
+
+	if calledAt.Valid {
+		reservedSlot.CalledAt = &calledAt.Time
+	}
+
+	if noShowAt.Valid {
+		reservedSlot.NoShowAt = &noShowAt.Time
 	}
 
-	id, err = result.LastInsertId()
+	user.Email = email.String
+
+	reservedSlot.ReservedBy = user
+
+	return &reservedSlot, nil
+}
+
+func (repo QueueRepository) CheckIn(ctx context.Context, tokenNo int64, arrivedAt time.Time) (bool, error) {
+
+	query := `UPDATE reserved_slots SET arrived_at = ? WHERE token_no = ? AND arrived_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
 	if err != nil {
-		return entities.ReservedSlots{}, err
+		return false, err
 	}
 
-	reserve.TokenNo = id
+	defer stmt.Close()
 
-	return reserve, nil
+	result, err := stmt.ExecContext(ctx, arrivedAt, tokenNo)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 0 {
+		return false, errors.New("reservation already checked in")
+	}
+
+	return true, nil
+}
+
+func (repo QueueRepository) RescheduleSlot(ctx context.Context, tokenNo int64, startTime time.Time, endTime time.Time) (bool, error) {
+
+	query := `
+        SELECT COUNT(*)
+        FROM reserved_slots
+        WHERE (? <= end_time) AND (? >= start_time) AND token_no != ?
+        AND queue_id = (SELECT queue_id FROM reserved_slots WHERE token_no = ?)
+    `
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	var count int
+
+	err = stmt.QueryRowContext(ctx, startTime, endTime, tokenNo, tokenNo).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	if count > 0 {
+		return false, errors.New("the slot already reserved")
+	}
+
+	// a recorded no-show stays, the no-show policy counts it
+	query = `UPDATE reserved_slots SET start_time = ?, end_time = ?, arrived_at = NULL, called_at = NULL WHERE token_no = ?;`
+
+	stmt, err = repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, startTime, endTime, tokenNo)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repo QueueRepository) CallToken(ctx context.Context, tokenNo int64, calledAt time.Time) (bool, error) {
+
+	query := `UPDATE reserved_slots SET called_at = ? WHERE token_no = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, calledAt, tokenNo)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
 }
 
 func (repo QueueRepository) UnReserveSlot(ctx context.Context, tokenNo int64) (bool, error) {
 
-	query := `SELECT EXISTS(SELECT 1 FROM reserved_slots WHERE token_no = ?);`
+	query := `
+		SELECT EXISTS(SELECT 1 FROM reserved_slots rs
+			INNER JOIN queue q on rs.queue_id = q.id
+			INNER JOIN merchant m on q.merchant_id = m.id
+			WHERE rs.token_no = ? AND q.deleted_at IS NULL AND m.deleted_at IS NULL);`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
 
@@ -426,17 +605,29 @@ func (repo QueueRepository) UnReserveSlot(ctx context.Context, tokenNo int64) (b
 		return false, errors.New("there are no such token no")
 	}
 
-	query = `DELETE FROM reserved_slots WHERE token_no = ?;`
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
 
-	stmt, err = repo.db.PrepareContext(ctx, query)
+	defer tx.Rollback()
 
+	// the cancellation is kept without the customer for analytics
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO cancelled_slots (token_no, queue_id, start_time, reserved_at)
+		SELECT token_no, queue_id, start_time, created_at FROM reserved_slots WHERE token_no = ?;`,
+		tokenNo,
+	)
 	if err != nil {
 		return false, err
 	}
 
-	defer stmt.Close()
+	_, err = tx.ExecContext(ctx, `DELETE FROM reserved_slots WHERE token_no = ?;`, tokenNo)
+	if err != nil {
+		return false, err
+	}
 
-	_, err = stmt.Exec(tokenNo)
+	err = tx.Commit()
 	if err != nil {
 		return false, err
 	}
@@ -444,9 +635,11 @@ func (repo QueueRepository) UnReserveSlot(ctx context.Context, tokenNo int64) (b
 	return true, nil
 }
 
+// Delete marks a queue deleted. Its reservations and closed dates stay until
+// the queue is purged, so it can be restored within the restore window.
 func (repo QueueRepository) Delete(ctx context.Context, merchantID int64, queueID int64) (bool, error) {
 
-	query := `DELETE FROM queue WHERE id = ? AND merchant_id = ?;`
+	query := `UPDATE queue SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND merchant_id = ? AND deleted_at IS NULL;`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
 
@@ -466,7 +659,7 @@ func (repo QueueRepository) Delete(ctx context.Context, merchantID int64, queueI
 
 func (repo QueueRepository) IsQueueBelongsToMerchant(ctx context.Context, merchantID int64, queueID int64) (bool, error) {
 
-	query := `SELECT EXISTS(SELECT 1 FROM queue WHERE id = ? AND merchant_id =?);`
+	query := `SELECT EXISTS(SELECT 1 FROM queue WHERE id = ? AND merchant_id =? AND deleted_at IS NULL);`
 
 	stmt, err := repo.db.PrepareContext(ctx, query)
 
@@ -492,3 +685,201 @@ func (repo QueueRepository) IsQueueBelongsToMerchant(ctx context.Context, mercha
 
 	return exists, nil
 }
+
+// GetMerchantCountry returns the country national phone numbers are read in
+// when booking on the queue.
+func (repo QueueRepository) GetMerchantCountry(ctx context.Context, queueID int64) (string, error) {
+
+	query := `SELECT m.country FROM queue q INNER JOIN merchant m on q.merchant_id = m.id WHERE q.id = ? AND q.deleted_at IS NULL AND m.deleted_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+
+	defer stmt.Close()
+
+	var country string
+
+	err = stmt.QueryRowContext(ctx, queueID).Scan(&country)
+
+	if err == sql.ErrNoRows {
+		return "", errors.New("there are no such queue")
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return country, nil
+}
+
+func (repo QueueRepository) GetMerchantStatus(ctx context.Context, queueID int64) (string, error) {
+
+	query := `SELECT m.status FROM queue q INNER JOIN merchant m on q.merchant_id = m.id WHERE q.id = ? AND q.deleted_at IS NULL AND m.deleted_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+
+	defer stmt.Close()
+
+	var status string
+
+	err = stmt.QueryRowContext(ctx, queueID).Scan(&status)
+
+	if err == sql.ErrNoRows {
+		return "", errors.New("there are no such queue")
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return status, nil
+}
+
+// GetDeleted lists a merchant's deleted queues that have not been purged
+// yet, most recently deleted first.
+func (repo QueueRepository) GetDeleted(ctx context.Context, merchantID int64) ([]entities.Queue, error) {
+
+	query := `
+		SELECT id, name, merchant_id, branch_id, intervals, start_time, end_time, is_available, created_at, deleted_at
+		FROM queue WHERE merchant_id = ? AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	queues := make([]entities.Queue, 0)
+
+	for rows.Next() {
+		queue := entities.Queue{}
+
+		var deletedAt time.Time
+
+		err := rows.Scan(
+			&queue.ID,
+			&queue.Name,
+			&queue.MerchantID,
+			&queue.BranchID,
+			&queue.Interval,
+			&queue.StartTime,
+			&queue.EndTime,
+			&queue.IsAvailable,
+			&queue.CreatedAt,
+			&deletedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		queue.DeletedAt = &deletedAt
+
+		queues = append(queues, queue)
+	}
+
+	return queues, nil
+}
+
+// Restore undoes the deletion of a queue deleted after since. Deleted queues
+// release their name, so the restore is refused when another queue of the
+// merchant has taken it since.
+func (repo QueueRepository) Restore(ctx context.Context, merchantID int64, queueID int64, since time.Time) (bool, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	var name string
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT name FROM queue
+		WHERE id = ? AND merchant_id = ? AND deleted_at IS NOT NULL AND deleted_at > ? FOR UPDATE;`, queueID, merchantID, since).Scan(&name)
+
+	if err == sql.ErrNoRows {
+		return false, errors.New("there are no such deleted queue within the restore window")
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	var taken bool
+
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM queue WHERE merchant_id = ? AND name = ? AND deleted_at IS NULL);`, merchantID, name).Scan(&taken)
+	if err != nil {
+		return false, err
+	}
+
+	if taken {
+		return false, fmt.Errorf("another queue has been named %s since this one was deleted", name)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE queue SET deleted_at = NULL WHERE id = ?;`, queueID)
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Purge removes queues deleted before the given time for good, together
+// with their reservations. Closed dates follow through their foreign key.
+func (repo QueueRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE rs FROM reserved_slots rs INNER JOIN queue q on rs.queue_id = q.id WHERE q.deleted_at IS NOT NULL AND q.deleted_at <= ?;`,
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM queue WHERE deleted_at IS NOT NULL AND deleted_at <= ?;`, before)
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,329 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+)
+
+type ReviewRepository struct {
+	db *sql.DB
+}
+
+func NewReviewRepository(db *sql.DB) interfaces.ReviewRepository {
+	repo := &ReviewRepository{
+		db: db,
+	}
+
+	return repo
+}
+
+// refreshRating recomputes the merchant's aggregate from every review that
+// has not been hidden by a moderator.
+const refreshRating = `
+	UPDATE merchant m SET
+		rating_avg = (SELECT COALESCE(AVG(r.rating), 0) FROM review r WHERE r.merchant_id = m.id AND r.status <> 'hidden'),
+		rating_count = (SELECT COUNT(*) FROM review r WHERE r.merchant_id = m.id AND r.status <> 'hidden')
+	WHERE m.id = ?;`
+
+const reviewColumns = `
+	r.id, r.token_no, r.merchant_id, r.user_id, u.name, r.rating, r.body, r.reply, r.replied_at,
+	r.status, r.moderation_reason, r.created_at, r.updated_at`
+
+func (repo ReviewRepository) Create(ctx context.Context, review entities.Review) (int64, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var exists bool
+
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM review WHERE token_no = ?);`, review.TokenNo).Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+
+	if exists {
+		return 0, errors.New("this visit has already been reviewed")
+	}
+
+	var merchantID int64
+
+	err = tx.QueryRowContext(
+		ctx,
+		`
+		SELECT q.merchant_id FROM reserved_slots rs
+		INNER JOIN queue q on rs.queue_id = q.id
+		INNER JOIN merchant m on q.merchant_id = m.id
+		WHERE rs.token_no = ? AND q.deleted_at IS NULL AND m.deleted_at IS NULL;`,
+		review.TokenNo,
+	).Scan(&merchantID)
+
+	if err == sql.ErrNoRows {
+		return 0, errors.New("there are no such token no")
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO review (token_no, merchant_id, user_id, rating, body) VALUES (?, ?, ?, ?, ?);`,
+		review.TokenNo,
+		merchantID,
+		review.UserID,
+		review.Rating,
+		review.Body,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, refreshRating, merchantID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo ReviewRepository) GetSingle(ctx context.Context, id int64) (*entities.Review, error) {
+
+	query := `SELECT ` + reviewColumns + `
+		FROM review r INNER JOIN user u on r.user_id = u.id
+		WHERE r.id = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	review, err := scanReview(stmt.QueryRowContext(ctx, id))
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// GetByMerchant lists the reviews customers can see, newest first.
+func (repo ReviewRepository) GetByMerchant(ctx context.Context, merchantID int64, paginator entities.Paginator) ([]entities.Review, error) {
+
+	query := `SELECT ` + reviewColumns + `
+		FROM review r
+		INNER JOIN user u on r.user_id = u.id
+		INNER JOIN merchant m on r.merchant_id = m.id
+		WHERE r.merchant_id = ? AND r.status <> 'hidden' AND m.deleted_at IS NULL
+		ORDER BY r.created_at DESC, r.id DESC LIMIT ? OFFSET ?;`
+
+	offset := (paginator.Page - 1) * paginator.Size
+
+	return repo.queryReviews(ctx, query, merchantID, paginator.Size, offset)
+}
+
+// GetByStatus lists reviews for moderators, oldest first so the queue is
+// worked through in order.
+func (repo ReviewRepository) GetByStatus(ctx context.Context, status string, paginator entities.Paginator) ([]entities.Review, error) {
+
+	query := `SELECT ` + reviewColumns + `
+		FROM review r INNER JOIN user u on r.user_id = u.id
+		WHERE r.status = ?
+		ORDER BY r.updated_at ASC, r.id ASC LIMIT ? OFFSET ?;`
+
+	offset := (paginator.Page - 1) * paginator.Size
+
+	return repo.queryReviews(ctx, query, status, paginator.Size, offset)
+}
+
+func (repo ReviewRepository) Reply(ctx context.Context, merchantID int64, id int64, reply string) (bool, error) {
+
+	query := `UPDATE review SET reply = ?, replied_at = CURRENT_TIMESTAMP WHERE id = ? AND merchant_id = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, reply, id, merchantID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 0 {
+		return false, errors.New("there are no such review")
+	}
+
+	return true, nil
+}
+
+// Flag reports a published review to the moderators. The review stays
+// visible until a moderator decides on it.
+func (repo ReviewRepository) Flag(ctx context.Context, merchantID int64, id int64, reason string) (bool, error) {
+
+	query := `
+		UPDATE review SET status = 'flagged', moderation_reason = ?
+		WHERE id = ? AND merchant_id = ? AND status = 'published';`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, reason, id, merchantID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 0 {
+		return false, errors.New("there are no such published review")
+	}
+
+	return true, nil
+}
+
+func (repo ReviewRepository) SetStatus(ctx context.Context, id int64, status string, reason string) (bool, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	var merchantID int64
+
+	err = tx.QueryRowContext(ctx, `SELECT merchant_id FROM review WHERE id = ? FOR UPDATE;`, id).Scan(&merchantID)
+
+	if err == sql.ErrNoRows {
+		return false, errors.New("there are no such review")
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE review SET status = ?, moderation_reason = ? WHERE id = ?;`, status, reason, id)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, refreshRating, merchantID)
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repo ReviewRepository) queryReviews(ctx context.Context, query string, args ...interface{}) ([]entities.Review, error) {
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	reviews := make([]entities.Review, 0)
+
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	return reviews, nil
+}
+
+type reviewScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReview(row reviewScanner) (entities.Review, error) {
+
+	review := entities.Review{}
+
+	var reply sql.NullString
+	var repliedAt sql.NullTime
+
+	err := row.Scan(
+		&review.ID,
+		&review.TokenNo,
+		&review.MerchantID,
+		&review.UserID,
+		&review.Reviewer,
+		&review.Rating,
+		&review.Body,
+		&reply,
+		&repliedAt,
+		&review.Status,
+		&review.ModerationReason,
+		&review.CreatedAt,
+		&review.UpdatedAt,
+	)
+	if err != nil {
+		return entities.Review{}, err
+	}
+
+	review.Reply = reply.String
+
+	if repliedAt.Valid {
+		review.RepliedAt = &repliedAt.Time
+	}
+
+	return review, nil
+}
@@ -0,0 +1,317 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+)
+
+type StaffRepository struct {
+	db *sql.DB
+}
+
+func NewStaffRepository(db *sql.DB) interfaces.StaffRepository {
+	repo := &StaffRepository{
+		db: db,
+	}
+
+	return repo
+}
+
+func (repo StaffRepository) GetByMerchant(ctx context.Context, merchantID int64) ([]entities.Staff, error) {
+
+	query := `
+		SELECT id, merchant_id, name, email, role, created_at, updated_at
+		FROM staff WHERE merchant_id = ? ORDER BY id ASC;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	members := make([]entities.Staff, 0)
+
+	for rows.Next() {
+		staff := entities.Staff{}
+
+		err := rows.Scan(
+			&staff.ID,
+			&staff.MerchantID,
+			&staff.Name,
+			&staff.Email,
+			&staff.Role,
+			&staff.CreatedAt,
+			&staff.UpdatedAt,
+		)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		members = append(members, staff)
+	}
+
+	return members, nil
+}
+
+func (repo StaffRepository) GetSingle(ctx context.Context, merchantID int64, id int64) (*entities.Staff, error) {
+
+	query := `
+		SELECT id, merchant_id, name, email, role, created_at, updated_at
+		FROM staff WHERE merchant_id = ? AND id = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	staff := entities.Staff{}
+
+	err = stmt.QueryRowContext(ctx, merchantID, id).Scan(
+		&staff.ID,
+		&staff.MerchantID,
+		&staff.Name,
+		&staff.Email,
+		&staff.Role,
+		&staff.CreatedAt,
+		&staff.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &staff, nil
+}
+
+// GetByEmail returns the staff member including the stored password, so it
+// can be verified by the caller.
+func (repo StaffRepository) GetByEmail(ctx context.Context, email string) (*entities.Staff, error) {
+
+	query := `
+		SELECT s.id, s.merchant_id, s.name, s.email, s.password, s.role, s.created_at, s.updated_at
+		FROM staff s INNER JOIN merchant m on m.id = s.merchant_id
+		WHERE s.email = ? AND s.deleted_at IS NULL AND m.deleted_at IS NULL;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	staff := entities.Staff{}
+
+	err = stmt.QueryRowContext(ctx, email).Scan(
+		&staff.ID,
+		&staff.MerchantID,
+		&staff.Name,
+		&staff.Email,
+		&staff.Password,
+		&staff.Role,
+		&staff.CreatedAt,
+		&staff.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &staff, nil
+}
+
+func (repo StaffRepository) Create(ctx context.Context, staff entities.Staff) (int64, error) {
+
+	// staff of deleted merchants release their email
+	query := `SELECT EXISTS(SELECT 1 FROM staff WHERE email = ? AND deleted_at IS NULL);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	var exists bool
+
+	err = stmt.QueryRowContext(ctx, staff.Email).Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+
+	if exists {
+		return 0, errors.New("email is already in use")
+	}
+
+	query = `INSERT INTO staff (merchant_id, name, email, password, role) VALUES (?, ?, ?, ?, ?);`
+
+	stmt, err = repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(
+		ctx,
+		staff.MerchantID,
+		staff.Name,
+		staff.Email,
+		staff.Password,
+		staff.Role,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo StaffRepository) Update(ctx context.Context, staff entities.Staff) (bool, error) {
+
+	query := `UPDATE staff SET name = ?, role = ? WHERE merchant_id = ? AND id = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, staff.Name, staff.Role, staff.MerchantID, staff.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// UpdatePassword changes the password and signs the staff member out on
+// every device.
+func (repo StaffRepository) UpdatePassword(ctx context.Context, id int64, password string) (bool, error) {
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `UPDATE staff SET password = ? WHERE id = ?;`, password, id)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM token WHERE staff_id = ?;`, id)
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repo StaffRepository) RehashPassword(ctx context.Context, id int64, old string, hash string) (bool, error) {
+
+	query := `UPDATE staff SET password = ? WHERE id = ? AND password = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, hash, id, old)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// Delete removes the staff member. Their tokens follow through the foreign
+// key.
+func (repo StaffRepository) Delete(ctx context.Context, merchantID int64, id int64) (bool, error) {
+
+	query := `DELETE FROM staff WHERE merchant_id = ? AND id = ?;`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, merchantID, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 0 {
+		return false, errors.New("there are no such staff member")
+	}
+
+	return true, nil
+}
+
+func (repo StaffRepository) CreateToken(ctx context.Context, staff entities.Staff, token string) (string, error) {
+
+	query := `INSERT INTO token (merchant_id, staff_id, auth_token) VALUES (?, ?, ?);`
+
+	stmt, err := repo.db.PrepareContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, staff.MerchantID, staff.ID, token)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
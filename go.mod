@@ -1,6 +1,6 @@
 module no-q-solution
 
-go 1.19
+go 1.20
 
 require (
 	github.com/go-playground/universal-translator v0.18.1
@@ -8,6 +8,8 @@ require (
 	github.com/google/uuid v1.3.0
 	github.com/gorilla/mux v1.8.0
 	github.com/rs/cors v1.9.0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	golang.org/x/crypto v0.14.0
 	gopkg.in/go-playground/validator.v9 v9.31.0
 	gopkg.in/yaml.v2 v2.4.0
 )
@@ -15,5 +17,6 @@ require (
 require (
 	github.com/go-playground/locales v0.14.1 // indirect
 	github.com/leodido/go-urn v1.2.3 // indirect
+	golang.org/x/sys v0.13.0 // indirect
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 )
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/domain/usecases"
+	"no-q-solution/http/error"
+	"no-q-solution/http/transport/request/decoders"
+	"no-q-solution/http/transport/response"
+	"no-q-solution/utils/container"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type AnalyticsController struct {
+	usecase usecases.AnalyticsUsecase
+	repo    interfaces.MerchantRepository
+}
+
+func NewAnalyticsController(ctr container.Containers) AnalyticsController {
+	ctl := AnalyticsController{
+		usecase: usecases.NewAnalyticsUsecase(ctr.Repositories.Analytics, ctr.Repositories.Queue),
+		repo:    ctr.Repositories.Merchant,
+	}
+
+	return ctl
+}
+
+func (ctl AnalyticsController) GetForMerchant(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionViewAnalytics)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	decoder := decoders.Analytics{
+		From: r.FormValue("from"),
+		To:   r.FormValue("to"),
+	}
+
+	query, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	query.MerchantID = staff.MerchantID
+
+	analytics, err := ctl.usecase.GetForMerchant(ctx, query)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(analytics, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl AnalyticsController) GetForQueue(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionViewAnalytics)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	queueID, err := strconv.ParseInt(vars["queue_id"], 10, 64)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	decoder := decoders.Analytics{
+		From: r.FormValue("from"),
+		To:   r.FormValue("to"),
+	}
+
+	query, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	query.MerchantID = staff.MerchantID
+	query.QueueID = queueID
+
+	analytics, err := ctl.usecase.GetForQueue(ctx, query)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(analytics, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/domain/usecases"
+	"no-q-solution/http/error"
+	"no-q-solution/http/transport/request"
+	"no-q-solution/http/transport/request/decoders"
+	"no-q-solution/http/transport/response"
+	"no-q-solution/http/validators"
+	"no-q-solution/utils/container"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type BranchController struct {
+	usecase   usecases.BranchUsecase
+	validator validators.Validator
+	repo      interfaces.MerchantRepository
+}
+
+func NewBranchController(ctr container.Containers) BranchController {
+	ctl := BranchController{
+		usecase:   usecases.NewBranchUsecase(ctr.Repositories.Branch, ctr.Repositories.Merchant),
+		validator: validators.NewValidator(),
+		repo:      ctr.Repositories.Merchant,
+	}
+
+	return ctl
+}
+
+func (ctl BranchController) GetByMerchant(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+
+	merchantID, err := strconv.ParseInt(vars["merchant_id"], 10, 64)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	branches, err := ctl.usecase.GetByMerchant(ctx, merchantID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(branches, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl BranchController) Create(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageBranches)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	decoder := decoders.Branch{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	branch, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	branch.MerchantID = staff.MerchantID
+
+	created, err := ctl.usecase.Create(ctx, branch)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(created, nil, "true")
+
+	response.Send(w, payload, http.StatusCreated)
+}
+
+func (ctl BranchController) Update(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageBranches)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	branchID, err := strconv.ParseInt(vars["branch_id"], 10, 64)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	decoder := decoders.Branch{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	branch, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	branch.ID = branchID
+	branch.MerchantID = staff.MerchantID
+
+	updated, err := ctl.usecase.Update(ctx, branch)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(updated, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl BranchController) Delete(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageBranches)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	branchID, err := strconv.ParseInt(vars["branch_id"], 10, 64)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	done, err := ctl.usecase.Delete(ctx, staff.MerchantID, branchID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
@@ -0,0 +1,330 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/domain/usecases"
+	"no-q-solution/http/error"
+	"no-q-solution/http/transport/request"
+	"no-q-solution/http/transport/request/decoders"
+	"no-q-solution/http/transport/response"
+	"no-q-solution/http/validators"
+	"no-q-solution/utils/container"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// CategoryController serves the category administration endpoints. Every
+// handler requires the platform admin key.
+type CategoryController struct {
+	usecase   usecases.CategoryUsecase
+	validator validators.Validator
+	admin     interfaces.AdminGuard
+}
+
+func NewCategoryController(ctr container.Containers) CategoryController {
+	ctl := CategoryController{
+		usecase:   usecases.NewCategoryUsecase(ctr.Repositories.Category, ctr.Repositories.Merchant, ctr.Adapters.Suggest),
+		validator: validators.NewValidator(),
+		admin:     ctr.Adapters.Admin,
+	}
+
+	return ctl
+}
+
+func (ctl CategoryController) GetAll(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err := ctl.admin.Validate(authHeader[len("Bearer "):])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	categories, err := ctl.usecase.GetAll(ctx)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(categories, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl CategoryController) Create(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err := ctl.admin.Validate(authHeader[len("Bearer "):])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	decoder := decoders.Category{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	category, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	created, err := ctl.usecase.Create(ctx, category)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(created, nil, "true")
+
+	response.Send(w, payload, http.StatusCreated)
+}
+
+func (ctl CategoryController) Update(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err := ctl.admin.Validate(authHeader[len("Bearer "):])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	name := vars["category"]
+
+	decoder := decoders.CategoryUpdate{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	update, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	category, err := ctl.usecase.Update(ctx, name, update)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(category, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl CategoryController) Reorder(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err := ctl.admin.Validate(authHeader[len("Bearer "):])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	decoder := decoders.CategoryOrder{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	parent, order, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	categories, err := ctl.usecase.Reorder(ctx, parent, order)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(categories, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl CategoryController) Retire(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err := ctl.admin.Validate(authHeader[len("Bearer "):])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	name := vars["category"]
+
+	done, err := ctl.usecase.Retire(ctx, name)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl CategoryController) Restore(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err := ctl.admin.Validate(authHeader[len("Bearer "):])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	name := vars["category"]
+
+	done, err := ctl.usecase.Restore(ctx, name)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
@@ -0,0 +1,447 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/domain/usecases"
+	"no-q-solution/http/error"
+	"no-q-solution/http/transport/request"
+	"no-q-solution/http/transport/request/decoders"
+	"no-q-solution/http/transport/response"
+	"no-q-solution/http/validators"
+	"no-q-solution/utils/container"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type CustomerController struct {
+	usecase   usecases.CustomerUsecase
+	privacy   usecases.PrivacyUsecase
+	validator validators.Validator
+	repo      interfaces.CustomerRepository
+}
+
+func NewCustomerController(ctr container.Containers) CustomerController {
+	ctl := CustomerController{
+		usecase: usecases.NewCustomerUsecase(
+			ctr.Repositories.Customer,
+			ctr.Adapters.SMS,
+			usecases.NewQueuetUsecase(ctr.Repositories.Queue, ctr.Repositories.Customer, ctr.Repositories.NoShow, ctr.Adapters.CheckIn, ctr.Adapters.Hub),
+		),
+		privacy:   usecases.NewPrivacyUsecase(ctr.Repositories.Privacy, ctr.Repositories.Merchant, ctr.Adapters.Blobs),
+		validator: validators.NewValidator(),
+		repo:      ctr.Repositories.Customer,
+	}
+
+	return ctl
+}
+
+func (ctl CustomerController) RequestOTP(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	decoder := decoders.RequestOTP{}
+
+	err := request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	phone, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	done, err := ctl.usecase.RequestOTP(ctx, phone)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusCreated)
+}
+
+func (ctl CustomerController) VerifyOTP(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	decoder := decoders.VerifyOTP{}
+
+	err := request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	phone, code, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token, err := ctl.usecase.VerifyOTP(ctx, phone, code)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(token, nil, "true")
+
+	response.Send(w, payload, http.StatusCreated)
+}
+
+func (ctl CustomerController) Logout(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	user, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	done, err := ctl.usecase.Logout(ctx, user)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl CustomerController) GetReservations(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	user, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	filterDecoder := decoders.BookingFilter{}
+
+	if param := r.FormValue("filter"); len(param) != 0 {
+		err = json.Unmarshal([]byte(param), &filterDecoder)
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	filter, err := filterDecoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	pageDecoder := decoders.Paginator{Page: 1, Size: 10}
+
+	if param := r.FormValue("paginator"); len(param) != 0 {
+		err = json.Unmarshal([]byte(param), &pageDecoder)
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	filter.Paginator, err = pageDecoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	switch mux.Vars(r)["scope"] {
+	case "upcoming":
+		upcoming := true
+		filter.Upcoming = &upcoming
+	case "past":
+		upcoming := false
+		filter.Upcoming = &upcoming
+	}
+
+	reservations, err := ctl.usecase.GetBookings(ctx, user, filter)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(reservations, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl CustomerController) CancelReservation(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	user, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	token_no, err := strconv.Atoi(vars["token_no"])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	done, err := ctl.usecase.CancelReservation(ctx, user, int64(token_no))
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl CustomerController) RebookReservation(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	user, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	token_no, err := strconv.Atoi(vars["token_no"])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	decoder := decoders.Rebook{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	rebook, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := ctl.usecase.RebookReservation(ctx, user, int64(token_no), rebook.StartTime, rebook.EndTime)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(reservation, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl CustomerController) ExportData(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	user, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	subject := entities.PrivacySubject{Phone: user.Phone}
+
+	archive, err := ctl.privacy.Export(ctx, subject, fmt.Sprintf("customer:%d", user.ID))
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(archive, nil, "true")
+
+	w.Header().Set("content-disposition", `attachment; filename="no-q-data-export.json"`)
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl CustomerController) EraseData(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	user, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	subject := entities.PrivacySubject{Phone: user.Phone}
+
+	affected, err := ctl.privacy.Erase(ctx, subject, fmt.Sprintf("customer:%d", user.ID))
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(affected, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"no-q-solution/domain/usecases"
+	"no-q-solution/http/error"
+	"no-q-solution/http/views"
+	"no-q-solution/utils/container"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// displayRefresh is how often, in seconds, the board reloads itself.
+const displayRefresh = 15
+
+type DisplayController struct {
+	usecase usecases.DisplayUsecase
+}
+
+func NewDisplayController(ctr container.Containers) DisplayController {
+	ctl := DisplayController{
+		usecase: usecases.NewDisplayUsecase(ctr.Repositories.Merchant, ctr.Repositories.Queue),
+	}
+
+	return ctl
+}
+
+func (ctl DisplayController) Board(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+
+	var queueID int64
+
+	if id, ok := vars["queue_id"]; ok {
+		queue_id, err := strconv.Atoi(id)
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		queueID = int64(queue_id)
+	}
+
+	board, err := ctl.usecase.GetBoard(ctx, vars["key"], queueID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusNotFound)
+		return
+	}
+
+	var page bytes.Buffer
+
+	err = views.DisplayBoard.Execute(&page, map[string]interface{}{
+		"Board":   board,
+		"Refresh": displayRefresh,
+	})
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "text/html; charset=utf-8")
+	w.Header().Set("cache-control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page.Bytes())
+}
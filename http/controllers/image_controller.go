@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/domain/usecases"
+	"no-q-solution/http/error"
+	"no-q-solution/http/transport/request"
+	"no-q-solution/http/transport/response"
+	"no-q-solution/utils/container"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type ImageController struct {
+	usecase usecases.ImageUsecase
+	repo    interfaces.MerchantRepository
+}
+
+func NewImageController(ctr container.Containers) ImageController {
+	ctl := ImageController{
+		usecase: usecases.NewImageUsecase(ctr.Repositories.Image, ctr.Adapters.Blobs),
+		repo:    ctr.Repositories.Merchant,
+	}
+
+	return ctl
+}
+
+func (ctl ImageController) UploadLogo(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageAccount)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	data, err := request.ReadFile(w, r, "image", usecases.LogoMaxBytes)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	logo, err := ctl.usecase.UploadLogo(ctx, staff.MerchantID, data)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(logo, nil, "true")
+
+	response.Send(w, payload, http.StatusCreated)
+}
+
+func (ctl ImageController) DeleteLogo(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageAccount)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	done, err := ctl.usecase.DeleteLogo(ctx, staff.MerchantID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl ImageController) UploadGalleryImage(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageAccount)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	data, err := request.ReadFile(w, r, "image", usecases.GalleryMaxBytes)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	image, err := ctl.usecase.UploadGalleryImage(ctx, staff.MerchantID, data)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(image, nil, "true")
+
+	response.Send(w, payload, http.StatusCreated)
+}
+
+func (ctl ImageController) DeleteGalleryImage(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageAccount)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	imageID, err := strconv.ParseInt(vars["image_id"], 10, 64)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	done, err := ctl.usecase.DeleteGalleryImage(ctx, staff.MerchantID, imageID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
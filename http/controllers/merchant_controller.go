@@ -3,8 +3,10 @@ package controllers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"no-q-solution/domain/entities"
 	"no-q-solution/domain/interfaces"
 	"no-q-solution/domain/usecases"
 	"no-q-solution/http/error"
@@ -13,6 +15,7 @@ import (
 	"no-q-solution/http/transport/response"
 	"no-q-solution/http/validators"
 	"no-q-solution/utils/container"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -20,15 +23,21 @@ import (
 
 type MerchantController struct {
 	usecase   usecases.MerchantUsecase
+	privacy   usecases.PrivacyUsecase
+	noShow    usecases.NoShowUsecase
 	validator validators.Validator
 	repo      interfaces.MerchantRepository
+	admin     interfaces.AdminGuard
 }
 
 func NewMerchantController(ctr container.Containers) MerchantController {
 	ctl := MerchantController{
-		usecase:   usecases.NewMerchantUsecase(ctr.Repositories.Merchant),
+		usecase:   usecases.NewMerchantUsecase(ctr.Repositories.Merchant, ctr.Adapters.Mailer, ctr.Adapters.Suggest, ctr.Adapters.Blobs),
+		privacy:   usecases.NewPrivacyUsecase(ctr.Repositories.Privacy, ctr.Repositories.Merchant, ctr.Adapters.Blobs),
+		noShow:    usecases.NewNoShowUsecase(ctr.Repositories.NoShow, ctr.Repositories.Queue, ctr.Repositories.Merchant),
 		validator: validators.NewValidator(),
 		repo:      ctr.Repositories.Merchant,
+		admin:     ctr.Adapters.Admin,
 	}
 
 	return ctl
@@ -66,7 +75,21 @@ func (ctl MerchantController) GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	merchants, err := ctl.usecase.GetAll(ctx, paginator)
+	filterDecoder := decoders.MerchantFilter{
+		OpenNow:   r.FormValue("open_now"),
+		FreeToday: r.FormValue("free_today"),
+		FreeOn:    r.FormValue("free_on"),
+	}
+
+	filter, err := filterDecoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	merchants, err := ctl.usecase.GetAll(ctx, paginator, r.FormValue("sort"), filter)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -110,7 +133,21 @@ func (ctl MerchantController) GetByCategory(w http.ResponseWriter, r *http.Reque
 		error.HandleError(w, err, http.StatusBadRequest)
 	}
 
-	merchants, err := ctl.usecase.GetByCategory(ctx, category)
+	filterDecoder := decoders.MerchantFilter{
+		OpenNow:   r.FormValue("open_now"),
+		FreeToday: r.FormValue("free_today"),
+		FreeOn:    r.FormValue("free_on"),
+	}
+
+	filter, err := filterDecoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	merchants, err := ctl.usecase.GetByCategory(ctx, category, r.FormValue("sort"), filter)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -138,7 +175,7 @@ func (ctl MerchantController) GetSingle(w http.ResponseWriter, r *http.Request)
 
 	token := authHeader[len("Bearer "):]
 
-	merchantID, err := ctl.repo.ValidateToken(ctx, token)
+	staff, err := ctl.repo.ValidateToken(ctx, token)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -146,6 +183,16 @@ func (ctl MerchantController) GetSingle(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	err = usecases.CheckPermission(staff, entities.PermissionViewMerchant)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
 	merchant, err := ctl.usecase.GetSingle(ctx, merchantID)
 	if err != nil {
 		log.Println(err.Error())
@@ -167,7 +214,30 @@ func (ctl MerchantController) Search(w http.ResponseWriter, r *http.Request) {
 
 	input := vars["input"]
 
-	merchants, err := ctl.usecase.Search(ctx, input)
+	// the paginator is optional here so existing clients keep working
+	paginator := entities.Paginator{Page: 1, Size: 10}
+
+	if param := r.FormValue("paginator"); len(param) > 0 {
+		pageDecoder := decoders.Paginator{}
+
+		err := json.Unmarshal([]byte(param), &pageDecoder)
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		paginator, err = pageDecoder.Validate()
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	merchants, err := ctl.usecase.Search(ctx, input, paginator)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -180,6 +250,78 @@ func (ctl MerchantController) Search(w http.ResponseWriter, r *http.Request) {
 	response.Send(w, payload, http.StatusOK)
 }
 
+func (ctl MerchantController) Suggest(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	limit := 0
+
+	if param := r.FormValue("limit"); len(param) > 0 {
+		parsed, err := strconv.Atoi(param)
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		limit = parsed
+	}
+
+	suggestions, err := ctl.usecase.Suggest(ctx, r.FormValue("q"), limit)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	payload := response.Encode(suggestions, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) Nearby(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	decoder := decoders.Nearby{
+		Latitude:  r.FormValue("lat"),
+		Longitude: r.FormValue("lng"),
+		Radius:    r.FormValue("radius"),
+		Category:  r.FormValue("category"),
+		Limit:     r.FormValue("limit"),
+	}
+
+	err := ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	geo, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	branches, err := ctl.usecase.Nearby(ctx, geo)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(branches, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
 func (ctl MerchantController) Create(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
@@ -266,7 +408,7 @@ func (ctl MerchantController) Login(w http.ResponseWriter, r *http.Request) {
 	response.Send(w, payload, http.StatusCreated)
 }
 
-func (ctl MerchantController) Logout(w http.ResponseWriter, r *http.Request) {
+func (ctl MerchantController) Update(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
@@ -281,7 +423,7 @@ func (ctl MerchantController) Logout(w http.ResponseWriter, r *http.Request) {
 
 	token := authHeader[len("Bearer "):]
 
-	merchantID, err := ctl.repo.ValidateToken(ctx, token)
+	staff, err := ctl.repo.ValidateToken(ctx, token)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -289,7 +431,105 @@ func (ctl MerchantController) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	done, err := ctl.usecase.Logout(ctx, merchantID)
+	err = usecases.CheckPermission(staff, entities.PermissionManageAccount)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	decoder := decoders.MerchantUpdate{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	update, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	merchant, err := ctl.usecase.Update(ctx, merchantID, token, update)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(merchant, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+
+	done, err := ctl.usecase.ConfirmEmail(ctx, vars["token"])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	decoder := decoders.ForgotPassword{}
+
+	err := request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	email, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	done, err := ctl.usecase.ForgotPassword(ctx, email)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -302,30 +542,99 @@ func (ctl MerchantController) Logout(w http.ResponseWriter, r *http.Request) {
 	response.Send(w, payload, http.StatusOK)
 }
 
-func (ctl MerchantController) Delete(w http.ResponseWriter, r *http.Request) {
+func (ctl MerchantController) ResetPassword(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	authHeader := r.Header.Get("Authorization")
-	if !strings.HasPrefix(authHeader, "Bearer ") {
-		err := errors.New("authorization token not found")
+	decoder := decoders.ResetPassword{}
+
+	err := request.Decode(ctx, r, &decoder)
+	if err != nil {
 		log.Println(err.Error())
 
 		error.HandleError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	token := authHeader[len("Bearer "):]
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
 
-	merchantID, err := ctl.repo.ValidateToken(ctx, token)
+	token, password, err := decoder.Validate()
 	if err != nil {
 		log.Println(err.Error())
 
-		error.HandleError(w, err, http.StatusUnauthorized)
+		error.HandleError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	done, err := ctl.usecase.Delete(ctx, merchantID)
+	done, err := ctl.usecase.ResetPassword(ctx, token, password)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+
+	done, err := ctl.usecase.VerifyEmail(ctx, vars["token"])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) ResendVerification(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	decoder := decoders.ResendVerification{}
+
+	err := request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	email, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	done, err := ctl.usecase.ResendVerification(ctx, email)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -337,3 +646,905 @@ func (ctl MerchantController) Delete(w http.ResponseWriter, r *http.Request) {
 
 	response.Send(w, payload, http.StatusOK)
 }
+
+func (ctl MerchantController) CreateDisplayKey(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageDisplays)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	key, err := ctl.usecase.CreateDisplayKey(ctx, merchantID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(key, nil, "true")
+
+	response.Send(w, payload, http.StatusCreated)
+}
+
+func (ctl MerchantController) GetDisplayKeys(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageDisplays)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	keys, err := ctl.usecase.GetDisplayKeys(ctx, merchantID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(keys, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) RevokeDisplayKey(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageDisplays)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	vars := mux.Vars(r)
+
+	key_id, err := strconv.Atoi(vars["key_id"])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	done, err := ctl.usecase.RevokeDisplayKey(ctx, merchantID, int64(key_id))
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) Logout(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	done, err := ctl.usecase.Logout(ctx, staff)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) Delete(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageAccount)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	done, err := ctl.usecase.Delete(ctx, merchantID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) ExportData(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageAccount)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	subject := entities.PrivacySubject{MerchantID: merchantID}
+
+	archive, err := ctl.privacy.Export(ctx, subject, fmt.Sprintf("merchant:%d", merchantID))
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(archive, nil, "true")
+
+	w.Header().Set("content-disposition", `attachment; filename="no-q-data-export.json"`)
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) EraseData(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageAccount)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	subject := entities.PrivacySubject{MerchantID: merchantID}
+
+	affected, err := ctl.privacy.Erase(ctx, subject, fmt.Sprintf("merchant:%d", merchantID))
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(affected, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) GetNoShowPolicy(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManagePolicies)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	policy, err := ctl.noShow.GetPolicy(ctx, merchantID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(policy, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) SaveNoShowPolicy(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManagePolicies)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	decoder := decoders.NoShowPolicy{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	policy, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	policy.MerchantID = merchantID
+
+	policy, err = ctl.noShow.SavePolicy(ctx, policy)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(policy, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) GetCustomerRestrictions(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManagePolicies)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	restrictions, err := ctl.noShow.GetRestrictions(ctx, merchantID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(restrictions, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl MerchantController) CreateCustomerRestriction(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManagePolicies)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	decoder := decoders.CustomerRestriction{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	restriction, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	restriction.MerchantID = merchantID
+
+	restriction, err = ctl.noShow.CreateRestriction(ctx, restriction)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(restriction, nil, "true")
+
+	response.Send(w, payload, http.StatusCreated)
+}
+
+func (ctl MerchantController) DeleteCustomerRestriction(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManagePolicies)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	vars := mux.Vars(r)
+
+	restriction_id, err := strconv.Atoi(vars["restriction_id"])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	done, err := ctl.noShow.DeleteRestriction(ctx, merchantID, int64(restriction_id))
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+// GetForModeration lists merchants by moderation status for platform admins,
+// pending ones unless a status is given.
+func (ctl MerchantController) GetForModeration(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err := ctl.admin.Validate(authHeader[len("Bearer "):])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	paginator := entities.Paginator{Page: 1, Size: 10}
+
+	if param := r.FormValue("paginator"); len(param) > 0 {
+		pageDecoder := decoders.Paginator{}
+
+		err = json.Unmarshal([]byte(param), &pageDecoder)
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		paginator, err = pageDecoder.Validate()
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	merchants, err := ctl.usecase.GetForModeration(ctx, r.FormValue("status"), paginator)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(merchants, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+// Moderate approves, suspends or rejects a merchant. Platform admins only.
+func (ctl MerchantController) Moderate(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err := ctl.admin.Validate(authHeader[len("Bearer "):])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	merchantID, err := strconv.ParseInt(vars["merchant_id"], 10, 64)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	decoder := decoders.MerchantModeration{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	moderation, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	merchant, err := ctl.usecase.Moderate(ctx, merchantID, moderation.Status, moderation.StatusReason)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(merchant, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+// RestoreAccount lets owners undo the deletion of their account within the
+// restore window, using their sign-in credentials.
+func (ctl MerchantController) RestoreAccount(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	decoder := decoders.Login{}
+
+	err := request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	login, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	merchant, err := ctl.usecase.RestoreAccount(ctx, login)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(merchant, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+// GetDeleted lists deleted merchants that can still be restored. Platform
+// admins only.
+func (ctl MerchantController) GetDeleted(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err := ctl.admin.Validate(authHeader[len("Bearer "):])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	paginator := entities.Paginator{Page: 1, Size: 10}
+
+	if param := r.FormValue("paginator"); len(param) > 0 {
+		pageDecoder := decoders.Paginator{}
+
+		err = json.Unmarshal([]byte(param), &pageDecoder)
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		paginator, err = pageDecoder.Validate()
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	merchants, err := ctl.usecase.GetDeleted(ctx, paginator)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(merchants, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+// Restore undoes the deletion of a merchant within the restore window.
+// Platform admins only.
+func (ctl MerchantController) Restore(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err := ctl.admin.Validate(authHeader[len("Bearer "):])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	merchantID, err := strconv.ParseInt(vars["merchant_id"], 10, 64)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	merchant, err := ctl.usecase.Restore(ctx, merchantID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(merchant, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
@@ -1,10 +1,12 @@
 package controllers
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"no-q-solution/domain/entities"
 	"no-q-solution/domain/interfaces"
 	"no-q-solution/domain/usecases"
 	"no-q-solution/http/error"
@@ -18,17 +20,20 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/skip2/go-qrcode"
 )
 
 type QueueController struct {
 	usecase   usecases.QueuetUsecase
+	noShow    usecases.NoShowUsecase
 	validator validators.Validator
 	repo      interfaces.MerchantRepository
 }
 
 func NewQueueController(ctr container.Containers) QueueController {
 	ctl := QueueController{
-		usecase:   usecases.NewQueuetUsecase(ctr.Repositories.Queue),
+		usecase:   usecases.NewQueuetUsecase(ctr.Repositories.Queue, ctr.Repositories.Customer, ctr.Repositories.NoShow, ctr.Adapters.CheckIn, ctr.Adapters.Hub),
+		noShow:    usecases.NewNoShowUsecase(ctr.Repositories.NoShow, ctr.Repositories.Queue, ctr.Repositories.Merchant),
 		validator: validators.NewValidator(),
 		repo:      ctr.Repositories.Merchant,
 	}
@@ -123,7 +128,7 @@ func (ctl QueueController) MakeItAvailable(w http.ResponseWriter, r *http.Reques
 
 	token := authHeader[len("Bearer "):]
 
-	merchantID, err := ctl.repo.ValidateToken(ctx, token)
+	staff, err := ctl.repo.ValidateToken(ctx, token)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -131,6 +136,16 @@ func (ctl QueueController) MakeItAvailable(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	err = usecases.CheckPermission(staff, entities.PermissionManageQueues)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
 	vars := mux.Vars(r)
 
 	queue_id, err := strconv.Atoi(vars["queue_id"])
@@ -169,7 +184,7 @@ func (ctl QueueController) MakeItUnAvailable(w http.ResponseWriter, r *http.Requ
 
 	token := authHeader[len("Bearer "):]
 
-	merchantID, err := ctl.repo.ValidateToken(ctx, token)
+	staff, err := ctl.repo.ValidateToken(ctx, token)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -177,6 +192,16 @@ func (ctl QueueController) MakeItUnAvailable(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	err = usecases.CheckPermission(staff, entities.PermissionManageQueues)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
 	vars := mux.Vars(r)
 
 	queue_id, err := strconv.Atoi(vars["queue_id"])
@@ -215,7 +240,7 @@ func (ctl QueueController) MakeDatesAvailable(w http.ResponseWriter, r *http.Req
 
 	token := authHeader[len("Bearer "):]
 
-	merchantID, err := ctl.repo.ValidateToken(ctx, token)
+	staff, err := ctl.repo.ValidateToken(ctx, token)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -223,6 +248,16 @@ func (ctl QueueController) MakeDatesAvailable(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	err = usecases.CheckPermission(staff, entities.PermissionManageQueues)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
 	vars := mux.Vars(r)
 
 	queue_id, err := strconv.Atoi(vars["queue_id"])
@@ -287,7 +322,7 @@ func (ctl QueueController) MakeDatesUnAvailable(w http.ResponseWriter, r *http.R
 
 	token := authHeader[len("Bearer "):]
 
-	merchantID, err := ctl.repo.ValidateToken(ctx, token)
+	staff, err := ctl.repo.ValidateToken(ctx, token)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -295,6 +330,16 @@ func (ctl QueueController) MakeDatesUnAvailable(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	err = usecases.CheckPermission(staff, entities.PermissionManageQueues)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
 	vars := mux.Vars(r)
 
 	queue_id, err := strconv.Atoi(vars["queue_id"])
@@ -362,7 +407,7 @@ func (ctl QueueController) Create(w http.ResponseWriter, r *http.Request) {
 
 	token := authHeader[len("Bearer "):]
 
-	merchant_id, err := ctl.repo.ValidateToken(ctx, token)
+	staff, err := ctl.repo.ValidateToken(ctx, token)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -370,6 +415,16 @@ func (ctl QueueController) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = usecases.CheckPermission(staff, entities.PermissionManageQueues)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchant_id := staff.MerchantID
+
 	decoder := decoders.Queue{}
 
 	err = request.Decode(ctx, r, &decoder)
@@ -454,6 +509,282 @@ func (ctl QueueController) ReserveSlot(w http.ResponseWriter, r *http.Request) {
 	response.Send(w, payload, http.StatusCreated)
 }
 
+func (ctl QueueController) CheckInQR(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+
+	code := vars["code"]
+
+	_, err := ctl.usecase.VerifyCheckInCode(ctx, code)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	png, err := qrcode.Encode(code, qrcode.Medium, 256)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("content-type", "image/png")
+	w.WriteHeader(http.StatusOK)
+	w.Write(png)
+}
+
+func (ctl QueueController) CheckIn(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionServeCustomers)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	decoder := decoders.CheckIn{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	code, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := ctl.usecase.CheckIn(ctx, merchantID, code)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(reservation, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl QueueController) CallToken(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionServeCustomers)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	vars := mux.Vars(r)
+
+	token_no, err := strconv.Atoi(vars["token_no"])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := ctl.usecase.CallToken(ctx, merchantID, int64(token_no))
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(reservation, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl QueueController) Events(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+
+	queue_id, err := strconv.Atoi(vars["queue_id"])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	events, unsubscribe := ctl.usecase.Subscribe(ctx, []int64{int64(queue_id)})
+	defer unsubscribe()
+
+	streamEvents(w, r, events, false)
+}
+
+func (ctl QueueController) MerchantEvents(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	// EventSource cannot set headers, so browsers pass the token as a query param.
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") && r.URL.Query().Has("token") {
+		authHeader = "Bearer " + r.URL.Query().Get("token")
+	}
+
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionViewMerchant)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	events, unsubscribe, err := ctl.usecase.SubscribeMerchant(ctx, staff.MerchantID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	defer unsubscribe()
+
+	streamEvents(w, r, events, true)
+}
+
+// streamEvents writes queue events as server-sent events until the client
+// goes away or the hub drops the subscription. Customer details are only
+// sent on private (merchant) streams.
+func streamEvents(w http.ResponseWriter, r *http.Request, events <-chan entities.QueueEvent, private bool) {
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("content-type", "text/event-stream")
+	w.Header().Set("cache-control", "no-cache")
+	w.Header().Set("connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		rc.SetWriteDeadline(time.Now().Add(30 * time.Second))
+
+		if err := rc.Flush(); err != nil {
+			log.Println(err.Error())
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if !private {
+				event.ReservedBy = nil
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Println(err.Error())
+				continue
+			}
+
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+	}
+}
+
 func (ctl QueueController) UnReserveSlot(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
@@ -469,7 +800,7 @@ func (ctl QueueController) UnReserveSlot(w http.ResponseWriter, r *http.Request)
 
 	token := authHeader[len("Bearer "):]
 
-	_, err := ctl.repo.ValidateToken(ctx, token)
+	staff, err := ctl.repo.ValidateToken(ctx, token)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -477,6 +808,14 @@ func (ctl QueueController) UnReserveSlot(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	err = usecases.CheckPermission(staff, entities.PermissionServeCustomers)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
 	vars := mux.Vars(r)
 
 	token_no, err := strconv.Atoi(vars["token_no"])
@@ -487,7 +826,7 @@ func (ctl QueueController) UnReserveSlot(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	id, err := ctl.usecase.UnReserveSlot(ctx, int64(token_no))
+	id, err := ctl.usecase.UnReserveSlot(ctx, staff.MerchantID, int64(token_no))
 	if err != nil {
 		log.Println(err.Error())
 
@@ -515,7 +854,7 @@ func (ctl QueueController) Delete(w http.ResponseWriter, r *http.Request) {
 
 	token := authHeader[len("Bearer "):]
 
-	merchant_id, err := ctl.repo.ValidateToken(ctx, token)
+	staff, err := ctl.repo.ValidateToken(ctx, token)
 	if err != nil {
 		log.Println(err.Error())
 
@@ -523,6 +862,16 @@ func (ctl QueueController) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = usecases.CheckPermission(staff, entities.PermissionManageQueues)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchant_id := staff.MerchantID
+
 	vars := mux.Vars(r)
 
 	queue_id, err := strconv.Atoi(vars["queue_id"])
@@ -545,3 +894,159 @@ func (ctl QueueController) Delete(w http.ResponseWriter, r *http.Request) {
 
 	response.Send(w, payload, http.StatusCreated)
 }
+
+func (ctl QueueController) MarkNoShow(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionServeCustomers)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	vars := mux.Vars(r)
+
+	token_no, err := strconv.Atoi(vars["token_no"])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	done, err := ctl.noShow.MarkNoShow(ctx, merchantID, int64(token_no))
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+// GetDeleted lists the merchant's deleted queues that can still be restored.
+func (ctl QueueController) GetDeleted(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageQueues)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	queues, err := ctl.usecase.GetDeleted(ctx, staff.MerchantID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(queues, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+// Restore undoes the deletion of a queue within the restore window.
+func (ctl QueueController) Restore(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.repo.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageQueues)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	queueID, err := strconv.ParseInt(vars["queue_id"], 10, 64)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	done, err := ctl.usecase.Restore(ctx, staff.MerchantID, queueID)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
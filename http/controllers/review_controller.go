@@ -0,0 +1,458 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"no-q-solution/domain/entities"
+	"no-q-solution/domain/interfaces"
+	"no-q-solution/domain/usecases"
+	"no-q-solution/http/error"
+	"no-q-solution/http/transport/request"
+	"no-q-solution/http/transport/request/decoders"
+	"no-q-solution/http/transport/response"
+	"no-q-solution/http/validators"
+	"no-q-solution/utils/container"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type ReviewController struct {
+	usecase   usecases.ReviewUsecase
+	validator validators.Validator
+	merchants interfaces.MerchantRepository
+	customers interfaces.CustomerRepository
+	admin     interfaces.AdminGuard
+}
+
+func NewReviewController(ctr container.Containers) ReviewController {
+	ctl := ReviewController{
+		usecase:   usecases.NewReviewUsecase(ctr.Repositories.Review, ctr.Repositories.Queue),
+		validator: validators.NewValidator(),
+		merchants: ctr.Repositories.Merchant,
+		customers: ctr.Repositories.Customer,
+		admin:     ctr.Adapters.Admin,
+	}
+
+	return ctl
+}
+
+func (ctl ReviewController) Create(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	user, err := ctl.customers.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	decoder := decoders.CreateReview{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	review, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	created, err := ctl.usecase.Create(ctx, user, review)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(created, nil, "true")
+
+	response.Send(w, payload, http.StatusCreated)
+}
+
+func (ctl ReviewController) GetByMerchant(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+
+	merchantID, err := strconv.ParseInt(vars["merchant_id"], 10, 64)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	paginator := entities.Paginator{Page: 1, Size: 10}
+
+	if param := r.FormValue("paginator"); len(param) > 0 {
+		pageDecoder := decoders.Paginator{}
+
+		err = json.Unmarshal([]byte(param), &pageDecoder)
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		err = ctl.validator.Validate(ctx, pageDecoder)
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		paginator, err = pageDecoder.Validate()
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	reviews, err := ctl.usecase.GetByMerchant(ctx, merchantID, paginator)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(reviews, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl ReviewController) Reply(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.merchants.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageReviews)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	vars := mux.Vars(r)
+
+	reviewID, err := strconv.ParseInt(vars["review_id"], 10, 64)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	decoder := decoders.ReviewReply{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	reply, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	done, err := ctl.usecase.Reply(ctx, merchantID, reviewID, reply)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl ReviewController) Flag(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token := authHeader[len("Bearer "):]
+
+	staff, err := ctl.merchants.ValidateToken(ctx, token)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = usecases.CheckPermission(staff, entities.PermissionManageReviews)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusForbidden)
+		return
+	}
+
+	merchantID := staff.MerchantID
+
+	vars := mux.Vars(r)
+
+	reviewID, err := strconv.ParseInt(vars["review_id"], 10, 64)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	decoder := decoders.ReviewFlag{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	reason, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	done, err := ctl.usecase.Flag(ctx, merchantID, reviewID, reason)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl ReviewController) GetForModeration(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err := ctl.admin.Validate(authHeader[len("Bearer "):])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	paginator := entities.Paginator{Page: 1, Size: 10}
+
+	if param := r.FormValue("paginator"); len(param) > 0 {
+		pageDecoder := decoders.Paginator{}
+
+		err = json.Unmarshal([]byte(param), &pageDecoder)
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		err = ctl.validator.Validate(ctx, pageDecoder)
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		paginator, err = pageDecoder.Validate()
+		if err != nil {
+			log.Println(err.Error())
+
+			error.HandleError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	reviews, err := ctl.usecase.GetForModeration(ctx, r.FormValue("status"), paginator)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(reviews, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
+
+func (ctl ReviewController) Moderate(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		err := errors.New("authorization token not found")
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err := ctl.admin.Validate(authHeader[len("Bearer "):])
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	reviewID, err := strconv.ParseInt(vars["review_id"], 10, 64)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	decoder := decoders.ReviewModeration{}
+
+	err = request.Decode(ctx, r, &decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ctl.validator.Validate(ctx, decoder)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	status, reason, err := decoder.Validate()
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	done, err := ctl.usecase.Moderate(ctx, reviewID, status, reason)
+	if err != nil {
+		log.Println(err.Error())
+
+		error.HandleError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload := response.Encode(done, nil, "true")
+
+	response.Send(w, payload, http.StatusOK)
+}
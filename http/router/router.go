@@ -15,6 +15,14 @@ func Init(ctr container.Containers) *mux.Router {
 
 	merchant := controllers.NewMerchantController(ctr)
 	queue := controllers.NewQueueController(ctr)
+	display := controllers.NewDisplayController(ctr)
+	customer := controllers.NewCustomerController(ctr)
+	review := controllers.NewReviewController(ctr)
+	staff := controllers.NewStaffController(ctr)
+	branch := controllers.NewBranchController(ctr)
+	category := controllers.NewCategoryController(ctr)
+	analytics := controllers.NewAnalyticsController(ctr)
+	image := controllers.NewImageController(ctr)
 
 	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		response.Send(w, []byte("No-Q Solution"), http.StatusOK)
@@ -25,10 +33,54 @@ func Init(ctr container.Containers) *mux.Router {
 	r.HandleFunc("/merchant/get_by_category/{category}", merchant.GetByCategory).Methods(http.MethodGet)
 	r.HandleFunc("/merchant/get_single", merchant.GetSingle).Methods(http.MethodGet)
 	r.HandleFunc("/merchant/search/{input}", merchant.Search).Methods(http.MethodGet)
+	r.HandleFunc("/merchant/nearby", merchant.Nearby).Methods(http.MethodGet)
+	r.HandleFunc("/merchant/suggest", merchant.Suggest).Methods(http.MethodGet)
 	r.HandleFunc("/merchant/create", merchant.Create).Methods(http.MethodPost)
 	r.HandleFunc("/merchant/login", merchant.Login).Methods(http.MethodPost)
+	r.HandleFunc("/merchant", merchant.Update).Methods(http.MethodPatch)
+	r.HandleFunc("/merchant/confirm_email/{token}", merchant.ConfirmEmail).Methods(http.MethodGet)
+	r.HandleFunc("/merchant/verify_email/{token}", merchant.VerifyEmail).Methods(http.MethodGet)
+	r.HandleFunc("/merchant/resend_verification", merchant.ResendVerification).Methods(http.MethodPost)
+	r.HandleFunc("/merchant/forgot_password", merchant.ForgotPassword).Methods(http.MethodPost)
+	r.HandleFunc("/merchant/reset_password", merchant.ResetPassword).Methods(http.MethodPost)
 	r.HandleFunc("/merchant/logout", merchant.Logout).Methods(http.MethodGet)
 	r.HandleFunc("/merchant/delete", merchant.Delete).Methods(http.MethodDelete)
+	r.HandleFunc("/merchant/restore", merchant.RestoreAccount).Methods(http.MethodPost)
+	r.HandleFunc("/merchant/deleted", merchant.GetDeleted).Methods(http.MethodGet)
+	r.HandleFunc("/merchant/restore/{merchant_id}", merchant.Restore).Methods(http.MethodPatch)
+	r.HandleFunc("/merchant/privacy/export", merchant.ExportData).Methods(http.MethodGet)
+	r.HandleFunc("/merchant/privacy/erase", merchant.EraseData).Methods(http.MethodDelete)
+	r.HandleFunc("/merchant/no_show_policy", merchant.GetNoShowPolicy).Methods(http.MethodGet)
+	r.HandleFunc("/merchant/no_show_policy", merchant.SaveNoShowPolicy).Methods(http.MethodPut)
+	r.HandleFunc("/merchant/customer_restrictions", merchant.GetCustomerRestrictions).Methods(http.MethodGet)
+	r.HandleFunc("/merchant/customer_restrictions", merchant.CreateCustomerRestriction).Methods(http.MethodPost)
+	r.HandleFunc("/merchant/customer_restrictions/{restriction_id}", merchant.DeleteCustomerRestriction).Methods(http.MethodDelete)
+	r.HandleFunc("/merchant/staff", staff.GetAll).Methods(http.MethodGet)
+	r.HandleFunc("/merchant/staff", staff.Create).Methods(http.MethodPost)
+	r.HandleFunc("/merchant/staff/login", staff.Login).Methods(http.MethodPost)
+	r.HandleFunc("/merchant/staff/{staff_id}", staff.Update).Methods(http.MethodPatch)
+	r.HandleFunc("/merchant/staff/{staff_id}", staff.Delete).Methods(http.MethodDelete)
+	r.HandleFunc("/merchant/logo", image.UploadLogo).Methods(http.MethodPost)
+	r.HandleFunc("/merchant/logo", image.DeleteLogo).Methods(http.MethodDelete)
+	r.HandleFunc("/merchant/gallery", image.UploadGalleryImage).Methods(http.MethodPost)
+	r.HandleFunc("/merchant/gallery/{image_id}", image.DeleteGalleryImage).Methods(http.MethodDelete)
+	r.HandleFunc("/merchant/display_keys", merchant.GetDisplayKeys).Methods(http.MethodGet)
+	r.HandleFunc("/merchant/display_keys", merchant.CreateDisplayKey).Methods(http.MethodPost)
+	r.HandleFunc("/merchant/display_keys/{key_id}", merchant.RevokeDisplayKey).Methods(http.MethodDelete)
+	r.HandleFunc("/merchant/moderation", merchant.GetForModeration).Methods(http.MethodGet)
+	r.HandleFunc("/merchant/moderate/{merchant_id}", merchant.Moderate).Methods(http.MethodPatch)
+
+	r.HandleFunc("/category/get_all", category.GetAll).Methods(http.MethodGet)
+	r.HandleFunc("/category/create", category.Create).Methods(http.MethodPost)
+	r.HandleFunc("/category/update/{category}", category.Update).Methods(http.MethodPatch)
+	r.HandleFunc("/category/reorder", category.Reorder).Methods(http.MethodPut)
+	r.HandleFunc("/category/retire/{category}", category.Retire).Methods(http.MethodPatch)
+	r.HandleFunc("/category/restore/{category}", category.Restore).Methods(http.MethodPatch)
+
+	r.HandleFunc("/branch/get_by_merchant/{merchant_id}", branch.GetByMerchant).Methods(http.MethodGet)
+	r.HandleFunc("/branch/create", branch.Create).Methods(http.MethodPost)
+	r.HandleFunc("/branch/{branch_id}", branch.Update).Methods(http.MethodPut)
+	r.HandleFunc("/branch/{branch_id}", branch.Delete).Methods(http.MethodDelete)
 
 	r.HandleFunc("/queue/get_by_merchant/{merchant_id}", queue.GetByMerchant).Methods(http.MethodGet)
 	r.HandleFunc("/queue/get_slots_by_date/{queue_id}/{date}", queue.GetSlotsByDate).Methods(http.MethodGet)
@@ -38,8 +90,48 @@ func Init(ctr container.Containers) *mux.Router {
 	r.HandleFunc("/queue/make_dates_un_available/{queue_id}", queue.MakeDatesUnAvailable).Methods(http.MethodDelete)
 	r.HandleFunc("/queue/create", queue.Create).Methods(http.MethodPost)
 	r.HandleFunc("/queue/reserve_slot", queue.ReserveSlot).Methods(http.MethodPost)
+	r.HandleFunc("/queue/check_in_qr/{code}", queue.CheckInQR).Methods(http.MethodGet)
+	r.HandleFunc("/queue/check_in", queue.CheckIn).Methods(http.MethodPatch)
+	r.HandleFunc("/queue/call_token/{token_no}", queue.CallToken).Methods(http.MethodPatch)
+	r.HandleFunc("/queue/no_show/{token_no}", queue.MarkNoShow).Methods(http.MethodPatch)
+	r.HandleFunc("/queue/events/{queue_id}", queue.Events).Methods(http.MethodGet)
+	r.HandleFunc("/queue/merchant_events", queue.MerchantEvents).Methods(http.MethodGet)
 	r.HandleFunc("/queue/un_reserve_slot/{token_no}", queue.UnReserveSlot).Methods(http.MethodDelete)
 	r.HandleFunc("/queue/delete/{queue_id}", queue.Delete).Methods(http.MethodDelete)
+	r.HandleFunc("/queue/deleted", queue.GetDeleted).Methods(http.MethodGet)
+	r.HandleFunc("/queue/restore/{queue_id}", queue.Restore).Methods(http.MethodPatch)
+
+	r.HandleFunc("/customer/request_otp", customer.RequestOTP).Methods(http.MethodPost)
+	r.HandleFunc("/customer/verify_otp", customer.VerifyOTP).Methods(http.MethodPost)
+	r.HandleFunc("/customer/logout", customer.Logout).Methods(http.MethodGet)
+	r.HandleFunc("/customer/privacy/export", customer.ExportData).Methods(http.MethodGet)
+	r.HandleFunc("/customer/privacy/erase", customer.EraseData).Methods(http.MethodDelete)
+	r.HandleFunc("/customer/reservations", customer.GetReservations).Methods(http.MethodGet)
+	r.HandleFunc("/customer/reservations/{scope:upcoming|past}", customer.GetReservations).Methods(http.MethodGet)
+	r.HandleFunc("/customer/reservations/{token_no}", customer.CancelReservation).Methods(http.MethodDelete)
+	r.HandleFunc("/customer/reservations/{token_no}", customer.RebookReservation).Methods(http.MethodPatch)
+
+	r.HandleFunc("/review/create", review.Create).Methods(http.MethodPost)
+	r.HandleFunc("/review/get_by_merchant/{merchant_id}", review.GetByMerchant).Methods(http.MethodGet)
+	r.HandleFunc("/review/reply/{review_id}", review.Reply).Methods(http.MethodPatch)
+	r.HandleFunc("/review/flag/{review_id}", review.Flag).Methods(http.MethodPatch)
+	r.HandleFunc("/review/moderation", review.GetForModeration).Methods(http.MethodGet)
+	r.HandleFunc("/review/moderate/{review_id}", review.Moderate).Methods(http.MethodPatch)
+
+	r.HandleFunc("/analytics/merchant", analytics.GetForMerchant).Methods(http.MethodGet)
+	r.HandleFunc("/analytics/queue/{queue_id}", analytics.GetForQueue).Methods(http.MethodGet)
+
+	r.HandleFunc("/display/{key}", display.Board).Methods(http.MethodGet)
+	r.HandleFunc("/display/{key}/{queue_id}", display.Board).Methods(http.MethodGet)
+
+	// the local blob store serves uploaded images itself
+	files, ok := ctr.Adapters.Blobs.(interface {
+		http.Handler
+		Prefix() string
+	})
+	if ok {
+		r.PathPrefix(files.Prefix() + "/").Handler(http.StripPrefix(files.Prefix(), files)).Methods(http.MethodGet)
+	}
 
 	return r
 }
@@ -0,0 +1,43 @@
+package decoders
+
+import (
+	"errors"
+	"no-q-solution/domain/entities"
+	"time"
+)
+
+// Analytics holds the date range of an analytics request, read with
+// r.FormValue. Both days are included.
+type Analytics struct {
+	From string
+	To   string
+}
+
+func (a Analytics) Format() string {
+	return `?from=2023-04-01&to=2023-04-30`
+}
+
+func (a Analytics) Validate() (entities.AnalyticsQuery, error) {
+
+	query := entities.AnalyticsQuery{}
+
+	if len(a.From) > 0 {
+		from, err := time.Parse("2006-01-02", a.From)
+		if err != nil {
+			return query, errors.New("from must be a date like 2023-04-01")
+		}
+
+		query.From = from
+	}
+
+	if len(a.To) > 0 {
+		to, err := time.Parse("2006-01-02", a.To)
+		if err != nil {
+			return query, errors.New("to must be a date like 2023-04-30")
+		}
+
+		query.To = to
+	}
+
+	return query, nil
+}
@@ -0,0 +1,30 @@
+package decoders
+
+import (
+	"no-q-solution/domain/entities"
+	"time"
+)
+
+type BookingFilter struct {
+	From *time.Time `json:"from"`
+	To   *time.Time `json:"to"`
+}
+
+func (f BookingFilter) Format() string {
+	return `
+		{
+			"from": "2023-04-01T00:00:00Z",
+			"to": "2023-05-01T00:00:00Z"
+		}
+	`
+}
+
+func (f BookingFilter) Validate() (entities.BookingFilter, error) {
+
+	filter := entities.BookingFilter{}
+
+	filter.From = f.From
+	filter.To = f.To
+
+	return filter, nil
+}
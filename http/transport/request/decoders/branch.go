@@ -0,0 +1,64 @@
+package decoders
+
+import "no-q-solution/domain/entities"
+
+type Branch struct {
+	Name       string         `json:"name" validate:"required"`
+	Address    string         `json:"address"`
+	City       string         `json:"city"`
+	PostalCode string         `json:"postal_code"`
+	Country    string         `json:"country"`
+	Latitude   *float64       `json:"latitude"`
+	Longitude  *float64       `json:"longitude"`
+	Phone      string         `json:"phone"`
+	Hours      []OpeningHours `json:"hours" validate:"dive"`
+}
+
+type OpeningHours struct {
+	Weekday int    `json:"weekday" validate:"min=0,max=6"`
+	Opens   string `json:"opens" validate:"required"`
+	Closes  string `json:"closes" validate:"required"`
+}
+
+func (b Branch) Format() string {
+	return `
+		{
+			"name": "Kandy",
+			"address": "12 Dalada Veediya",
+			"city": "Kandy",
+			"postal_code": "20000",
+			"country": "LK",
+			"latitude": 7.2936,
+			"longitude": 80.6413,
+			"phone": "0812222333",
+			"hours": [
+				{"weekday": 1, "opens": "09:00", "closes": "17:00"}
+			]
+		}
+	`
+}
+
+func (b Branch) Validate() (entities.Branch, error) {
+
+	branch := entities.Branch{}
+
+	branch.Name = b.Name
+	branch.Address = b.Address
+	branch.City = b.City
+	branch.PostalCode = b.PostalCode
+	branch.Country = b.Country
+	branch.Latitude = b.Latitude
+	branch.Longitude = b.Longitude
+	branch.Phone = b.Phone
+	branch.Hours = make([]entities.OpeningHours, 0, len(b.Hours))
+
+	for _, h := range b.Hours {
+		branch.Hours = append(branch.Hours, entities.OpeningHours{
+			Weekday: h.Weekday,
+			Opens:   h.Opens,
+			Closes:  h.Closes,
+		})
+	}
+
+	return branch, nil
+}
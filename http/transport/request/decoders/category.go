@@ -0,0 +1,75 @@
+package decoders
+
+import "no-q-solution/domain/entities"
+
+type Category struct {
+	Name   string `json:"name" validate:"required"`
+	Parent string `json:"parent"`
+	Icon   string `json:"icon"`
+}
+
+func (c Category) Format() string {
+	return `
+		{
+			"name": "Dental",
+			"parent": "Health",
+			"icon": "tooth"
+		}
+	`
+}
+
+func (c Category) Validate() (entities.Category, error) {
+
+	category := entities.Category{}
+
+	category.Name = c.Name
+	category.Parent = c.Parent
+	category.Icon = c.Icon
+
+	return category, nil
+}
+
+type CategoryUpdate struct {
+	Name   *string `json:"name"`
+	Parent *string `json:"parent"`
+	Icon   *string `json:"icon"`
+}
+
+func (c CategoryUpdate) Format() string {
+	return `
+		{
+			"name": "Dental Care",
+			"parent": ""
+		}
+	`
+}
+
+func (c CategoryUpdate) Validate() (entities.CategoryUpdate, error) {
+
+	update := entities.CategoryUpdate{}
+
+	update.Name = c.Name
+	update.Parent = c.Parent
+	update.Icon = c.Icon
+
+	return update, nil
+}
+
+type CategoryOrder struct {
+	Parent string   `json:"parent"`
+	Order  []string `json:"order" validate:"required"`
+}
+
+func (c CategoryOrder) Format() string {
+	return `
+		{
+			"parent": "Health",
+			"order": ["Dental", "Pharmacy"]
+		}
+	`
+}
+
+func (c CategoryOrder) Validate() (string, []string, error) {
+
+	return c.Parent, c.Order, nil
+}
@@ -0,0 +1,18 @@
+package decoders
+
+type CheckIn struct {
+	Code string `json:"code" validate:"required"`
+}
+
+func (c CheckIn) Format() string {
+	return `
+		{
+			"code": "MTI6MzoxNjgxNDY2NDAw.2Y0rN0p8fGZb7kYQbq1x6w"
+		}
+	`
+}
+
+func (c CheckIn) Validate() (string, error) {
+
+	return c.Code, nil
+}
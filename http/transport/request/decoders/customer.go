@@ -0,0 +1,80 @@
+package decoders
+
+import (
+	"errors"
+	"no-q-solution/domain/entities"
+	"no-q-solution/utils/identity"
+	"time"
+)
+
+type RequestOTP struct {
+	Phone string `json:"phone" validate:"required"`
+}
+
+func (o RequestOTP) Format() string {
+	return `
+		{
+			"phone": "0779497842"
+		}
+	`
+}
+
+func (o RequestOTP) Validate() (string, error) {
+
+	phone := identity.NormalizePhone(o.Phone)
+
+	if len(phone) == 0 {
+		return "", errors.New("invalid phone number")
+	}
+
+	return phone, nil
+}
+
+type VerifyOTP struct {
+	Phone string `json:"phone" validate:"required"`
+	Code  string `json:"code" validate:"required"`
+}
+
+func (o VerifyOTP) Format() string {
+	return `
+		{
+			"phone": "0779497842",
+			"code": "123456"
+		}
+	`
+}
+
+func (o VerifyOTP) Validate() (string, string, error) {
+
+	phone := identity.NormalizePhone(o.Phone)
+
+	if len(phone) == 0 {
+		return "", "", errors.New("invalid phone number")
+	}
+
+	return phone, o.Code, nil
+}
+
+type Rebook struct {
+	StartTime time.Time `json:"start_time" validate:"required"`
+	EndTime   time.Time `json:"end_time" validate:"required"`
+}
+
+func (rb Rebook) Format() string {
+	return `
+		{
+			"start_time": "2023-04-14T10:00:00Z",
+			"end_time": "2023-04-14T10:30:00Z"
+		}
+	`
+}
+
+func (rb Rebook) Validate() (entities.ReservedSlots, error) {
+
+	reservation := entities.ReservedSlots{}
+
+	reservation.StartTime = rb.StartTime
+	reservation.EndTime = rb.EndTime
+
+	return reservation, nil
+}
@@ -25,3 +25,56 @@ func (l Login) Validate() (entities.Login, error) {
 
 	return login, nil
 }
+
+type ForgotPassword struct {
+	Email string `json:"email" validate:"required"`
+}
+
+func (f ForgotPassword) Format() string {
+	return `
+		{
+			"email": "merchant@example.com"
+		}
+	`
+}
+
+func (f ForgotPassword) Validate() (string, error) {
+
+	return f.Email, nil
+}
+
+type ResetPassword struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (r ResetPassword) Format() string {
+	return `
+		{
+			"token": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			"password": "n3w-#xsgJ62J"
+		}
+	`
+}
+
+func (r ResetPassword) Validate() (string, string, error) {
+
+	return r.Token, r.Password, nil
+}
+
+type ResendVerification struct {
+	Email string `json:"email" validate:"required"`
+}
+
+func (r ResendVerification) Format() string {
+	return `
+		{
+			"email": "merchant@example.com"
+		}
+	`
+}
+
+func (r ResendVerification) Validate() (string, error) {
+
+	return r.Email, nil
+}
@@ -9,6 +9,7 @@ type Merchant struct {
 	Category  string `json:"category" validate:"required"`
 	Email     string `json:"email" validate:"required"`
 	Password  string `json:"password" validate:"required"`
+	Country   string `json:"country"`
 	Facebook  string `json:"facebook"`
 	Instagram string `json:"instagram"`
 	Website   string `json:"website"`
@@ -21,6 +22,7 @@ func (m Merchant) Format() string {
 			"category": "medical",
 			"email": "merchant@example.com",
 			"password": "#xsgJ62J",
+			"country": "LK",
 			"facebook": "fb.com/merchant",
 			"instagram": "insta.com/merchant",
 			"website": "merchant.com"
@@ -36,9 +38,50 @@ func (m Merchant) Validate() (entities.Merchant, error) {
 	merchant.Category = m.Category
 	merchant.Email = m.Email
 	merchant.Password = m.Password
+	merchant.Country = m.Country
 	merchant.Facebook = m.Facebook
 	merchant.Instagram = m.Instagram
 	merchant.Website = m.Website
 
 	return merchant, nil
 }
+
+type MerchantUpdate struct {
+	Name            *string `json:"name"`
+	Category        *string `json:"category"`
+	Email           *string `json:"email"`
+	Country         *string `json:"country"`
+	Facebook        *string `json:"facebook"`
+	Instagram       *string `json:"instagram"`
+	Website         *string `json:"website"`
+	Password        *string `json:"password"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+func (m MerchantUpdate) Format() string {
+	return `
+		{
+			"name": "merchant",
+			"website": "merchant.com",
+			"email": "owner@merchant.com",
+			"current_password": "#xsgJ62J"
+		}
+	`
+}
+
+func (m MerchantUpdate) Validate() (entities.MerchantUpdate, error) {
+
+	update := entities.MerchantUpdate{}
+
+	update.Name = m.Name
+	update.Category = m.Category
+	update.Email = m.Email
+	update.Country = m.Country
+	update.Facebook = m.Facebook
+	update.Instagram = m.Instagram
+	update.Website = m.Website
+	update.Password = m.Password
+	update.CurrentPassword = m.CurrentPassword
+
+	return update, nil
+}
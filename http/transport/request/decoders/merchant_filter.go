@@ -0,0 +1,56 @@
+package decoders
+
+import (
+	"errors"
+	"no-q-solution/domain/entities"
+	"strconv"
+	"time"
+)
+
+// MerchantFilter holds the availability filters of a merchant listing, read
+// with r.FormValue.
+type MerchantFilter struct {
+	OpenNow   string
+	FreeToday string
+	FreeOn    string
+}
+
+func (f MerchantFilter) Format() string {
+	return `?open_now=true&free_today=true&free_on=2023-04-14`
+}
+
+func (f MerchantFilter) Validate() (entities.MerchantFilter, error) {
+
+	filter := entities.MerchantFilter{}
+
+	var err error
+
+	if len(f.OpenNow) > 0 {
+		filter.OpenNow, err = strconv.ParseBool(f.OpenNow)
+		if err != nil {
+			return filter, errors.New("open_now must be true or false")
+		}
+	}
+
+	if len(f.FreeToday) > 0 {
+		filter.FreeToday, err = strconv.ParseBool(f.FreeToday)
+		if err != nil {
+			return filter, errors.New("free_today must be true or false")
+		}
+	}
+
+	if len(f.FreeOn) > 0 {
+		date, err := time.Parse("2006-01-02", f.FreeOn)
+		if err != nil {
+			return filter, errors.New("free_on must be a date like 2023-04-14")
+		}
+
+		filter.FreeOn = &date
+	}
+
+	if filter.FreeToday && filter.FreeOn != nil {
+		return filter, errors.New("use either free_today or free_on")
+	}
+
+	return filter, nil
+}
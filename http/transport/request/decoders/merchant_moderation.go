@@ -0,0 +1,27 @@
+package decoders
+
+import "no-q-solution/domain/entities"
+
+type MerchantModeration struct {
+	Status string `json:"status" validate:"required"`
+	Reason string `json:"reason"`
+}
+
+func (m MerchantModeration) Format() string {
+	return `
+		{
+			"status": "suspended",
+			"reason": "Repeated customer complaints"
+		}
+	`
+}
+
+func (m MerchantModeration) Validate() (entities.Merchant, error) {
+
+	merchant := entities.Merchant{}
+
+	merchant.Status = m.Status
+	merchant.StatusReason = m.Reason
+
+	return merchant, nil
+}
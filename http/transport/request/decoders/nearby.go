@@ -0,0 +1,55 @@
+package decoders
+
+import (
+	"errors"
+	"no-q-solution/domain/entities"
+	"strconv"
+)
+
+// Nearby holds the query string of a geo search, read with r.FormValue.
+type Nearby struct {
+	Latitude  string `validate:"required"`
+	Longitude string `validate:"required"`
+	Radius    string
+	Category  string
+	Limit     string
+}
+
+func (n Nearby) Format() string {
+	return `?lat=6.9271&lng=79.8612&radius=5&category=Health&limit=20`
+}
+
+func (n Nearby) Validate() (entities.GeoQuery, error) {
+
+	geo := entities.GeoQuery{}
+
+	var err error
+
+	geo.Latitude, err = strconv.ParseFloat(n.Latitude, 64)
+	if err != nil {
+		return geo, errors.New("lat must be a number")
+	}
+
+	geo.Longitude, err = strconv.ParseFloat(n.Longitude, 64)
+	if err != nil {
+		return geo, errors.New("lng must be a number")
+	}
+
+	if len(n.Radius) > 0 {
+		geo.Radius, err = strconv.ParseFloat(n.Radius, 64)
+		if err != nil {
+			return geo, errors.New("radius must be a number")
+		}
+	}
+
+	if len(n.Limit) > 0 {
+		geo.Limit, err = strconv.Atoi(n.Limit)
+		if err != nil {
+			return geo, errors.New("limit must be an integer")
+		}
+	}
+
+	geo.Category = n.Category
+
+	return geo, nil
+}
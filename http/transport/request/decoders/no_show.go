@@ -0,0 +1,72 @@
+package decoders
+
+import (
+	"no-q-solution/domain/entities"
+	"time"
+)
+
+type NoShowPolicy struct {
+	Enabled      bool `json:"enabled"`
+	MaxNoShows   int  `json:"max_no_shows" validate:"required"`
+	WindowDays   int  `json:"window_days" validate:"required"`
+	BlockDays    int  `json:"block_days" validate:"required"`
+	AutoDetect   bool `json:"auto_detect"`
+	GraceMinutes int  `json:"grace_minutes"`
+}
+
+func (p NoShowPolicy) Format() string {
+	return `
+		{
+			"enabled": true,
+			"max_no_shows": 3,
+			"window_days": 60,
+			"block_days": 30,
+			"auto_detect": true,
+			"grace_minutes": 15
+		}
+	`
+}
+
+func (p NoShowPolicy) Validate() (entities.NoShowPolicy, error) {
+
+	policy := entities.NoShowPolicy{}
+
+	policy.Enabled = p.Enabled
+	policy.MaxNoShows = p.MaxNoShows
+	policy.WindowDays = p.WindowDays
+	policy.BlockDays = p.BlockDays
+	policy.AutoDetect = p.AutoDetect
+	policy.GraceMinutes = p.GraceMinutes
+
+	return policy, nil
+}
+
+type CustomerRestriction struct {
+	Phone  string     `json:"phone" validate:"required"`
+	Mode   string     `json:"mode" validate:"required"`
+	Until  *time.Time `json:"until"`
+	Reason string     `json:"reason"`
+}
+
+func (c CustomerRestriction) Format() string {
+	return `
+		{
+			"phone": "0779497842",
+			"mode": "allow",
+			"until": "2023-05-14T00:00:00Z",
+			"reason": "called ahead to explain"
+		}
+	`
+}
+
+func (c CustomerRestriction) Validate() (entities.CustomerRestriction, error) {
+
+	restriction := entities.CustomerRestriction{}
+
+	restriction.Phone = c.Phone
+	restriction.Mode = c.Mode
+	restriction.Until = c.Until
+	restriction.Reason = c.Reason
+
+	return restriction, nil
+}
@@ -6,6 +6,7 @@ import (
 )
 
 type Queue struct {
+	BranchID  int64     `json:"branch_id"`
 	Name      string    `json:"name" validate:"required"`
 	Interval  int       `json:"interval" validate:"required"`
 	StartTime time.Time `json:"start_time" validate:"required"`
@@ -15,6 +16,7 @@ type Queue struct {
 func (q Queue) Format() string {
 	return `
 		{
+			"branch_id": 1,
 			"name": "xyz",
 			"interval": 30,
 			"start_time": "2023-04-14T10:00:00Z",
@@ -27,6 +29,7 @@ func (q Queue) Validate() (entities.Queue, error) {
 
 	queue := entities.Queue{}
 
+	queue.BranchID = q.BranchID
 	queue.Name = q.Name
 	queue.Interval = q.Interval
 	queue.StartTime = q.StartTime
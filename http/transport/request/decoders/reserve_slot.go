@@ -1,8 +1,9 @@
 package decoders
 
 import (
-	"errors"
 	"no-q-solution/domain/entities"
+	"no-q-solution/utils/identity"
+	"strings"
 	"time"
 )
 
@@ -42,12 +43,8 @@ func (r ReserveSlot) Validate() (entities.ReservedSlots, error) {
 	reserveSlot.StartTime = r.StartTime
 	reserveSlot.EndTime = r.EndTime
 	reserveSlot.ReservedBy.Name = r.ReservedBy.Name
-	reserveSlot.ReservedBy.Phone = r.ReservedBy.Phone
-	reserveSlot.ReservedBy.Email = r.ReservedBy.Email
-
-	if len(reserveSlot.ReservedBy.Phone) != 10 {
-		return entities.ReservedSlots{}, errors.New("invalid phone number")
-	}
+	reserveSlot.ReservedBy.Phone = strings.TrimSpace(r.ReservedBy.Phone)
+	reserveSlot.ReservedBy.Email = identity.NormalizeEmail(r.ReservedBy.Email)
 
 	return reserveSlot, nil
 }
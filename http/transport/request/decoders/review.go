@@ -0,0 +1,83 @@
+package decoders
+
+import "no-q-solution/domain/entities"
+
+type CreateReview struct {
+	TokenNo int64  `json:"token_no" validate:"required"`
+	Rating  int    `json:"rating" validate:"required"`
+	Body    string `json:"body"`
+}
+
+func (c CreateReview) Format() string {
+	return `
+		{
+			"token_no": 12,
+			"rating": 5,
+			"body": "Called right on time, friendly staff."
+		}
+	`
+}
+
+func (c CreateReview) Validate() (entities.Review, error) {
+
+	review := entities.Review{}
+
+	review.TokenNo = c.TokenNo
+	review.Rating = c.Rating
+	review.Body = c.Body
+
+	return review, nil
+}
+
+type ReviewReply struct {
+	Reply string `json:"reply" validate:"required"`
+}
+
+func (r ReviewReply) Format() string {
+	return `
+		{
+			"reply": "Thank you, see you again!"
+		}
+	`
+}
+
+func (r ReviewReply) Validate() (string, error) {
+
+	return r.Reply, nil
+}
+
+type ReviewFlag struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+func (f ReviewFlag) Format() string {
+	return `
+		{
+			"reason": "contains a phone number"
+		}
+	`
+}
+
+func (f ReviewFlag) Validate() (string, error) {
+
+	return f.Reason, nil
+}
+
+type ReviewModeration struct {
+	Status string `json:"status" validate:"required"`
+	Reason string `json:"reason"`
+}
+
+func (m ReviewModeration) Format() string {
+	return `
+		{
+			"status": "hidden",
+			"reason": "personal data"
+		}
+	`
+}
+
+func (m ReviewModeration) Validate() (string, string, error) {
+
+	return m.Status, m.Reason, nil
+}
@@ -0,0 +1,58 @@
+package decoders
+
+import "no-q-solution/domain/entities"
+
+type Staff struct {
+	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+	Role     string `json:"role" validate:"required"`
+}
+
+func (s Staff) Format() string {
+	return `
+		{
+			"name": "reception",
+			"email": "reception@merchant.com",
+			"password": "#xsgJ62J",
+			"role": "front_desk"
+		}
+	`
+}
+
+func (s Staff) Validate() (entities.Staff, error) {
+
+	staff := entities.Staff{}
+
+	staff.Name = s.Name
+	staff.Email = s.Email
+	staff.Password = s.Password
+	staff.Role = s.Role
+
+	return staff, nil
+}
+
+type StaffUpdate struct {
+	Name     *string `json:"name"`
+	Role     *string `json:"role"`
+	Password *string `json:"password"`
+}
+
+func (s StaffUpdate) Format() string {
+	return `
+		{
+			"role": "manager"
+		}
+	`
+}
+
+func (s StaffUpdate) Validate() (entities.StaffUpdate, error) {
+
+	update := entities.StaffUpdate{}
+
+	update.Name = s.Name
+	update.Role = s.Role
+	update.Password = s.Password
+
+	return update, nil
+}
@@ -0,0 +1,39 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ReadFile reads one file field of a multipart form, refusing anything
+// larger than limit bytes.
+func ReadFile(w http.ResponseWriter, r *http.Request, field string, limit int64) ([]byte, error) {
+
+	// leave room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, limit+64<<10)
+
+	err := r.ParseMultipartForm(limit)
+	if err != nil {
+		return nil, fmt.Errorf("upload must be a multipart form of at most %d MB", limit>>20)
+	}
+
+	file, _, err := r.FormFile(field)
+	if err != nil {
+		return nil, fmt.Errorf("%s file not found", field)
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, errors.New("file is too large")
+	}
+
+	return data, nil
+}
@@ -0,0 +1,18 @@
+package views
+
+import (
+	"embed"
+	"html/template"
+	"time"
+)
+
+//go:embed *.html
+var files embed.FS
+
+var funcs = template.FuncMap{
+	"minutes": func(d time.Duration) int {
+		return int(d.Minutes())
+	},
+}
+
+var DisplayBoard = template.Must(template.New("display_board.html").Funcs(funcs).ParseFS(files, "display_board.html"))
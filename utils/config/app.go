@@ -9,6 +9,15 @@ import (
 type App struct {
 	Port int    `yaml:"service-port"`
 	Host string `yaml:"service-host"`
+
+	// CheckInSecret signs check-in codes. CHECK_IN_SECRET in the environment
+	// takes precedence, so the secret does not have to live in the file.
+	CheckInSecret string `yaml:"check-in-secret"`
+	AdminKey      string `yaml:"admin-key"`
+
+	// DefaultCountry is the ISO 3166 alpha-2 code national phone numbers are
+	// read in when no merchant country applies, e.g. on customer login.
+	DefaultCountry string `yaml:"default-country"`
 }
 
 func (app *App) Parse() error {
@@ -22,5 +31,9 @@ func (app *App) Parse() error {
 		return err
 	}
 
+	if secret, ok := os.LookupEnv("CHECK_IN_SECRET"); ok {
+		app.CheckInSecret = secret
+	}
+
 	return nil
 }
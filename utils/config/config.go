@@ -3,4 +3,7 @@ package config
 type Config struct {
 	App      App
 	Database Database
+	SMS      SMS
+	Mail     Mail
+	Storage  Storage
 }
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+type Mail struct {
+	Driver  string `yaml:"driver"`
+	From    string `yaml:"from"`
+	Dir     string `yaml:"dir"`
+	BaseURL string `yaml:"base-url"`
+	// ResetPasswordURL is the frontend page that lets a merchant choose a
+	// new password; the reset token is appended as the token parameter.
+	ResetPasswordURL string `yaml:"reset-password-url"`
+	SMTP             SMTP   `yaml:"smtp"`
+}
+
+type SMTP struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+func (mail *Mail) Parse() error {
+
+	yamlFile, err := os.ReadFile("configurations/mail.yaml")
+	if err != nil {
+		return err
+	}
+	err = yaml.Unmarshal(yamlFile, mail)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -3,6 +3,9 @@ package config
 func Parse() (Config, error) {
 	app := &App{}
 	db := &Database{}
+	sms := &SMS{}
+	mail := &Mail{}
+	storage := &Storage{}
 
 	err := app.Parse()
 	if err != nil {
@@ -14,9 +17,27 @@ func Parse() (Config, error) {
 		return Config{}, err
 	}
 
+	err = sms.Parse()
+	if err != nil {
+		return Config{}, err
+	}
+
+	err = mail.Parse()
+	if err != nil {
+		return Config{}, err
+	}
+
+	err = storage.Parse()
+	if err != nil {
+		return Config{}, err
+	}
+
 	configs := Config{
 		App:      *app,
 		Database: *db,
+		SMS:      *sms,
+		Mail:     *mail,
+		Storage:  *storage,
 	}
 
 	return configs, nil
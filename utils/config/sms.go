@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+type SMS struct {
+	Driver string `yaml:"driver"`
+	File   string `yaml:"file"`
+}
+
+func (sms *SMS) Parse() error {
+
+	yamlFile, err := os.ReadFile("configurations/sms.yaml")
+	if err != nil {
+		return err
+	}
+	err = yaml.Unmarshal(yamlFile, sms)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
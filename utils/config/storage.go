@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+type Storage struct {
+	Driver  string `yaml:"driver"`
+	Dir     string `yaml:"dir"`
+	BaseURL string `yaml:"base-url"`
+	S3      S3     `yaml:"s3"`
+}
+
+// S3 points at any S3 compatible service. PathStyle addresses the bucket in
+// the path, which local stand-ins such as MinIO expect.
+type S3 struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access-key"`
+	SecretKey string `yaml:"secret-key"`
+	PathStyle bool   `yaml:"path-style"`
+	PublicURL string `yaml:"public-url"`
+}
+
+func (storage *Storage) Parse() error {
+
+	yamlFile, err := os.ReadFile("configurations/storage.yaml")
+	if err != nil {
+		return err
+	}
+	err = yaml.Unmarshal(yamlFile, storage)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
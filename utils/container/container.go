@@ -11,10 +11,26 @@ type Containers struct {
 }
 
 type Adapters struct {
-	Db *sql.DB
+	Db      *sql.DB
+	CheckIn interfaces.CheckInSigner
+	Hub     interfaces.EventHub
+	SMS     interfaces.SMSSender
+	Admin   interfaces.AdminGuard
+	Mailer  interfaces.Mailer
+	Suggest interfaces.SuggestIndex
+	Blobs   interfaces.BlobStore
 }
 
 type Repositories struct {
-	Merchant interfaces.MerchantRepository
-	Queue    interfaces.QueueRepository
+	Merchant  interfaces.MerchantRepository
+	Queue     interfaces.QueueRepository
+	Customer  interfaces.CustomerRepository
+	Privacy   interfaces.PrivacyRepository
+	NoShow    interfaces.NoShowRepository
+	Review    interfaces.ReviewRepository
+	Staff     interfaces.StaffRepository
+	Branch    interfaces.BranchRepository
+	Category  interfaces.CategoryRepository
+	Image     interfaces.ImageRepository
+	Analytics interfaces.AnalyticsRepository
 }
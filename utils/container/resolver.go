@@ -5,9 +5,17 @@ import (
 	"no-q-solution/externals/adapters"
 	"no-q-solution/externals/repositories"
 	"no-q-solution/utils/config"
+	"no-q-solution/utils/identity"
 )
 
 func Resolve(config config.Config) (Containers, error) {
+	if len(config.App.DefaultCountry) != 0 {
+		err := identity.SetDefaultCountry(config.App.DefaultCountry)
+		if err != nil {
+			return Containers{}, err
+		}
+	}
+
 	adaptrs, err := resolveAdapters(config)
 	if err != nil {
 		return Containers{}, err
@@ -33,8 +41,35 @@ func resolveAdapters(config config.Config) (Adapters, error) {
 		return Adapters{}, err
 	}
 
+	checkIn, err := adapters.NewCheckInSigner(config.App.CheckInSecret)
+	if err != nil {
+		return Adapters{}, err
+	}
+
+	sms, err := adapters.NewSMSSender(config.SMS)
+	if err != nil {
+		return Adapters{}, err
+	}
+
+	mailer, err := adapters.NewMailer(config.Mail)
+	if err != nil {
+		return Adapters{}, err
+	}
+
+	blobs, err := adapters.NewBlobStore(config.Storage)
+	if err != nil {
+		return Adapters{}, err
+	}
+
 	adapters := Adapters{
-		Db: mysql,
+		Db:      mysql,
+		CheckIn: checkIn,
+		Hub:     adapters.NewEventHub(),
+		SMS:     sms,
+		Admin:   adapters.NewAdminGuard(config.App.AdminKey),
+		Mailer:  mailer,
+		Suggest: adapters.NewSuggestIndex(),
+		Blobs:   blobs,
 	}
 
 	return adapters, nil
@@ -43,10 +78,28 @@ func resolveAdapters(config config.Config) (Adapters, error) {
 func resolveRepostories(db *sql.DB) (Repositories, error) {
 	merchantRepo := repositories.NewMerchantRepository(db)
 	queueRepo := repositories.NewQueueRepository(db)
+	customerRepo := repositories.NewCustomerRepository(db)
+	privacyRepo := repositories.NewPrivacyRepository(db)
+	noShowRepo := repositories.NewNoShowRepository(db)
+	reviewRepo := repositories.NewReviewRepository(db)
+	staffRepo := repositories.NewStaffRepository(db)
+	branchRepo := repositories.NewBranchRepository(db)
+	categoryRepo := repositories.NewCategoryRepository(db)
+	imageRepo := repositories.NewImageRepository(db)
+	analyticsRepo := repositories.NewAnalyticsRepository(db)
 
 	repos := Repositories{
-		Merchant: merchantRepo,
-		Queue:    queueRepo,
+		Merchant:  merchantRepo,
+		Queue:     queueRepo,
+		Customer:  customerRepo,
+		Privacy:   privacyRepo,
+		NoShow:    noShowRepo,
+		Review:    reviewRepo,
+		Staff:     staffRepo,
+		Branch:    branchRepo,
+		Category:  categoryRepo,
+		Image:     imageRepo,
+		Analytics: analyticsRepo,
 	}
 
 	return repos, nil
@@ -0,0 +1,22 @@
+package identity
+
+import "strings"
+
+// NormalizePhone converts a phone number to E.164 so the same number typed
+// in different ways maps to a single customer. National numbers are read in
+// the default country. An empty string is returned for invalid numbers.
+func NormalizePhone(phone string) string {
+
+	normalized, err := ParsePhone(phone, defaultCountry)
+	if err != nil {
+		return ""
+	}
+
+	return normalized
+}
+
+// NormalizeEmail trims and lowercases an email address.
+func NormalizeEmail(email string) string {
+
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,46 @@
+package identity
+
+import "testing"
+
+func TestNormalizePhone(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		phone string
+		want  string
+	}{
+		{name: "default country", phone: "077 949 7842", want: "+94779497842"},
+		{name: "already E.164", phone: "+94779497842", want: "+94779497842"},
+		{name: "unparseable", phone: "12ab", want: ""},
+		{name: "empty", phone: "", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NormalizePhone(test.phone); got != test.want {
+				t.Errorf("NormalizePhone() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		email string
+		want  string
+	}{
+		{name: "mixed case", email: "Ann@Example.COM", want: "ann@example.com"},
+		{name: "surrounding space", email: "  ann@example.com\n", want: "ann@example.com"},
+		{name: "empty", email: "", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NormalizeEmail(test.email); got != test.want {
+				t.Errorf("NormalizeEmail() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package identity
+
+import (
+	"errors"
+	"strings"
+)
+
+// phoneCountry describes how national numbers of a country are written. The
+// trunk prefix is what locals dial in front of a national number, e.g. the 0
+// in 077 949 7842.
+type phoneCountry struct {
+	callingCode string
+	trunk       string
+	minLength   int
+	maxLength   int
+}
+
+var phoneCountries = map[string]phoneCountry{
+	"AE": {"971", "0", 8, 9},
+	"AU": {"61", "0", 9, 9},
+	"BD": {"880", "0", 10, 10},
+	"CA": {"1", "1", 10, 10},
+	"CN": {"86", "0", 11, 11},
+	"DE": {"49", "0", 6, 13},
+	"FR": {"33", "0", 9, 9},
+	"GB": {"44", "0", 10, 10},
+	"IN": {"91", "0", 10, 10},
+	"IT": {"39", "", 6, 11},
+	"JP": {"81", "0", 9, 10},
+	"LK": {"94", "0", 9, 9},
+	"MV": {"960", "", 7, 7},
+	"MY": {"60", "0", 9, 10},
+	"NZ": {"64", "0", 8, 10},
+	"PK": {"92", "0", 10, 10},
+	"QA": {"974", "", 8, 8},
+	"SA": {"966", "0", 9, 9},
+	"SG": {"65", "", 8, 8},
+	"US": {"1", "1", 10, 10},
+}
+
+var phoneCallingCodes = func() map[string]phoneCountry {
+	codes := make(map[string]phoneCountry, len(phoneCountries))
+
+	for _, country := range phoneCountries {
+		codes[country.callingCode] = country
+	}
+
+	return codes
+}()
+
+var defaultCountry = "LK"
+
+// IsCountry reports whether phone numbers of the ISO 3166 alpha-2 country
+// code can be parsed.
+func IsCountry(code string) bool {
+
+	_, ok := phoneCountries[strings.ToUpper(code)]
+
+	return ok
+}
+
+// DefaultCountry is used for numbers given without a country code when no
+// merchant country applies.
+func DefaultCountry() string {
+
+	return defaultCountry
+}
+
+func SetDefaultCountry(code string) error {
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	if !IsCountry(code) {
+		return errors.New("unsupported default country: " + code)
+	}
+
+	defaultCountry = code
+
+	return nil
+}
+
+// ParsePhone converts a phone number to E.164. Numbers starting with + or 00
+// carry their own country code; any other number is read as a national
+// number of the given country.
+func ParsePhone(phone string, country string) (string, error) {
+
+	phone = strings.TrimSpace(phone)
+
+	international := strings.HasPrefix(phone, "+")
+
+	var b strings.Builder
+
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			continue
+		}
+
+		if strings.ContainsRune(" -.()/+", r) {
+			continue
+		}
+
+		return "", errors.New("invalid phone number")
+	}
+
+	digits := b.String()
+
+	if !international && strings.HasPrefix(digits, "00") {
+		international = true
+		digits = digits[2:]
+	}
+
+	if international {
+		return parseInternational(digits)
+	}
+
+	c, ok := phoneCountries[strings.ToUpper(country)]
+	if !ok {
+		return "", errors.New("unsupported country: " + country)
+	}
+
+	national := digits
+
+	if len(c.trunk) != 0 && strings.HasPrefix(national, c.trunk) && len(national)-len(c.trunk) >= c.minLength {
+		national = national[len(c.trunk):]
+	}
+
+	if len(national) < c.minLength || len(national) > c.maxLength {
+		return "", errors.New("invalid phone number")
+	}
+
+	return "+" + c.callingCode + national, nil
+}
+
+func parseInternational(digits string) (string, error) {
+
+	// E.164 allows at most 15 digits including the country code.
+	if len(digits) < 8 || len(digits) > 15 || strings.HasPrefix(digits, "0") {
+		return "", errors.New("invalid phone number")
+	}
+
+	for size := 1; size <= 3; size++ {
+		c, ok := phoneCallingCodes[digits[:size]]
+		if !ok {
+			continue
+		}
+
+		national := digits[size:]
+
+		if len(national) < c.minLength || len(national) > c.maxLength {
+			return "", errors.New("invalid phone number")
+		}
+
+		break
+	}
+
+	return "+" + digits, nil
+}
@@ -0,0 +1,72 @@
+package identity
+
+import "testing"
+
+func TestParsePhone(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		phone   string
+		country string
+		want    string
+		wantErr bool
+	}{
+		{name: "national with trunk", phone: "0779497842", country: "LK", want: "+94779497842"},
+		{name: "national without trunk", phone: "779497842", country: "LK", want: "+94779497842"},
+		{name: "national with separators", phone: " 077-949 (7842) ", country: "lk", want: "+94779497842"},
+		{name: "international plus", phone: "+94 77 949 7842", country: "US", want: "+94779497842"},
+		{name: "international double zero", phone: "0094779497842", country: "US", want: "+94779497842"},
+		{name: "country without trunk", phone: "91234567", country: "SG", want: "+6591234567"},
+		{name: "north american trunk", phone: "1 415 555 0100", country: "US", want: "+14155550100"},
+		{name: "unknown calling code", phone: "+2348012345678", country: "LK", want: "+2348012345678"},
+		{name: "letters", phone: "abcdefghij", country: "LK", wantErr: true},
+		{name: "too short", phone: "07794978", country: "LK", wantErr: true},
+		{name: "too long", phone: "07794978421", country: "LK", wantErr: true},
+		{name: "international too short", phone: "+9477949", country: "LK", wantErr: true},
+		{name: "international too long", phone: "+9477949784212345", country: "LK", wantErr: true},
+		{name: "international wrong length", phone: "+9477949784", country: "LK", wantErr: true},
+		{name: "unsupported country", phone: "0779497842", country: "XX", wantErr: true},
+		{name: "empty", phone: "", country: "LK", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParsePhone(test.phone, test.country)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParsePhone() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if got != test.want {
+				t.Errorf("ParsePhone() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSetDefaultCountry(t *testing.T) {
+
+	defer SetDefaultCountry(DefaultCountry())
+
+	tests := []struct {
+		name    string
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{name: "supported", code: " gb ", want: "GB"},
+		{name: "unsupported", code: "XX", want: "GB", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := SetDefaultCountry(test.code)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("SetDefaultCountry() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if DefaultCountry() != test.want {
+				t.Errorf("DefaultCountry() = %q, want %q", DefaultCountry(), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// MaxPixels bounds the decoded size of an upload so a small file cannot
+// expand into a huge bitmap.
+const MaxPixels = 25_000_000
+
+// Decode checks the sniffed content type and dimensions before decoding the
+// image. Only JPEG, PNG and GIF are accepted; GIFs keep their first frame.
+func Decode(data []byte) (image.Image, error) {
+
+	switch http.DetectContentType(data) {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, errors.New("file must be a jpeg, png or gif image")
+	}
+
+	conf, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("image could not be read")
+	}
+
+	if conf.Width == 0 || conf.Height == 0 || conf.Width*conf.Height > MaxPixels {
+		return nil, errors.New("image dimensions are too large")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("image could not be read")
+	}
+
+	return img, nil
+}
+
+// Fit scales the image down to fit within width x height, keeping its aspect
+// ratio. Smaller images are returned unchanged, copied into RGBA.
+func Fit(img image.Image, width int, height int) *image.RGBA {
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	if w > width || h > height {
+		if w*height > h*width {
+			h = h * width / w
+			w = width
+		} else {
+			w = w * height / h
+			h = height
+		}
+	}
+
+	if w < 1 {
+		w = 1
+	}
+
+	if h < 1 {
+		h = 1
+	}
+
+	return scale(img, w, h)
+}
+
+// scale resizes by averaging every source pixel that falls into a target
+// pixel, which keeps downscaled photos smooth.
+func scale(img image.Image, width int, height int) *image.RGBA {
+
+	src := image.NewRGBA(img.Bounds())
+	draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	if sw == width && sh == height {
+		draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+		return dst
+	}
+
+	for y := 0; y < height; y++ {
+		y0 := y * sh / height
+		y1 := (y + 1) * sh / height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < width; x++ {
+			x0 := x * sw / width
+			x1 := (x + 1) * sw / width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					b += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n),
+				G: uint8(g / n),
+				B: uint8(b / n),
+				A: uint8(a / n),
+			})
+		}
+	}
+
+	return dst
+}
+
+// EncodeJPEG flattens the image onto white, since JPEG has no transparency.
+func EncodeJPEG(img image.Image) ([]byte, error) {
+
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	buf := bytes.Buffer{}
+
+	err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: 85})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func EncodePNG(img image.Image) ([]byte, error) {
+
+	buf := bytes.Buffer{}
+
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+
+	err := encoder.Encode(&buf, img)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,123 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// params are the argon2id settings new hashes are created with. They are
+// stored with every hash, so raising them only affects hashes created or
+// upgraded afterwards.
+type params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	keyLen  uint32
+}
+
+var current = params{
+	memory:  64 * 1024,
+	time:    3,
+	threads: 2,
+	keyLen:  32,
+}
+
+const (
+	prefix  = "$argon2id$"
+	saltLen = 16
+)
+
+// Hash returns the password as an argon2id hash in the PHC string format,
+// e.g. $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>.
+func Hash(password string) (string, error) {
+
+	salt := make([]byte, saltLen)
+
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, current.time, current.memory, current.threads, current.keyLen)
+
+	encoded := fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		prefix,
+		argon2.Version,
+		current.memory,
+		current.time,
+		current.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+
+	return encoded, nil
+}
+
+// Verify checks the password against a stored value. Values that are not
+// argon2id hashes are legacy plaintext passwords. rehash reports whether a
+// matching password should be stored again with Hash, because it is still
+// plaintext or was hashed with weaker settings.
+func Verify(password string, stored string) (ok bool, rehash bool, err error) {
+
+	if !strings.HasPrefix(stored, prefix) {
+		ok = subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+
+		return ok, ok, nil
+	}
+
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return false, false, errors.New("malformed password hash")
+	}
+
+	var version int
+
+	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil {
+		return false, false, err
+	}
+
+	if version != argon2.Version {
+		return false, false, errors.New("unsupported argon2 version")
+	}
+
+	p := params{}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads)
+	if err != nil {
+		return false, false, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, err
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, err
+	}
+
+	if len(salt) == 0 || len(key) == 0 || p.time == 0 || p.threads == 0 {
+		return false, false, errors.New("malformed password hash")
+	}
+
+	p.keyLen = uint32(len(key))
+
+	candidate := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, p.keyLen)
+
+	if subtle.ConstantTimeCompare(key, candidate) != 1 {
+		return false, false, nil
+	}
+
+	rehash = p.memory < current.memory || p.time < current.time || p.threads < current.threads || p.keyLen < current.keyLen
+
+	return true, rehash, nil
+}
@@ -0,0 +1,88 @@
+package password
+
+import (
+	"strings"
+	"testing"
+)
+
+// hashWith hashes the password with the given settings instead of the
+// current ones, as an older release would have.
+func hashWith(t *testing.T, p params, password string) string {
+
+	t.Helper()
+
+	saved := current
+	current = p
+	defer func() { current = saved }()
+
+	hash, err := Hash(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return hash
+}
+
+func TestHash(t *testing.T) {
+
+	first, err := Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(first, "$argon2id$v=19$m=65536,t=3,p=2$") {
+		t.Errorf("Hash() = %q, want an argon2id hash with the current settings", first)
+	}
+
+	if first == second {
+		t.Error("Hash() returned the same value twice, the salt is not random")
+	}
+}
+
+func TestVerify(t *testing.T) {
+
+	hash, err := Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weak := hashWith(t, params{memory: 8 * 1024, time: 1, threads: 1, keyLen: 32}, "correct horse")
+
+	tests := []struct {
+		name       string
+		password   string
+		stored     string
+		wantOK     bool
+		wantRehash bool
+		wantErr    bool
+	}{
+		{name: "current hash", password: "correct horse", stored: hash, wantOK: true},
+		{name: "wrong password", password: "wrong horse", stored: hash},
+		{name: "weaker hash", password: "correct horse", stored: weak, wantOK: true, wantRehash: true},
+		{name: "weaker hash wrong password", password: "wrong horse", stored: weak},
+		{name: "legacy plaintext", password: "correct horse", stored: "correct horse", wantOK: true, wantRehash: true},
+		{name: "legacy plaintext wrong password", password: "wrong horse", stored: "correct horse"},
+		{name: "missing parts", password: "correct horse", stored: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA", wantErr: true},
+		{name: "unsupported version", password: "correct horse", stored: strings.Replace(hash, "v=19", "v=16", 1), wantErr: true},
+		{name: "bad salt", password: "correct horse", stored: "$argon2id$v=19$m=65536,t=3,p=2$***$a2V5", wantErr: true},
+		{name: "zero time", password: "correct horse", stored: "$argon2id$v=19$m=65536,t=0,p=2$c2FsdA$a2V5", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ok, rehash, err := Verify(test.password, test.stored)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if ok != test.wantOK || rehash != test.wantRehash {
+				t.Errorf("Verify() = %v, %v; want %v, %v", ok, rehash, test.wantOK, test.wantRehash)
+			}
+		})
+	}
+}
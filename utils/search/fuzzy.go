@@ -0,0 +1,189 @@
+package search
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Normalize lowercases the text and collapses everything that is not a
+// letter or digit into single spaces.
+func Normalize(text string) string {
+
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.Join(fields, " ")
+}
+
+// Trigrams returns the distinct three letter slices of every word, padded
+// with a space on each side so short words still produce some.
+func Trigrams(text string) []string {
+
+	seen := make(map[string]bool)
+	trigrams := make([]string, 0)
+
+	for _, word := range strings.Fields(Normalize(text)) {
+		runes := []rune(" " + word + " ")
+
+		for i := 0; i+3 <= len(runes); i++ {
+			trigram := string(runes[i : i+3])
+
+			if !seen[trigram] {
+				seen[trigram] = true
+				trigrams = append(trigrams, trigram)
+			}
+		}
+	}
+
+	return trigrams
+}
+
+// Similarity is the share of trigrams the two texts have in common, from 0
+// to 1.
+func Similarity(a string, b string) float64 {
+
+	left := Trigrams(a)
+	right := make(map[string]bool)
+
+	for _, trigram := range Trigrams(b) {
+		right[trigram] = true
+	}
+
+	if len(left) == 0 || len(right) == 0 {
+		return 0
+	}
+
+	shared := 0
+
+	for _, trigram := range left {
+		if right[trigram] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(left)+len(right)-shared)
+}
+
+// Distance is the Levenshtein edit distance between two strings.
+func Distance(a string, b string) int {
+
+	left, right := []rune(a), []rune(b)
+
+	previous := make([]int, len(right)+1)
+	current := make([]int, len(right)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(left); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(right); j++ {
+			cost := 1
+			if left[i-1] == right[j-1] {
+				cost = 0
+			}
+
+			current[j] = previous[j-1] + cost
+
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(right)]
+}
+
+// Score rates how well the text matches the query, from 0 to 1. Exact and
+// prefix matches rank first, then substrings, then words within a small
+// edit distance, then overall trigram similarity.
+func Score(query string, text string) float64 {
+
+	query, text = Normalize(query), Normalize(text)
+
+	if len(query) == 0 || len(text) == 0 {
+		return 0
+	}
+
+	switch {
+	case text == query:
+		return 1
+	case strings.HasPrefix(text, query):
+		return 0.9
+	case strings.Contains(" "+text, " "+query):
+		return 0.8
+	case strings.Contains(text, query):
+		return 0.7
+	}
+
+	best := 0.0
+
+	for _, want := range strings.Fields(query) {
+		allowed := (len([]rune(want)) + 1) / 3
+		if allowed > 2 {
+			allowed = 2
+		}
+
+		for _, word := range strings.Fields(text) {
+			// compare against the word and against its prefix of the same
+			// length, so "dentsit" matches "dentist" and "dentistry"
+			candidates := []string{word}
+
+			if runes := []rune(word); len(runes) > len([]rune(want)) {
+				candidates = append(candidates, string(runes[:len([]rune(want))]))
+			}
+
+			for _, candidate := range candidates {
+				d := Distance(want, candidate)
+
+				if d <= allowed {
+					best = math.Max(best, 0.6-0.1*float64(d))
+				}
+			}
+		}
+	}
+
+	return math.Max(best, 0.6*Similarity(query, text))
+}
+
+// Patterns returns SQL LIKE patterns that find candidates for the query:
+// the whole query and every three letter slice of its words, so a typo in
+// one place still leaves other slices to match on. Wildcards in the query
+// are escaped.
+func Patterns(query string) []string {
+
+	escape := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+	query = Normalize(query)
+	if len(query) == 0 {
+		return nil
+	}
+
+	seen := map[string]bool{query: true}
+	patterns := []string{"%" + escape.Replace(query) + "%"}
+
+	for _, word := range strings.Fields(query) {
+		runes := []rune(word)
+
+		for i := 0; i+3 <= len(runes); i++ {
+			slice := string(runes[i : i+3])
+
+			if !seen[slice] {
+				seen[slice] = true
+				patterns = append(patterns, "%"+escape.Replace(slice)+"%")
+			}
+		}
+	}
+
+	return patterns
+}
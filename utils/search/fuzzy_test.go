@@ -0,0 +1,120 @@
+package search
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "punctuation and case", text: "  Dr. Silva's  Clinic!! ", want: "dr silva s clinic"},
+		{name: "unicode letters", text: "Café-Zürich", want: "café zürich"},
+		{name: "only symbols", text: "%_-", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Normalize(test.text); got != test.want {
+				t.Errorf("Normalize() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTrigrams(t *testing.T) {
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "short word is padded", text: "ab", want: []string{" ab", "ab "}},
+		{name: "repeated words once", text: "a A", want: []string{" a "}},
+		{name: "empty", text: "", want: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Trigrams(test.text); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Trigrams() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "flaw", b: "lawn", want: 2},
+		{a: "", b: "abc", want: 3},
+		{a: "abc", b: "abc", want: 0},
+		{a: "café", b: "cafe", want: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.a+"/"+test.b, func(t *testing.T) {
+			if got := Distance(test.a, test.b); got != test.want {
+				t.Errorf("Distance() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		query string
+		text  string
+		want  float64
+	}{
+		{name: "exact", query: "Dentist", text: "dentist", want: 1},
+		{name: "prefix", query: "dent", text: "Dentist Care", want: 0.9},
+		{name: "word", query: "care", text: "Dentist Care", want: 0.8},
+		{name: "substring", query: "tist", text: "dentist", want: 0.7},
+		{name: "one typo", query: "dentst", text: "dentist", want: 0.5},
+		{name: "transposed letters", query: "dentsit", text: "dentist", want: 0.4},
+		{name: "typo in prefix", query: "dentsit", text: "dentistry clinic", want: 0.4},
+		{name: "unrelated", query: "bakery", text: "dentist", want: 0},
+		{name: "empty query", query: "", text: "dentist", want: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Score(test.query, test.text); math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("Score() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPatterns(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "word slices", query: "Dentist", want: []string{"%dentist%", "%den%", "%ent%", "%nti%", "%tis%", "%ist%"}},
+		{name: "wildcards dropped", query: "50%_off", want: []string{"%50 off%", "%off%"}},
+		{name: "empty", query: " ", want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Patterns(test.query); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Patterns() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}